@@ -39,6 +39,7 @@ func NewRootCmd(version, buildDate, commitSHA string) *cobra.Command {
 		NewAgentCmd(kubeCfg, namespace),
 		NewTaskCmd(kubeCfg, namespace),
 		NewClusterCmd(kubeCfg),
+		NewAuditCmd(),
 		newVersionCmd(version),
 		newCompletionCmd(),
 	)
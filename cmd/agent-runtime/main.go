@@ -2,43 +2,235 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/agent"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/observability"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/tools"
 )
 
 const ServiceName = "agent-runtime"
 
-func main() {
-	// TODO: This main function is broken and needs to be fixed.
-	// Commenting out for now to allow compilation.
-	log.Println("agent-runtime is starting...")
+// runtimeConfig 以 internal/config 为底，运行时专属项取环境变量；
+// 监听地址由 agent.EnvListenAddr、实例 ID 由 RUNTIME_ID 在 agent.New 中读取
+type runtimeConfig struct {
+	ProbeAddr       string // healthz/readyz/metrics
+	MetricsPath     string // 为空时不暴露指标
+	Image           string // 任务未指定镜像时使用
+	SandboxType     string
+	Syscalls        []string             // 匹配到的 SecurityPolicy 额外禁止的系统调用，由控制器注入
+	CPU, Memory     string               // 实例容量，随心跳上报
+	Agent           types.NamespacedName // 所属 Agent，为空时不上报心跳、工具用内存注册表
+	GracefulTimeout time.Duration        // 排空进行中任务的上限
+	LogLevel        string
+	Audit           config.Audit
 }
 
-/*
-// ------------------- 配置组装 -------------------
-func loadRuntimeConfig() (*agent.Config, error) {
-	cfg := &agent.Config{}
-	cfg.Observability.Port = mustEnv("OTEL_PORT", "8081")
-	cfg.Sandbox.Type = mustEnv("SANDBOX_TYPE", "gvisor")
-	cfg.Tools.GatewayAddr = mustEnv("TOOL_GATEWAY", "")
-	return cfg, nil
+func loadRuntimeConfig() (*runtimeConfig, error) {
+	if err := config.Init(os.Getenv("AIAI_MODE")); err != nil {
+		return nil, err
+	}
+	c := config.Get()
+	rc := &runtimeConfig{
+		ProbeAddr:       envWithDefault("AGENT_PROBE_ADDR", fmt.Sprintf(":%d", c.Server.HTTPPort)),
+		Image:           os.Getenv("AGENT_IMAGE"),
+		SandboxType:     envWithDefault(constants.EnvSandboxType, c.Sandbox.Type),
+		CPU:             os.Getenv(constants.EnvAgentCPU),
+		Memory:          os.Getenv(constants.EnvAgentMemory),
+		Agent:           types.NamespacedName{Namespace: os.Getenv(constants.EnvAgentNamespace), Name: os.Getenv(constants.EnvAgentName)},
+		GracefulTimeout: c.Server.GracefulTimeout,
+		LogLevel:        c.Log.Level,
+		Audit:           c.Security.Audit,
+	}
+	if v := os.Getenv(constants.EnvRestrictedSyscalls); v != "" {
+		rc.Syscalls = strings.Split(v, ",")
+	}
+	if c.Observability.MetricsEnabled {
+		rc.MetricsPath = c.Observability.MetricsPath
+	}
+	if (rc.Agent.Name == "") != (rc.Agent.Namespace == "") {
+		return nil, fmt.Errorf("%s and %s must be set together", constants.EnvAgentName, constants.EnvAgentNamespace)
+	}
+	if v := os.Getenv("AGENT_GRACEFUL_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("AGENT_GRACEFUL_TIMEOUT: %w", err)
+		}
+		rc.GracefulTimeout = d
+	}
+	return rc, nil
 }
 
-func mustEnv(k, defVal string) string {
+func envWithDefault(k, defVal string) string {
 	if v := os.Getenv(k); v != "" {
 		return v
 	}
 	return defVal
 }
 
-// ------------------- 探针 -------------------
-func startProbe() {
+func main() {
+	cfg, err := loadRuntimeConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	level := constants.DefaultLogLevel
+	if cfg.LogLevel != "" {
+		if level, err = zapcore.ParseLevel(cfg.LogLevel); err != nil {
+			log.Fatalf("log level: %v", err)
+		}
+	}
+	if err := logger.Init(level, "json"); err != nil {
+		log.Fatalf("init logger: %v", err)
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, cfg); err != nil {
+		logger.Error(ctx, "agent-runtime exited", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *runtimeConfig) error {
+	shutdownTracing, err := observability.InitTracing(ctx, ServiceName)
+	if err != nil {
+		logger.Warn(ctx, "tracing disabled", zap.Error(err))
+	} else {
+		defer shutdownTracing()
+	}
+	closeAudit, err := security.InitAudit(cfg.Audit)
+	if err != nil {
+		return err
+	}
+	defer closeAudit()
+
+	opts := []agent.Option{
+		agent.WithSandboxType(sandbox.Type(cfg.SandboxType)),
+		agent.WithRestrictedSyscalls(cfg.Syscalls...),
+	}
+	if cfg.Agent.Name != "" {
+		kubeOpts, err := kubeOptions(ctx, cfg.Agent)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, kubeOpts...)
+	} else {
+		logger.Warn(ctx, "AGENT_NAME not set, heartbeats disabled and only in-memory tools available")
+	}
+
+	spec := &agent.AgentSpec{Image: cfg.Image}
+	if cfg.CPU != "" || cfg.Memory != "" {
+		spec.ResourceQuota = &agent.ResourceQuota{CPU: cfg.CPU, Mem: cfg.Memory}
+	}
+	rt, err := agent.New(spec, opts...)
+	if err != nil {
+		return err
+	}
+
+	probe := &http.Server{Addr: cfg.ProbeAddr, Handler: probeMux(rt, cfg.MetricsPath), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := probe.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(ctx, "probe server failed", zap.Error(err))
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = probe.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info(ctx, "agent-runtime serving", zap.String("id", rt.ID), zap.String("addr", rt.Listener.Addr().String()),
+		zap.String("probe", cfg.ProbeAddr))
+	// 收到 SIGTERM 后 readyz 立即失败，进行中的任务在 GracefulTimeout 内跑完
+	if err := rt.Run(ctx, cfg.GracefulTimeout); err != nil {
+		logger.Warn(ctx, "in-flight tasks cancelled at shutdown", zap.Error(err))
+	}
+	return nil
+}
+
+// kubeOptions 工具取所属命名空间的 Tool CRD，心跳写回所属 Agent 的 status
+func kubeOptions(ctx context.Context, owner types.NamespacedName) ([]agent.Option, error) {
+	restCfg, err := ctrlconfig.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("kube config: %w", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	informers, err := cache.New(restCfg, cache.Options{
+		Scheme:            scheme,
+		DefaultNamespaces: map[string]cache.Config{owner.Namespace: {}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tool cache: %w", err)
+	}
+	cached, err := client.New(restCfg, client.Options{Scheme: scheme, Cache: &client.CacheOptions{Reader: informers}})
+	if err != nil {
+		return nil, fmt.Errorf("kube client: %w", err)
+	}
+	reg, err := tools.NewCRDRegistry(ctx, cached, informers, owner.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := informers.Start(ctx); err != nil {
+			logger.Error(ctx, "tool cache stopped", zap.Error(err))
+		}
+	}()
+	if !informers.WaitForCacheSync(ctx) {
+		return nil, fmt.Errorf("tool cache did not sync")
+	}
+
+	// 心跳读写直达 apiserver，避免缓存滞后导致的反复冲突
+	direct, err := client.New(restCfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("kube client: %w", err)
+	}
+	return []agent.Option{
+		agent.WithToolRegistry(reg),
+		agent.WithReporter(&agent.StatusReporter{Client: direct, Agent: owner}),
+	}, nil
+}
+
+func probeMux(rt *agent.Runtime, metricsPath string) *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("ok")) })
-	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("ready")) })
-	mux.Handle("/metrics", observability.MustHandler())
-	s := &http.Server{Addr: ":8080", Handler: mux}
-	log.Println("🔥 agent-runtime probe on :8080")
-	_ = s.ListenAndServe()
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !rt.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ready"))
+	})
+	if metricsPath != "" {
+		mux.Handle(metricsPath, observability.Handler())
+	}
+	return mux
 }
-*/
+
 //Personal.AI order the ending
@@ -2,12 +2,86 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/controller"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/webhook"
 )
 
+const ServiceName = "controller"
+
 func main() {
-	// TODO: This main function is broken and needs to be fixed.
-	// Commenting out for now to allow compilation.
-	log.Println("controller is starting...")
+	if err := config.Init(os.Getenv("AIAI_MODE")); err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	cfg := config.Get()
+
+	opts := controller.ManagerOptions{
+		K8sController: cfg.K8sController,
+		Admission:     webhook.Options{Sandbox: cfg.Sandbox, AllowedImages: cfg.Security.AllowedImages},
+	}
+	flag.StringVar(&opts.MetricsAddr, "metrics-bind-address", fmt.Sprintf(":%d", cfg.Server.MetricsPort),
+		`metrics endpoint address, "0" disables it`)
+	flag.StringVar(&opts.ProbeAddr, "health-probe-bind-address", ":8081", "healthz/readyz endpoint address")
+	flag.BoolVar(&opts.LeaderElection, "leader-elect", false, "enable leader election for multi-replica deployments")
+	flag.StringVar(&opts.LeaderElectionNamespace, "leader-election-namespace", "",
+		"namespace of the leader election lease, required when running outside the cluster")
+	flag.IntVar(&opts.WebhookPort, "webhook-port", 0,
+		fmt.Sprintf("admission webhook port (usually %d), 0 disables the webhooks and Agent defaults are applied to the generated Deployment only",
+			constants.DefaultWebhookPort))
+	flag.StringVar(&opts.WebhookCertDir, "webhook-cert-dir", "", "directory holding the webhook tls.crt and tls.key")
+	flag.StringVar(&opts.K8sController.WatchNamespace, "namespace", cfg.K8sController.WatchNamespace,
+		"only watch this namespace, empty for all")
+	flag.DurationVar(&opts.K8sController.ToolResync, "tool-resync", cfg.K8sController.ToolResync,
+		"how often OpenAPI documents bound to Tools are fetched again when unchanged")
+	flag.Parse()
+
+	level := constants.DefaultLogLevel
+	if cfg.Log.Level != "" {
+		var err error
+		if level, err = zapcore.ParseLevel(cfg.Log.Level); err != nil {
+			log.Fatalf("log level: %v", err)
+		}
+	}
+	if err := logger.Init(level, "json"); err != nil {
+		log.Fatalf("init logger: %v", err)
+	}
+	defer logger.Sync()
+	ctrl.SetLogger(ctrlzap.New(ctrlzap.Level(level)))
+	closeAudit, err := security.InitAudit(cfg.Security.Audit)
+	if err != nil {
+		log.Fatalf("init audit: %v", err)
+	}
+	defer closeAudit()
+
+	ctx := ctrl.SetupSignalHandler()
+	mgr, err := controller.NewManager(ctx, ctrl.GetConfigOrDie(), opts)
+	if err != nil {
+		logger.Error(ctx, "create manager", zap.Error(err))
+		os.Exit(1)
+	}
+	logger.Info(ctx, "controller manager starting",
+		zap.Bool("leaderElection", opts.LeaderElection),
+		zap.String("leaderElectionID", opts.K8sController.LeaderElectionID),
+		zap.String("namespace", opts.K8sController.WatchNamespace),
+		zap.Int("webhookPort", opts.WebhookPort),
+		zap.Int("workers", opts.K8sController.WorkerThreads))
+	if err := mgr.Start(ctx); err != nil {
+		logger.Error(ctx, "controller manager exited", zap.Error(err))
+		os.Exit(1)
+	}
 }
+
 //Personal.AI order the ending
@@ -2,25 +2,106 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/gateway"
+	"github.com/turtacn/agenticai/pkg/observability"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/tools"
 )
 
 const ServiceName = "tool-gateway"
 
-func main() {
-	// TODO: This main function is broken and needs to be fixed.
-	// Commenting out for now to allow compilation.
-	log.Println("tool-gateway is starting...")
+// anonymousCaller 既无 mTLS 也无 JWT 时 MCP 与 REST API 的调用方身份
+const anonymousCaller = "anonymous"
+
+// gwConfig 除审计外全部来自环境变量
+type gwConfig struct {
+	ListenAddr  string // MCP streamable HTTP
+	APIAddr     string // Gateway REST API
+	ProbeAddr   string // healthz/readyz/metrics
+	MCPPath     string
+	Namespace   string // 读取 Tool CRD 的命名空间，空为全部
+	PolicyPath  string // SecurityPolicy 文件，空则不做授权，仅 Insecure 时允许
+	Insecure    bool   // 允许不配置策略启动，只用于开发环境
+	SandboxType string
+	StdioCaller string // stdio 模式下的调用方身份
+
+	// MCP 与 REST API 的 JWT 认证，JWKS 与 HS256 密钥都未配置时只接受 SPIFFE 客户端证书
+	JWTIssuer     string
+	JWTAudience   []string
+	JWKSURL       string
+	JWKSFile      string
+	JWTSecretFile string
+
+	// Audit 取自 config 的 security.audit，可用 AIAI_SECURITY_AUDIT_* 覆盖
+	Audit config.Audit
+}
+
+func (c *gwConfig) jwtEnabled() bool {
+	return c.JWKSURL != "" || c.JWKSFile != "" || c.JWTSecretFile != ""
+}
+
+func loadGwConfig() (*gwConfig, error) {
+	if err := config.Init(os.Getenv("AIAI_MODE")); err != nil {
+		return nil, err
+	}
+	return &gwConfig{
+		ListenAddr:  envWithDefault("TOOLGW_ADDR", ":8082"),
+		APIAddr:     envWithDefault("TOOLGW_API_ADDR", ":8083"),
+		ProbeAddr:   envWithDefault("TOOLGW_PROBE_ADDR", ":8084"),
+		MCPPath:     envWithDefault("TOOLGW_MCP_PATH", "/mcp"),
+		Namespace:   os.Getenv("TOOLGW_NAMESPACE"),
+		PolicyPath:  os.Getenv("TOOLGW_POLICY"),
+		SandboxType: envWithDefault("TOOLGW_SANDBOX", string(sandbox.TypeGvisor)),
+		StdioCaller: os.Getenv("TOOLGW_STDIO_CALLER"),
+
+		JWTIssuer:     os.Getenv("TOOLGW_JWT_ISSUER"),
+		JWTAudience:   splitList(os.Getenv("TOOLGW_JWT_AUDIENCE")),
+		JWKSURL:       os.Getenv("TOOLGW_JWKS_URL"),
+		JWKSFile:      os.Getenv("TOOLGW_JWKS_FILE"),
+		JWTSecretFile: os.Getenv("TOOLGW_JWT_SECRET_FILE"),
+
+		Audit: config.Get().Security.Audit,
+	}, nil
 }
 
-/*
-// ------------------- 配置函数 -------------------
-func loadGwConfig() (*gateway.Config, error) {
-	cfg := gateway.DefaultConfig()
-	cfg.ListenAddr = envWithDefault("TOOLGW_ADDR", ":8082")
-	cfg.ToolsConfigPath = envWithDefault("TOOLGW_CONFIG", "/etc/agentic/gw-tools.json")
-	cfg.Observability.Port = envWithDefault("OTEL_PORT", "8083")
-	return cfg, nil
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
 }
 
 func envWithDefault(k, defVal string) string {
@@ -30,15 +111,258 @@ func envWithDefault(k, defVal string) string {
 	return defVal
 }
 
-// ------------------- 探针 -------------------
-func startProbe(cfg *gateway.Config) {
+func main() {
+	stdio := flag.Bool("stdio", false, "serve MCP over stdin/stdout instead of streamable HTTP")
+	insecure := flag.Bool("insecure", false, "allow starting without TOOLGW_POLICY; every caller may list and call every tool")
+	flag.Parse()
+	cfg, err := loadGwConfig()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	cfg.Insecure = *insecure
+
+	// stdio 模式下 stdout 是协议通道，日志改写 stderr
+	out := os.Stdout
+	if *stdio {
+		out = os.Stderr
+	}
+	if err := logger.InitWriter(out, constants.DefaultLogLevel, "json"); err != nil {
+		log.Fatalf("init logger: %v", err)
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, cfg, *stdio); err != nil {
+		logger.Error(ctx, "tool-gateway exited", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *gwConfig, stdio bool) error {
+	if cfg.PolicyPath == "" && !cfg.Insecure {
+		return fmt.Errorf("TOOLGW_POLICY not set; pass --insecure to serve without authorization")
+	}
+	// stdio 模式下 stdout 是协议通道，审计与日志一样改写 stderr
+	auditOut := os.Stdout
+	if stdio {
+		auditOut = os.Stderr
+	}
+	closeAudit, err := security.InitAuditTo(cfg.Audit, auditOut)
+	if err != nil {
+		return err
+	}
+	defer closeAudit()
+
+	reg, err := newRegistry(ctx, cfg.Namespace)
+	if err != nil {
+		return err
+	}
+	sb, err := sandbox.NewManager(ctx, "", sandbox.Type(cfg.SandboxType))
+	if err != nil {
+		return err
+	}
+	defer sb.Close()
+	inv := tools.NewInvoker(reg, sb, tools.WithSandboxType(sandbox.Type(cfg.SandboxType)))
+	defer inv.Close()
+
+	var (
+		opts   []tools.MCPServerOption
+		gwOpts []gateway.Option
+	)
+	if cfg.PolicyPath != "" {
+		rbac, err := loadPolicy(cfg.PolicyPath)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, tools.WithMCPAuthorizer(rbac))
+		gwOpts = append(gwOpts, gateway.WithAuthorizer(rbac))
+	} else {
+		logger.Warn(ctx, "--insecure and TOOLGW_POLICY not set, every caller may list and call every tool")
+	}
+	srv := tools.NewMCPServer(reg, inv, opts...)
+
+	if stdio {
+		logger.Info(ctx, "tool-gateway serving MCP on stdio", zap.String("caller", cfg.StdioCaller))
+		return srv.ServeStdio(security.WithCaller(ctx, cfg.StdioCaller), os.Stdin, os.Stdout)
+	}
+	authn, err := newAuthenticator(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	gwOpts = append(gwOpts, gateway.WithAuthenticator(authn))
+	api := gateway.New(&config.GatewayConfig{Listen: cfg.APIAddr}, reg, inv, gwOpts...)
+	return serveHTTP(ctx, cfg, gateway.AuthenticateHTTP(authn, srv), api)
+}
+
+// newAuthenticator MCP 与 REST API 共用：配置了 JWT 时 bearer token 与 SPIFFE 证书都接受，
+// 否则只认 SPIFFE 证书；两者都没有时所有请求视为匿名调用方
+func newAuthenticator(ctx context.Context, cfg *gwConfig) (gateway.Authenticator, error) {
+	switch {
+	case cfg.jwtEnabled():
+		v, err := newJWTVerifier(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return gateway.NewAuthenticator(v), nil
+	case os.Getenv(workloadapi.SocketEnv) != "":
+		return gateway.SPIFFEAuthenticator(), nil
+	default:
+		return gateway.Anonymous(anonymousCaller), nil
+	}
+}
+
+func newJWTVerifier(ctx context.Context, cfg *gwConfig) (*security.JWTVerifier, error) {
+	jwtCfg := security.JWTConfig{
+		Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience, JWKSURL: cfg.JWKSURL, JWKSFile: cfg.JWKSFile,
+	}
+	if cfg.JWTSecretFile != "" {
+		secret, err := os.ReadFile(cfg.JWTSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("read jwt secret: %w", err)
+		}
+		if jwtCfg.HMACSecret = bytes.TrimSpace(secret); len(jwtCfg.HMACSecret) == 0 {
+			return nil, fmt.Errorf("jwt secret %s is empty", cfg.JWTSecretFile)
+		}
+	}
+	if len(cfg.JWTAudience) == 0 {
+		logger.Warn(ctx, "TOOLGW_JWT_AUDIENCE not set, tokens issued for any audience are accepted")
+	}
+	return security.NewJWTVerifier(ctx, jwtCfg)
+}
+
+// newRegistry 以 Tool CRD 为后端，等待 informer 首次同步完成
+func newRegistry(ctx context.Context, namespace string) (tools.Registry, error) {
+	restCfg, err := ctrlconfig.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("kube config: %w", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	cacheOpts := cache.Options{Scheme: scheme}
+	if namespace != "" {
+		cacheOpts.DefaultNamespaces = map[string]cache.Config{namespace: {}}
+	}
+	informers, err := cache.New(restCfg, cacheOpts)
+	if err != nil {
+		return nil, fmt.Errorf("tool cache: %w", err)
+	}
+	c, err := client.New(restCfg, client.Options{Scheme: scheme, Cache: &client.CacheOptions{Reader: informers}})
+	if err != nil {
+		return nil, fmt.Errorf("kube client: %w", err)
+	}
+	reg, err := tools.NewCRDRegistry(ctx, c, informers, namespace)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := informers.Start(ctx); err != nil {
+			logger.Error(ctx, "tool cache stopped", zap.Error(err))
+		}
+	}()
+	if !informers.WaitForCacheSync(ctx) {
+		return nil, fmt.Errorf("tool cache did not sync")
+	}
+	return reg, nil
+}
+
+// loadPolicy 读取 YAML/JSON 格式的 SecurityPolicy
+func loadPolicy(path string) (security.RBAC, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	var pol apis.SecurityPolicy
+	if err := yaml.Unmarshal(raw, &pol); err != nil {
+		return nil, fmt.Errorf("parse policy %s: %w", path, err)
+	}
+	rbac := security.NewRBAC()
+	if err := rbac.UpdatePolicy(&pol); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", path, err)
+	}
+	return rbac, nil
+}
+
+// serveHTTP 同时提供 MCP 与 REST API，mcp 已带认证。存在 SPIFFE Workload API 时两者都启用 mTLS，
+// 调用方身份取对端 SVID；启用 JWT 时客户端证书改为可选。
+// 没有 SPIFFE 时提供明文 HTTP，以 JWT 或匿名身份认证
+func serveHTTP(ctx context.Context, cfg *gwConfig, mcp http.Handler, api *gateway.Gateway) error {
+	mux := http.NewServeMux()
+	mux.Handle(cfg.MCPPath, mcp)
+	server := &http.Server{Addr: cfg.ListenAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	probe := &http.Server{Addr: cfg.ProbeAddr, Handler: probeMux(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := probe.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(ctx, "probe server failed", zap.Error(err))
+		}
+	}()
+
+	serve, serveAPI := server.ListenAndServe, api.ListenAndServe
+	if os.Getenv(workloadapi.SocketEnv) != "" {
+		src, err := workloadapi.NewX509Source(ctx)
+		if err != nil {
+			return fmt.Errorf("spiffe source: %w", err)
+		}
+		defer src.Close()
+		td, err := spiffeid.TrustDomainFromString(constants.TrustDomain)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = tlsconfig.MTLSServerConfig(src, src, tlsconfig.AuthorizeMemberOf(td))
+		if cfg.jwtEnabled() {
+			server.TLSConfig = optionalClientCert(server.TLSConfig)
+		}
+		api.TLSConfig = server.TLSConfig
+		serve = func() error { return server.ListenAndServeTLS("", "") }
+		serveAPI = func() error { return api.ListenAndServeTLS("", "") }
+	} else {
+		logger.Warn(ctx, "SPIFFE workload API not available, serving MCP and REST API over plain HTTP")
+	}
+
+	errc := make(chan error, 2)
+	go func() { errc <- serve() }()
+	go func() { errc <- serveAPI() }()
+	logger.Info(ctx, "tool-gateway serving MCP", zap.String("addr", cfg.ListenAddr), zap.String("path", cfg.MCPPath))
+	logger.Info(ctx, "tool-gateway serving REST API", zap.String("addr", cfg.APIAddr))
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	_ = probe.Shutdown(shutdownCtx)
+	apiErr := api.Stop()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return apiErr
+}
+
+// optionalClientCert 客户端证书可选，但提供了就必须通过 SPIFFE 校验
+func optionalClientCert(base *tls.Config) *tls.Config {
+	cfg := base.Clone()
+	verify := base.VerifyPeerCertificate
+	cfg.ClientAuth = tls.RequestClientCert
+	cfg.VerifyPeerCertificate = func(raw [][]byte, chains [][]*x509.Certificate) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return verify(raw, chains)
+	}
+	return cfg
+}
+
+func probeMux() *http.ServeMux {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("ok")) })
 	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("ready")) })
-	mux.Handle("/metrics", observability.MustHandler())
-	s := &http.Server{Addr: ":8084", Handler: mux}
-	log.Println("🩺 probe on :8084")
-	_ = s.ListenAndServe()
+	mux.Handle("/metrics", observability.Handler())
+	return mux
 }
-*/
+
 //Personal.AI order the ending
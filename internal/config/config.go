@@ -2,6 +2,7 @@
 package config
 
 import (
+	stderrors "errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -58,6 +59,8 @@ type K8sController struct {
 	WorkerThreads    int    `mapstructure:"worker_threads"` // 默认 4
 	LeaderElectionID string `mapstructure:"leader_election_id"`
 	WatchNamespace   string `mapstructure:"watch_namespace"` // ""  = all
+	// ToolResync 重新拉取 Tool 绑定的 OpenAPI 文档的间隔，<=0 时取控制器默认值
+	ToolResync time.Duration `mapstructure:"tool_resync"`
 }
 
 type Observability struct {
@@ -70,6 +73,21 @@ type Observability struct {
 type Security struct {
 	TrustDomain     string `mapstructure:"trust_domain"`     // SPIFFE
 	KeyStoreBackend string `mapstructure:"keystore_backend"` // k8s/vault
+	// AllowedImages Agent/Task/自定义工具可用的镜像 glob，空为不限制
+	AllowedImages []string `mapstructure:"allowed_images"`
+	Audit         Audit    `mapstructure:"audit"`
+}
+
+// Audit 审计记录的去向，file 按大小与时间轮转
+type Audit struct {
+	Sink           string        `mapstructure:"sink"` // stdout/file/webhook/none
+	Path           string        `mapstructure:"path"`
+	MaxSizeMB      int           `mapstructure:"max_size_mb"`     // 0 为不按大小轮转
+	RotateInterval time.Duration `mapstructure:"rotate_interval"` // 0 为不按时间轮转
+	MaxBackups     int           `mapstructure:"max_backups"`     // 0 为保留全部
+	KeyFile        string        `mapstructure:"key_file"`        // 非空时哈希链用其中的密钥做 HMAC
+	WebhookURL     string        `mapstructure:"webhook_url"`
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"`
 }
 
 type Sandbox struct {
@@ -117,7 +135,9 @@ func Init(modeOpt ...string) error {
 	// 默认值
 	setDefaults()
 
-	if err := v.ReadInConfig(); err != nil {
+	// 没有配置文件时只用默认值与环境变量
+	var notFound viper.ConfigFileNotFoundError
+	if err := v.ReadInConfig(); err != nil && !stderrors.As(err, &notFound) {
 		logger.Error(nil, "failed to read default config", zap.Error(err))
 		return errors.E(errors.KindInternal, err, "config load failure")
 	}
@@ -158,6 +178,7 @@ func setDefaults() {
 	v.SetDefault("k8s_controller.worker_threads", constants.ControllerWorkerThreads)
 	v.SetDefault("k8s_controller.leader_election_id", fmt.Sprintf("%s-leader", constants.ProjectName))
 	v.SetDefault("k8s_controller.watch_namespace", metav1.NamespaceAll)
+	v.SetDefault("k8s_controller.tool_resync", 10*time.Minute)
 
 	v.SetDefault("observability.metrics_enabled", true)
 	v.SetDefault("observability.metrics_path", "/metrics")
@@ -165,8 +186,16 @@ func setDefaults() {
 
 	v.SetDefault("security.trust_domain", constants.TrustDomain)
 	v.SetDefault("security.keystore_backend", "k8s")
-
-	v.SetDefault("sandbox.type", "gvisor")
+	// 审计记录与 JSON 日志交错输出，需要独立、可校验的审计文件时改用 file 并挂载可写目录
+	v.SetDefault("security.audit.sink", "stdout")
+	v.SetDefault("security.audit.path", constants.DefaultAuditPath)
+	v.SetDefault("security.audit.max_size_mb", 100)
+	v.SetDefault("security.audit.rotate_interval", 24*time.Hour)
+	v.SetDefault("security.audit.max_backups", 10)
+	v.SetDefault("security.audit.webhook_timeout", 5*time.Second)
+	v.SetDefault("security.audit.key_file", "") // 登记键名，AIAI_SECURITY_AUDIT_KEY_FILE 才能生效
+
+	v.SetDefault("sandbox.type", constants.DefaultSandboxType)
 	v.SetDefault("sandbox.cpu_limit", constants.DefaultSandboxCPU)
 	v.SetDefault("sandbox.memory_limit", constants.DefaultSandboxMemory)
 }
@@ -187,6 +216,19 @@ func validate(c *Config) error {
 			return errors.E(errors.KindValidation, fmt.Sprintf("invalid log level %q", c.Log.Level))
 		}
 	}
+	switch a := c.Security.Audit; a.Sink {
+	case "", "none", "stdout":
+	case "file":
+		if a.Path == "" {
+			return errors.E(errors.KindValidation, "security.audit.path required for file sink")
+		}
+	case "webhook":
+		if a.WebhookURL == "" {
+			return errors.E(errors.KindValidation, "security.audit.webhook_url required for webhook sink")
+		}
+	default:
+		return errors.E(errors.KindValidation, fmt.Sprintf("invalid audit sink %q", a.Sink))
+	}
 	return nil
 }
 
@@ -30,6 +30,19 @@ const (
 	DefaultNamespace        = "agenticai-system"
 	AgentCRDName            = "agents.agenticai.io"
 	TaskCRDName             = "tasks.agenticai.io"
+	EnvPinnedTools          = "AGENTICAI_TOOLS" // 任务容器内已钉死版本的工具引用，逗号分隔
+	EnvContextID            = "AGENTICAI_CONTEXT_ID" // 任务关联的 ModelContextService 上下文 ID
+	EnvRuntimeID            = "RUNTIME_ID"           // agent-runtime 实例 ID，取 Pod 名
+	EnvAgentName            = "AGENT_NAME"           // agent-runtime 所属 Agent 对象
+	EnvAgentNamespace       = "AGENT_NAMESPACE"
+	EnvAgentCPU             = "AGENT_CPU"    // agent-runtime 实例容量，随心跳上报
+	EnvAgentMemory          = "AGENT_MEMORY"
+	EnvSandboxType          = "SANDBOX_TYPE" // agent-runtime 启动的沙箱类型
+	EnvRestrictedSyscalls   = "AGENTICAI_RESTRICTED_SYSCALLS" // 匹配策略要求沙箱内额外禁止的系统调用，逗号分隔
+	AgentHeartbeatInterval  = 15 * time.Second
+	AgentHeartbeatMisses    = 3 // 连续错过的心跳数，超过即视为不可用
+	DefaultTaskTimeout      = time.Hour // 未指定 Timeout 的任务由 webhook 补齐
+	DefaultWebhookPort      = 9443
 )
 
 // Security
@@ -38,6 +51,14 @@ const (
 	SVIDTTLMinutes          = 60
 	SVIDRefreshThresholdPct = 50
 	DefaultSecurityPolicy   = "default"
+	// AnnotationRestrictedSyscalls 匹配策略要求禁止的系统调用，逗号分隔
+	AnnotationRestrictedSyscalls = "agenticai.io/restricted-syscalls"
+	// AnnotationSecurityPolicies 作用于该 Pod 模板的策略 namespace/name，逗号分隔
+	AnnotationSecurityPolicies = "agenticai.io/security-policies"
+	// SeccompProfilesConfigMap 生成的 seccomp profile，由 config/seccomp 中的 DaemonSet 同步到各节点
+	SeccompProfilesConfigMap = "agenticai-seccomp-profiles"
+	// DefaultAuditPath file 审计 sink 的默认路径，轮转后的文件以 .<时间戳> 为后缀
+	DefaultAuditPath = "/var/log/agenticai/audit.jsonl"
 )
 
 // Observability
@@ -52,6 +73,7 @@ const (
 	DefaultSandboxCPU    = "500m"
 	DefaultSandboxMemory = "512Mi"
 	DefaultSandboxDisk   = "1Gi"
+	DefaultSandboxType   = "gvisor"
 	LabelIsAgent         = "agenticai.io/is-agent"
 )
 
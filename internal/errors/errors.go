@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Kind - 错误分类代码（可用于日志、告警、国际化）
@@ -19,6 +23,7 @@ const (
 	KindTimeout     Kind = "timeout"      // 超时
 	KindUnavailable Kind = "unavailable"  // 服务不可用
 	KindCancelled   Kind = "cancelled"    // 任务取消
+	KindRateLimited Kind = "rate_limited" // 超出限流配额
 )
 
 // Error - 自定义错误类型
@@ -28,6 +33,8 @@ type Error struct {
 	Msg   string // 描述文本
 	Err   error  // 原始错误
 	Stack []byte // 调用栈快照
+
+	RetryAfter time.Duration // 建议的重试间隔，0 表示未知
 }
 
 //
@@ -44,6 +51,8 @@ func E(args ...interface{}) *Error {
 			e.Err = v
 		case string:
 			e.Msg = v
+		case time.Duration:
+			e.RetryAfter = v
 		default:
 			panic(fmt.Errorf("errors.E: bad arg %T", arg))
 		}
@@ -78,6 +87,24 @@ func (e *Error) As(target interface{}) bool {
 	return errors.As(e.Err, target)
 }
 
+// KindOf 取错误链上第一个 *Error 的分类；非本包错误视为 internal
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// RetryAfterOf 取错误链上第一个 *Error 的重试建议
+func RetryAfterOf(err error) time.Duration {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.RetryAfter
+	}
+	return 0
+}
+
 //
 // HTTP mapping
 //
@@ -98,11 +125,43 @@ func (e *Error) HTTPStatus() int {
 		return http.StatusBadRequest
 	case KindCancelled:
 		return http.StatusRequestTimeout
+	case KindRateLimited:
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
+//
+// gRPC mapping
+//
+
+// GRPCStatus 供 grpc 服务端把返回的 *Error 转为对应状态码；冲突映射为 Aborted，提示调用方重读后重试
+func (e *Error) GRPCStatus() *status.Status {
+	var code codes.Code
+	switch e.Kind {
+	case KindNotFound:
+		code = codes.NotFound
+	case KindConflict:
+		code = codes.Aborted
+	case KindPermission:
+		code = codes.PermissionDenied
+	case KindTimeout:
+		code = codes.DeadlineExceeded
+	case KindUnavailable:
+		code = codes.Unavailable
+	case KindValidation:
+		code = codes.InvalidArgument
+	case KindCancelled:
+		code = codes.Canceled
+	case KindRateLimited:
+		code = codes.ResourceExhausted
+	default:
+		code = codes.Internal
+	}
+	return status.New(code, e.Error())
+}
+
 //
 // convenience constructors
 //
@@ -115,6 +174,11 @@ func Permission(err error, args ...interface{}) *Error { return mk(KindPermissio
 func Timeout(err error, args ...interface{}) *Error { return mk(KindTimeout, err, args...) }
 func Unavailable(err error, args ...interface{}) *Error { return mk(KindUnavailable, err, args...) }
 
+// RateLimited 超限拒绝，retryAfter 供调用方设置 Retry-After
+func RateLimited(retryAfter time.Duration, msg string) *Error {
+	return E(KindRateLimited, msg, retryAfter)
+}
+
 func mk(k Kind, err error, a ...interface{}) *Error {
 	msg := ""
 	if len(a) > 0 {
@@ -3,6 +3,7 @@ package logger
 
 import (
 	"context"
+	"io"
 	"os"
 
 	"go.opentelemetry.io/otel/trace"
@@ -21,12 +22,18 @@ const (
 type ctxKey struct{}
 
 var (
-	global *zap.Logger
+	// global 未 Init 前为 Nop，避免库代码/单测中空指针
+	global = zap.NewNop()
 )
 
 // Init create a new global logger instance
 // Must be called once at program bootstrap
 func Init(level zapcore.Level, encoding string) error {
+	return InitWriter(os.Stdout, level, encoding)
+}
+
+// InitWriter 同 Init，日志写入 w；stdout 被协议占用时（如 MCP stdio）改写 stderr
+func InitWriter(w io.Writer, level zapcore.Level, encoding string) error {
 	encoderCfg := zapcore.EncoderConfig{
 		TimeKey:        "ts",
 		LevelKey:       "level",
@@ -42,8 +49,7 @@ func Init(level zapcore.Level, encoding string) error {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	var sink zapcore.WriteSyncer
-	sink = zapcore.AddSync(os.Stdout)
+	sink := zapcore.AddSync(w)
 
 	core := zapcore.NewCore(
 		func() zapcore.Encoder {
@@ -0,0 +1,119 @@
+// pkg/agent/heartbeat.go
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// Reporter 把运行时心跳送到控制面
+type Reporter interface {
+	Report(ctx context.Context, hb *apis.RuntimeStatus) error
+}
+
+// StatusReporter 把心跳写入所属 Agent 的 status.runtimes，同一 Agent 的多个实例各占一项
+type StatusReporter struct {
+	Client client.Client
+	Agent  types.NamespacedName
+}
+
+func (s *StatusReporter) Report(ctx context.Context, hb *apis.RuntimeStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var agent apis.Agent
+		if err := s.Client.Get(ctx, s.Agent, &agent); err != nil {
+			return err
+		}
+		agent.Status.Runtimes = UpsertRuntimeStatus(agent.Status.Runtimes, hb)
+		return s.Client.Status().Update(ctx, &agent)
+	})
+}
+
+// UpsertRuntimeStatus 按 ID 替换或追加，结果按 ID 排序
+func UpsertRuntimeStatus(list []apis.RuntimeStatus, hb *apis.RuntimeStatus) []apis.RuntimeStatus {
+	out := make([]apis.RuntimeStatus, 0, len(list)+1)
+	for _, rs := range list {
+		if rs.ID != hb.ID {
+			out = append(out, rs)
+		}
+	}
+	out = append(out, *hb.DeepCopy())
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Heartbeat 汇总容量、运行中任务、已加载工具与沙箱健康度。
+// 容量取 AgentSpec.ResourceQuota，未配置时不上报容量，调度器不会向该实例派发任务
+func (r *Runtime) Heartbeat(ctx context.Context) *apis.RuntimeStatus {
+	hb := &apis.RuntimeStatus{ID: r.ID, LastHeartbeat: metav1.Now(), SandboxHealthy: true}
+
+	used := corev1.ResourceList{}
+	r.tasksMu.Lock()
+	for _, st := range r.tasks {
+		select {
+		case <-st.done:
+			continue
+		default:
+		}
+		hb.RunningTasks++
+		addQuantity(used, corev1.ResourceCPU, st.res.CPU)
+		addQuantity(used, corev1.ResourceMemory, st.res.Mem)
+	}
+	r.tasksMu.Unlock()
+
+	if q := r.quota(); q != nil {
+		hb.Capacity = corev1.ResourceList{}
+		addQuantity(hb.Capacity, corev1.ResourceCPU, q.CPU)
+		addQuantity(hb.Capacity, corev1.ResourceMemory, q.Mem)
+		hb.Free = corev1.ResourceList{}
+		for name, total := range hb.Capacity {
+			free := total.DeepCopy()
+			free.Sub(used[name])
+			if free.Sign() < 0 {
+				free.Set(0)
+			}
+			hb.Free[name] = free
+		}
+	}
+
+	if _, err := r.SandboxMgr.List(ctx); err != nil {
+		hb.SandboxHealthy = false
+		hb.Message = fmt.Sprintf("sandbox manager: %v", err)
+	}
+	if metas, err := r.ToolRegistry.List(ctx, nil); err == nil {
+		for _, m := range metas {
+			hb.Tools = append(hb.Tools, m.Name+"@"+m.Version)
+		}
+		sort.Strings(hb.Tools)
+	}
+	return hb
+}
+
+func (r *Runtime) quota() *ResourceQuota {
+	if r.Spec == nil {
+		return nil
+	}
+	return r.Spec.ResourceQuota
+}
+
+// addQuantity 非法或为空的数量忽略
+func addQuantity(list corev1.ResourceList, name corev1.ResourceName, s string) {
+	q, err := resource.ParseQuantity(s)
+	if s == "" || err != nil {
+		return
+	}
+	sum := list[name]
+	sum.Add(q)
+	list[name] = sum
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/test/bufconn"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/turtacn/agenticai/pkg/apis"
+	agentv1 "github.com/turtacn/agenticai/pkg/gen/api/proto/agent/v1"
+)
+
+// chanReporter 把心跳转给测试
+type chanReporter chan *apis.RuntimeStatus
+
+func (c chanReporter) Report(ctx context.Context, hb *apis.RuntimeStatus) error {
+	select {
+	case c <- hb:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	rt, c, _ := runtimeFixture(t)
+	rt.Spec.ResourceQuota = &ResourceQuota{CPU: "2", Mem: "1Gi"}
+	ctx := context.Background()
+
+	hb := rt.Heartbeat(ctx)
+	assert.NotEmpty(t, hb.ID)
+	assert.Zero(t, hb.RunningTasks)
+	assert.True(t, hb.SandboxHealthy)
+	assert.Equal(t, []string{"search@1.2.0"}, hb.Tools)
+	assert.True(t, hb.Capacity.Cpu().Equal(resource.MustParse("2")))
+
+	_, err := c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "busy", ImageRef: "sleep", Cpu: "500m", Memory: "256Mi"}})
+	require.NoError(t, err)
+	hb = rt.Heartbeat(ctx)
+	assert.Equal(t, int32(1), hb.RunningTasks)
+	assert.True(t, hb.Free.Cpu().Equal(resource.MustParse("1500m")), hb.Free.Cpu().String())
+	assert.True(t, hb.Free.Memory().Equal(resource.MustParse("768Mi")), hb.Free.Memory().String())
+
+	_, err = c.CancelTask(ctx, &agentv1.CancelTaskRequest{TaskId: "busy"})
+	require.NoError(t, err)
+	assert.Zero(t, rt.Heartbeat(ctx).RunningTasks)
+}
+
+func TestHeartbeatLoop(t *testing.T) {
+	reports := make(chanReporter, 8)
+	rt, err := New(&AgentSpec{Image: "echo"}, WithListener(bufconn.Listen(1<<20)), WithSandboxManager(&fakeSandboxes{}),
+		WithGRPCOptions(), WithReporter(reports), WithHeartbeatInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, rt.Start())
+	defer rt.Stop()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case hb := <-reports:
+			assert.Equal(t, rt.ID, hb.ID)
+		case <-time.After(2 * time.Second):
+			t.Fatal("no heartbeat")
+		}
+	}
+}
+
+func TestStatusReporter(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, apis.AddToScheme(s))
+	agent := &apis.Agent{ObjectMeta: metav1.ObjectMeta{Name: "coder", Namespace: "agents"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(agent).WithStatusSubresource(&apis.Agent{}).Build()
+	key := types.NamespacedName{Namespace: "agents", Name: "coder"}
+	rep := &StatusReporter{Client: c, Agent: key}
+	ctx := context.Background()
+
+	cpu := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+	require.NoError(t, rep.Report(ctx, &apis.RuntimeStatus{ID: "pod-b", Capacity: cpu, RunningTasks: 1}))
+	require.NoError(t, rep.Report(ctx, &apis.RuntimeStatus{ID: "pod-a", Capacity: cpu}))
+	require.NoError(t, rep.Report(ctx, &apis.RuntimeStatus{ID: "pod-b", Capacity: cpu, RunningTasks: 3}))
+
+	var got apis.Agent
+	require.NoError(t, c.Get(ctx, key, &got))
+	require.Len(t, got.Status.Runtimes, 2)
+	assert.Equal(t, "pod-a", got.Status.Runtimes[0].ID)
+	assert.Equal(t, int32(3), got.Status.Runtimes[1].RunningTasks)
+}
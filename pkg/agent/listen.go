@@ -0,0 +1,70 @@
+// pkg/agent/listen.go
+package agent
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ParseListenAddr 支持 unix:///abs/path、unix://rel/path、tcp://host:port 与裸的绝对路径
+func ParseListenAddr(addr string) (network, address string, err error) {
+	if strings.HasPrefix(addr, "/") {
+		return "unix", addr, nil
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	switch u.Scheme {
+	case "unix":
+		if p := u.Host + u.Path; p != "" {
+			return "unix", p, nil
+		}
+	case "tcp":
+		if u.Host != "" && u.Path == "" {
+			return "tcp", u.Host, nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid listen address %q, want unix:///path or tcp://host:port", addr)
+}
+
+// Listen unix socket 启动前清理上次异常退出遗留的文件，仍有进程在监听时报错；
+// socket 权限收紧为 0660，由 SO_PEERCRED 再按 uid 过滤
+func Listen(addr string) (net.Listener, error) {
+	network, address, err := ParseListenAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		if err := os.MkdirAll(filepath.Dir(address), 0o755); err != nil {
+			return nil, err
+		}
+		if fi, err := os.Lstat(address); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			if c, err := net.DialTimeout("unix", address, time.Second); err == nil {
+				c.Close()
+				return nil, fmt.Errorf("listen %s: address already in use", addr)
+			}
+			if err := os.Remove(address); err != nil {
+				return nil, err
+			}
+		}
+	}
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	if network == "unix" {
+		if err := os.Chmod(address, 0o660); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+//Personal.AI order the ending
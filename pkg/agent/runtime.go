@@ -3,23 +3,33 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"os"
-	"os/signal"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
 	"github.com/turtacn/agenticai/internal/logger"
+	agentv1 "github.com/turtacn/agenticai/pkg/gen/api/proto/agent/v1"
 	"github.com/turtacn/agenticai/pkg/tools"
 	"github.com/turtacn/agenticai/pkg/security"
 	"github.com/turtacn/agenticai/pkg/sandbox"
 )
 
+const (
+	// DefaultSocketPath 未配置监听地址时的 unix socket
+	DefaultSocketPath = "/var/run/agenticai/agent.sock"
+	// EnvListenAddr AgentSpec.ListenAddr 为空时从该环境变量读取监听地址
+	EnvListenAddr = "AGENT_LISTEN_ADDR"
+)
+
 // Runtime 智能体运行时实例
 type Runtime struct {
 	ID           string
@@ -33,9 +43,27 @@ type Runtime struct {
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
+	started      atomic.Bool
+	stopOnce     sync.Once
+
+	sandboxType sandbox.Type
+	syscalls    []string
+	reporter    Reporter
+	heartbeat   time.Duration
+	identity    security.Identity
+	allowedUIDs []uint32
+	grpcOpts    []grpc.ServerOption
+	customGRPC  bool
+
+	tasksMu  sync.Mutex
+	draining bool // 置位后拒绝新任务，与 tasks 同受 tasksMu 保护
+	tasks    map[string]*taskState
+	finished []string // 已结束任务按结束顺序，超出上限时淘汰最早的
 }
 
 type AgentSpec struct {
+	// ListenAddr unix:///path 或 tcp://host:port，为空时取 EnvListenAddr，再缺省为 DefaultSocketPath
+	ListenAddr    string
 	Image         string
 	Envs          map[string]string
 	GPU           bool
@@ -47,35 +75,155 @@ type ResourceQuota struct {
 	CPU, Mem string
 }
 
-func New(spec *AgentSpec) (*Runtime, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	l, err := net.Listen("unix", "/var/run/agenticai/agent.sock")
-	if err != nil {
-		return nil, err
+// Option 运行时可选项
+type Option func(*Runtime)
+
+// WithListener 使用已有监听器代替 AgentSpec.ListenAddr
+func WithListener(l net.Listener) Option {
+	return func(r *Runtime) { r.Listener = l }
+}
+
+// WithIdentity TCP 监听时出示的 SPIFFE 身份，默认经 workload API 获取
+func WithIdentity(id security.Identity) Option {
+	return func(r *Runtime) { r.identity = id }
+}
+
+// WithAllowedUIDs unix socket 只接受这些 uid 的对端，默认为运行时自身 uid 与 root
+func WithAllowedUIDs(uids ...uint32) Option {
+	return func(r *Runtime) { r.allowedUIDs = uids }
+}
+
+// WithToolRegistry 任务引用的工具从 reg 解析，默认为空的内存注册表
+func WithToolRegistry(reg tools.Registry) Option {
+	return func(r *Runtime) { r.ToolRegistry = reg }
+}
+
+// WithSandboxManager 替换沙箱管理器，默认按 WithSandboxType 创建
+func WithSandboxManager(m sandbox.Manager) Option {
+	return func(r *Runtime) { r.SandboxMgr = m }
+}
+
+// WithSandboxType 任务使用的沙箱类型，默认 gvisor
+func WithSandboxType(t sandbox.Type) Option {
+	return func(r *Runtime) { r.sandboxType = t }
+}
+
+// WithRestrictedSyscalls 沙箱在 agent-default 之外额外禁止的系统调用
+func WithRestrictedSyscalls(names ...string) Option {
+	return func(r *Runtime) { r.syscalls = names }
+}
+
+// WithReporter 心跳上报目标，为 nil 时不上报
+func WithReporter(rep Reporter) Option {
+	return func(r *Runtime) { r.reporter = rep }
+}
+
+// WithHeartbeatInterval 心跳间隔，默认 constants.AgentHeartbeatInterval
+func WithHeartbeatInterval(d time.Duration) Option {
+	return func(r *Runtime) { r.heartbeat = d }
+}
+
+// WithGRPCOptions 替换按监听类型选择的传输凭据与认证拦截器
+func WithGRPCOptions(opts ...grpc.ServerOption) Option {
+	return func(r *Runtime) {
+		r.grpcOpts = opts
+		r.customGRPC = true
 	}
-	srv := grpc.NewServer(
-		grpc.UnaryInterceptor(security.SPIFFEInterceptor()),
-		grpc.StreamInterceptor(security.SPIFFEStreamInterceptor()),
-	)
+}
+
+func New(spec *AgentSpec, opts ...Option) (*Runtime, error) {
 	rt := &Runtime{
-		ID:       os.Getenv("RUNTIME_ID"),
-		Spec:     spec,
-		Listener: l,
-		GRPCSrv:  srv,
-		ctx:      ctx,
-		cancel:   cancel,
+		ID:          os.Getenv(constants.EnvRuntimeID),
+		Spec:        spec,
+		sandboxType: sandbox.TypeGvisor,
+		heartbeat:   constants.AgentHeartbeatInterval,
+		tasks:       make(map[string]*taskState),
+	}
+	for _, o := range opts {
+		o(rt)
+	}
+	if rt.ID == "" {
+		rt.ID, _ = os.Hostname()
 	}
 	// 初始化组件
-	rt.ToolRegistry = tools.NewInMemRegistry()
+	if rt.ToolRegistry == nil {
+		rt.ToolRegistry = tools.NewInMemRegistry()
+	}
 	// rt.StorageIface = storage.NewLocalStorage("/tmp/agents", ctx)
-	rt.SandboxMgr, _ = sandbox.NewManager(ctx, spec.Image, sandbox.TypeGvisor)
+	if rt.SandboxMgr == nil {
+		m, err := sandbox.NewManager(context.Background(), spec.Image, rt.sandboxType)
+		if err != nil {
+			return nil, err
+		}
+		rt.SandboxMgr = m
+	}
 	// rt.MetricCollector = observability.NewLocalMetricsCollector()
+	if rt.Listener == nil {
+		l, err := Listen(rt.listenAddr())
+		if err != nil {
+			return nil, err
+		}
+		rt.Listener = l
+	}
+	if !rt.customGRPC {
+		opts, err := rt.transportOptions()
+		if err != nil {
+			rt.Listener.Close()
+			return nil, err
+		}
+		rt.grpcOpts = opts
+	}
+	rt.ctx, rt.cancel = context.WithCancel(context.Background())
+	rt.GRPCSrv = grpc.NewServer(rt.grpcOpts...)
 	// 注册自服务
-	// pb.RegisterAgentServer(srv, rt)
-	reflection.Register(srv)
+	agentv1.RegisterAgentServiceServer(rt.GRPCSrv, &agentServer{Runtime: rt})
+	reflection.Register(rt.GRPCSrv)
 	return rt, nil
 }
 
+func (r *Runtime) listenAddr() string {
+	if r.Spec != nil && r.Spec.ListenAddr != "" {
+		return r.Spec.ListenAddr
+	}
+	if addr := os.Getenv(EnvListenAddr); addr != "" {
+		return addr
+	}
+	return "unix://" + DefaultSocketPath
+}
+
+// transportOptions unix socket 以 SO_PEERCRED 认证本机进程，TCP 走 SPIFFE mTLS
+func (r *Runtime) transportOptions() ([]grpc.ServerOption, error) {
+	switch network := r.Listener.Addr().Network(); network {
+	case "unix":
+		uids := r.allowedUIDs
+		if len(uids) == 0 {
+			uids = []uint32{uint32(os.Getuid()), 0}
+		}
+		return []grpc.ServerOption{
+			grpc.Creds(security.PeerCredentials(uids...)),
+			grpc.UnaryInterceptor(security.PeerCredInterceptor()),
+			grpc.StreamInterceptor(security.PeerCredStreamInterceptor()),
+		}, nil
+	case "tcp", "tcp4", "tcp6":
+		if r.identity == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultTimeout)
+			defer cancel()
+			id, err := security.GetIdentity(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("tcp listener requires a SPIFFE identity: %w", err)
+			}
+			r.identity = id
+		}
+		return []grpc.ServerOption{
+			grpc.Creds(r.identity.Source()),
+			grpc.UnaryInterceptor(security.SPIFFEInterceptor()),
+			grpc.StreamInterceptor(security.SPIFFEStreamInterceptor()),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported listener network %q", network)
+	}
+}
+
 func (r *Runtime) Start() error {
 	r.wg.Add(1)
 	go func() {
@@ -84,53 +232,93 @@ func (r *Runtime) Start() error {
 	}()
 	r.wg.Add(1)
 	go r.keepAliveLoop()
+	r.started.Store(true)
 	return nil
 }
 
+// Ready 已启动且未进入排空时可接收任务
+func (r *Runtime) Ready() bool {
+	if !r.started.Load() || r.ctx.Err() != nil {
+		return false
+	}
+	r.tasksMu.Lock()
+	defer r.tasksMu.Unlock()
+	return !r.draining
+}
+
+// keepAliveLoop 启动后立即上报一次，之后按心跳间隔上报
 func (r *Runtime) keepAliveLoop() {
 	defer r.wg.Done()
-	t := time.NewTicker(15 * time.Second)
+	if r.reporter == nil {
+		return
+	}
+	t := time.NewTicker(r.heartbeat)
 	defer t.Stop()
 	for {
+		if err := r.report(); err != nil && r.ctx.Err() == nil {
+			logger.Error(r.ctx, "heartbeat: report", zap.Error(err))
+		}
 		select {
 		case <-r.ctx.Done():
 			return
 		case <-t.C:
-			if err := r.report(); err != nil {
-				logger.Error(r.ctx, "heartbeat: report", zap.Error(err))
-			}
 		}
 	}
 }
 
 func (r *Runtime) report() error {
-	return nil // TODO: gRPC to controller
+	ctx, cancel := context.WithTimeout(r.ctx, r.heartbeat)
+	defer cancel()
+	return r.reporter.Report(ctx, r.Heartbeat(ctx))
 }
 
+// Stop 立即取消所有任务并关闭服务，可重复调用
 func (r *Runtime) Stop() {
-	r.cancel()
-	r.GRPCSrv.GracefulStop()
-	r.SandboxMgr.Close()
-	r.wg.Wait()
+	r.stopOnce.Do(func() {
+		r.cancel()
+		r.GRPCSrv.GracefulStop()
+		r.wg.Wait()
+		r.SandboxMgr.Close()
+	})
+}
+
+// Drain 拒绝新任务并等待进行中的任务结束，ctx 到期时返回其错误，剩余任务留给 Stop 取消
+func (r *Runtime) Drain(ctx context.Context) error {
+	r.tasksMu.Lock()
+	r.draining = true
+	var running []*taskState
+	for _, st := range r.tasks {
+		running = append(running, st)
+	}
+	r.tasksMu.Unlock()
+
+	for _, st := range running {
+		select {
+		case <-st.done:
+		case <-ctx.Done():
+			return errors.Timeout(ctx.Err(), "drain in-flight tasks")
+		}
+	}
+	return nil
 }
 
-func (r *Runtime) awaitShutdown() {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-	<-c
+// Shutdown 排空后停止
+func (r *Runtime) Shutdown(ctx context.Context) error {
+	err := r.Drain(ctx)
 	r.Stop()
+	return err
 }
 
-/*
-type pbAgentServer struct{ *Runtime }
-func (r *pbAgentServer) ExecuteTask(ctx context.Context, req *pb.ExecuteTaskRequest) (*pb.ExecuteTaskResponse, error) {
-	_, span := otel.Tracer("runtime").Start(ctx, "ExecuteTask")
-	defer span.End()
-	tr, err := NewExecutor(r.Runtime).ExecuteTask(ctx, req.Task)
-	if err != nil {
-		return nil, re.ToGRPC(err)
+// Run 启动后阻塞到 ctx 结束，再以 grace 为上限排空并停止
+func (r *Runtime) Run(ctx context.Context, grace time.Duration) error {
+	if err := r.Start(); err != nil {
+		return err
 	}
-	return &pb.ExecuteTaskResponse{Result: tr}, nil
+	<-ctx.Done()
+	logger.Info(ctx, "runtime draining", zap.String("id", r.ID), zap.Duration("grace", grace))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	return r.Shutdown(shutdownCtx)
 }
-*/
+
 //Personal.AI order the ending
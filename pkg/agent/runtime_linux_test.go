@@ -0,0 +1,72 @@
+//go:build linux
+
+package agent
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	agentv1 "github.com/turtacn/agenticai/pkg/gen/api/proto/agent/v1"
+)
+
+func unixClient(t *testing.T, path string) agentv1.AgentServiceClient {
+	conn, err := grpc.NewClient("unix://"+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return agentv1.NewAgentServiceClient(conn)
+}
+
+func TestRuntimeUnixPeerCred(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "run", "agent.sock")
+
+	// 上次异常退出遗留的 socket 文件被清理
+	require.NoError(t, os.MkdirAll(filepath.Dir(sock), 0o755))
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: sock, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	t.Setenv(EnvListenAddr, "unix://"+sock)
+	rt, err := New(&AgentSpec{Image: "echo"}, WithSandboxManager(&fakeSandboxes{}))
+	require.NoError(t, err)
+	require.NoError(t, rt.Start())
+	t.Cleanup(rt.Stop)
+	fi, err := os.Stat(sock)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), fi.Mode().Perm())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := unixClient(t, sock).ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "local"}})
+	require.NoError(t, err)
+	assert.NotEqual(t, agentv1.TaskPhase_TASK_PHASE_FAILED, st.Phase)
+
+	// 仍在监听的 socket 不会被抢占
+	_, err = Listen("unix://" + sock)
+	assert.ErrorContains(t, err, "already in use")
+}
+
+func TestRuntimeUnixRejectsUID(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	rt, err := New(&AgentSpec{ListenAddr: "unix://" + sock, Image: "echo"},
+		WithSandboxManager(&fakeSandboxes{}), WithAllowedUIDs(uint32(os.Getuid())+1))
+	require.NoError(t, err)
+	require.NoError(t, rt.Start())
+	t.Cleanup(rt.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = unixClient(t, sock).GetTaskStatus(ctx, &agentv1.GetTaskStatusRequest{TaskId: "x"})
+	assert.Contains(t, []codes.Code{codes.Unavailable, codes.DeadlineExceeded}, status.Code(err))
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	agentv1 "github.com/turtacn/agenticai/pkg/gen/api/proto/agent/v1"
+)
+
+func TestParseListenAddr(t *testing.T) {
+	for addr, want := range map[string][2]string{
+		"unix:///var/run/agent.sock": {"unix", "/var/run/agent.sock"},
+		"unix://run/agent.sock":      {"unix", "run/agent.sock"},
+		"/tmp/agent.sock":            {"unix", "/tmp/agent.sock"},
+		"tcp://:50052":               {"tcp", ":50052"},
+		"tcp://0.0.0.0:50052":        {"tcp", "0.0.0.0:50052"},
+	} {
+		network, address, err := ParseListenAddr(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, want, [2]string{network, address}, addr)
+	}
+	for _, addr := range []string{"", "unix://", "tcp://", "tcp://host:1/path", "http://x:1", "agent.sock"} {
+		_, _, err := ParseListenAddr(addr)
+		assert.Error(t, err, addr)
+	}
+}
+
+// testPKI 自签 CA 与按 SPIFFE ID 签发的叶子证书
+type testPKI struct {
+	ca    *x509.Certificate
+	key   *ecdsa.PrivateKey
+	roots *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1), NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour),
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return &testPKI{ca: ca, key: key, roots: roots}
+}
+
+func (p *testPKI) issue(t *testing.T, id string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	u, err := url.Parse(id)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()), NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour),
+		URIs: []*url.URL{u}, IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// staticIdentity 以固定证书代替 workload API
+type staticIdentity struct {
+	id    spiffeid.ID
+	creds credentials.TransportCredentials
+}
+
+func (s *staticIdentity) ID() spiffeid.ID                          { return s.id }
+func (s *staticIdentity) Source() credentials.TransportCredentials { return s.creds }
+
+func TestRuntimeTCPRequiresMTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	server := &staticIdentity{
+		id: spiffeid.RequireFromString("spiffe://agenticai.io/agent"),
+		creds: credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{pki.issue(t, "spiffe://agenticai.io/agent")},
+			ClientCAs:    pki.roots,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		}),
+	}
+	rt, err := New(&AgentSpec{ListenAddr: "tcp://127.0.0.1:0", Image: "echo"},
+		WithIdentity(server), WithSandboxManager(&fakeSandboxes{}))
+	require.NoError(t, err)
+	require.NoError(t, rt.Start())
+	t.Cleanup(rt.Stop)
+	addr := rt.Listener.Addr().String()
+
+	call := func(certs ...tls.Certificate) error {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			RootCAs: pki.roots, Certificates: certs, ServerName: "127.0.0.1",
+		})))
+		require.NoError(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = agentv1.NewAgentServiceClient(conn).ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{
+			Task: &agentv1.Task{TaskId: "tcp-" + time.Now().Format(time.RFC3339Nano)},
+		})
+		return err
+	}
+	assert.NoError(t, call(pki.issue(t, "spiffe://agenticai.io/controller")))
+	// 不出示客户端证书的连接在握手阶段被拒绝
+	assert.Error(t, call())
+}
+
+func TestRuntimeDrain(t *testing.T) {
+	rt, c, _ := runtimeFixture(t)
+	ctx := context.Background()
+	assert.True(t, rt.Ready())
+
+	_, err := c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "long", ImageRef: "sleep"}})
+	require.NoError(t, err)
+
+	drained := make(chan error, 1)
+	go func() { drained <- rt.Drain(ctx) }()
+	require.Eventually(t, func() bool { return !rt.Ready() }, 2*time.Second, 10*time.Millisecond)
+
+	// 排空期间拒绝新任务，已有任务仍可查询与取消
+	_, err = c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "late"}})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	select {
+	case <-drained:
+		t.Fatal("drain returned with a task in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	_, err = c.CancelTask(ctx, &agentv1.CancelTaskRequest{TaskId: "long"})
+	require.NoError(t, err)
+	assert.NoError(t, <-drained)
+}
+
+func TestRuntimeShutdownDeadline(t *testing.T) {
+	rt, c, _ := runtimeFixture(t)
+	ctx := context.Background()
+	_, err := c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "stuck", ImageRef: "sleep"}})
+	require.NoError(t, err)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = rt.Shutdown(shutdownCtx)
+	assert.Error(t, err)
+
+	// 超出宽限期的任务由 Stop 取消
+	snap := rt.tasks["stuck"].snapshot()
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_CANCELLED, snap.Phase)
+	assert.Equal(t, "runtime shutting down", snap.Message)
+}
@@ -0,0 +1,414 @@
+// pkg/agent/service.go
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/internal/logger"
+	agentv1 "github.com/turtacn/agenticai/pkg/gen/api/proto/agent/v1"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+const (
+	// maxTaskEvents 每个任务保留的最近事件数，更早的事件不再补发
+	maxTaskEvents = 1024
+	// maxFinishedTasks 保留可查询的已结束任务数
+	maxFinishedTasks = 256
+	// maxTaskOutput 状态中保留的标准输出上限，完整输出经 TaskEvents 获取
+	maxTaskOutput = 1 << 20
+	// maxTaskStderr 失败时附在 message 中的标准错误尾部
+	maxTaskStderr = 4 << 10
+)
+
+// agentServer 以 Runtime 实现 AgentService
+type agentServer struct {
+	agentv1.UnimplementedAgentServiceServer
+	*Runtime
+}
+
+func (s *agentServer) ExecuteTask(ctx context.Context, req *agentv1.ExecuteTaskRequest) (*agentv1.TaskStatus, error) {
+	ctx, span := otel.Tracer("runtime").Start(ctx, "ExecuteTask")
+	defer span.End()
+
+	task := req.GetTask()
+	if task.GetTaskId() == "" {
+		return nil, errors.E(errors.KindValidation, "task_id required")
+	}
+	span.SetAttributes(attribute.String("task.id", task.TaskId))
+	sbSpec, resolved, err := s.sandboxSpec(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	// 沙箱生命周期跟随运行时而非本次请求
+	taskCtx, cancel := context.WithCancelCause(s.ctx)
+	if task.TimeoutMs > 0 {
+		timeout := time.Duration(task.TimeoutMs) * time.Millisecond
+		var stop context.CancelFunc
+		taskCtx, stop = context.WithTimeoutCause(taskCtx, timeout,
+			errors.E(errors.KindTimeout, fmt.Sprintf("task timed out after %s", timeout)))
+		cancel = chainCancel(cancel, stop)
+	}
+
+	s.tasksMu.Lock()
+	if st, ok := s.tasks[task.TaskId]; ok {
+		s.tasksMu.Unlock()
+		cancel(nil)
+		return st.snapshot(), nil
+	}
+	if s.draining {
+		s.tasksMu.Unlock()
+		cancel(nil)
+		return nil, errors.E(errors.KindUnavailable, "runtime is draining, not accepting new tasks")
+	}
+	st := newTaskState(task.TaskId, resolved, cancel)
+	st.res = sbSpec.Resource
+	s.tasks[task.TaskId] = st
+	s.tasksMu.Unlock()
+
+	sbSpec.Stdout = &eventWriter{st: st, typ: agentv1.TaskEventType_TASK_EVENT_TYPE_STDOUT}
+	sbSpec.Stderr = &eventWriter{st: st, typ: agentv1.TaskEventType_TASK_EVENT_TYPE_STDERR}
+
+	sb, err := s.SandboxMgr.Start(taskCtx, sbSpec)
+	if err != nil {
+		s.finish(taskCtx, st, err, true)
+		return st.snapshot(), nil
+	}
+	st.setPhase(agentv1.TaskPhase_TASK_PHASE_RUNNING, "")
+	logger.Info(ctx, "task started", zap.String("task", task.TaskId), zap.String("image", sbSpec.ImageRef))
+
+	s.wg.Add(1)
+	go s.wait(taskCtx, st, sb)
+	return st.snapshot(), nil
+}
+
+// sandboxSpec 解析任务引用的工具并拼出沙箱参数；工具以 name@digest 钉死后经环境变量传入
+func (s *agentServer) sandboxSpec(ctx context.Context, task *agentv1.Task) (*sandbox.SandboxSpec, []*agentv1.ResolvedTool, error) {
+	image := task.ImageRef
+	if image == "" && s.Spec != nil {
+		image = s.Spec.Image
+	}
+	if image == "" {
+		return nil, nil, errors.E(errors.KindValidation, "image_ref required")
+	}
+
+	env := make(map[string]string, len(task.Env)+2)
+	if s.Spec != nil {
+		for k, v := range s.Spec.Envs {
+			env[k] = v
+		}
+	}
+	for k, v := range task.Env {
+		env[k] = v
+	}
+	var resolved []*agentv1.ResolvedTool
+	if len(task.Tools) > 0 {
+		refs := make([]string, 0, len(task.Tools))
+		for _, ref := range task.Tools {
+			spec, err := s.ToolRegistry.Resolve(ctx, ref)
+			if err != nil {
+				return nil, nil, err
+			}
+			resolved = append(resolved, &agentv1.ResolvedTool{
+				Ref: ref, Id: spec.ID, Name: spec.Name, Version: spec.Version, Digest: spec.Digest,
+			})
+			refs = append(refs, tools.PinRef(spec.ID, spec.Name, spec.Digest))
+		}
+		env[constants.EnvPinnedTools] = strings.Join(refs, ",")
+	}
+	if task.ContextId != "" {
+		env[constants.EnvContextID] = task.ContextId
+	}
+
+	res := sandbox.ResourceLimit{CPU: constants.DefaultSandboxCPU, Mem: constants.DefaultSandboxMemory}
+	if q := s.quota(); q != nil {
+		if q.CPU != "" {
+			res.CPU = q.CPU
+		}
+		if q.Mem != "" {
+			res.Mem = q.Mem
+		}
+	}
+	if task.Cpu != "" {
+		res.CPU = task.Cpu
+	}
+	if task.Memory != "" {
+		res.Mem = task.Memory
+	}
+	return &sandbox.SandboxSpec{
+		Type:               s.sandboxType,
+		ImageRef:           image,
+		Cmd:                append(append([]string{}, task.Command...), task.Args...),
+		Env:                env,
+		Resource:           res,
+		Network:            task.Network,
+		Stdin:              strings.NewReader(task.Input),
+		SysCallRestriction: s.syscalls,
+	}, resolved, nil
+}
+
+func (s *agentServer) wait(ctx context.Context, st *taskState, sb sandbox.Sandbox) {
+	defer s.wg.Done()
+	defer sb.Delete(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- sb.Wait(ctx) }()
+	select {
+	case err := <-done:
+		s.finish(ctx, st, err, false)
+	case <-ctx.Done():
+		_ = sb.Kill(context.Background())
+		s.finish(ctx, st, ctx.Err(), false)
+	}
+}
+
+// finish 按 ctx 的取消原因区分取消、超时与运行时退出
+func (s *agentServer) finish(ctx context.Context, st *taskState, err error, startFailed bool) {
+	phase, msg, code := agentv1.TaskPhase_TASK_PHASE_COMPLETED, "", int32(0)
+	switch cause := context.Cause(ctx); {
+	case ctx.Err() != nil && errors.KindOf(cause) == errors.KindCancelled:
+		phase, msg, code = agentv1.TaskPhase_TASK_PHASE_CANCELLED, cause.Error(), -1
+	case ctx.Err() != nil && errors.KindOf(cause) == errors.KindTimeout:
+		phase, msg, code = agentv1.TaskPhase_TASK_PHASE_FAILED, cause.Error(), -1
+	case s.ctx.Err() != nil:
+		phase, msg, code = agentv1.TaskPhase_TASK_PHASE_CANCELLED, "runtime shutting down", -1
+	case startFailed:
+		phase, msg, code = agentv1.TaskPhase_TASK_PHASE_FAILED, fmt.Sprintf("start sandbox: %v", err), -1
+	case err != nil:
+		phase, msg, code = agentv1.TaskPhase_TASK_PHASE_FAILED, err.Error(), 1
+		if ec, ok := err.(interface{ ExitCode() int }); ok {
+			code = int32(ec.ExitCode())
+		}
+		if tail := st.stderrTail(); tail != "" {
+			msg += ": " + tail
+		}
+	}
+	st.complete(phase, msg, code)
+	st.cancel(nil)
+	logger.Info(ctx, "task finished", zap.String("task", st.id), zap.String("phase", phase.String()), zap.Int32("exitCode", code))
+
+	s.tasksMu.Lock()
+	defer s.tasksMu.Unlock()
+	s.finished = append(s.finished, st.id)
+	if n := len(s.finished) - maxFinishedTasks; n > 0 {
+		for _, id := range s.finished[:n] {
+			delete(s.tasks, id)
+		}
+		s.finished = append([]string(nil), s.finished[n:]...)
+	}
+}
+
+func (s *agentServer) CancelTask(ctx context.Context, req *agentv1.CancelTaskRequest) (*agentv1.TaskStatus, error) {
+	st, err := s.task(req.GetTaskId())
+	if err != nil {
+		return nil, err
+	}
+	reason := req.GetReason()
+	if reason == "" {
+		reason = "cancelled by request"
+	}
+	st.cancel(errors.E(errors.KindCancelled, reason))
+	// 已结束的任务原样返回；否则等沙箱被杀后返回终态
+	select {
+	case <-st.done:
+	case <-ctx.Done():
+		return nil, errors.Timeout(ctx.Err(), fmt.Sprintf("cancel task %s", st.id))
+	}
+	return st.snapshot(), nil
+}
+
+func (s *agentServer) GetTaskStatus(_ context.Context, req *agentv1.GetTaskStatusRequest) (*agentv1.TaskStatus, error) {
+	st, err := s.task(req.GetTaskId())
+	if err != nil {
+		return nil, err
+	}
+	return st.snapshot(), nil
+}
+
+func (s *agentServer) TaskEvents(req *agentv1.TaskEventsRequest, stream agentv1.AgentService_TaskEventsServer) error {
+	st, err := s.task(req.GetTaskId())
+	if err != nil {
+		return err
+	}
+	next := max(req.GetFromSeq(), 1)
+	for {
+		events, changed, done := st.since(next)
+		for _, ev := range events {
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+			next = ev.Seq + 1
+		}
+		if len(events) > 0 {
+			continue
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *agentServer) task(id string) (*taskState, error) {
+	s.tasksMu.Lock()
+	defer s.tasksMu.Unlock()
+	st, ok := s.tasks[id]
+	if !ok {
+		return nil, errors.E(errors.KindNotFound, fmt.Sprintf("task %q not found", id))
+	}
+	return st, nil
+}
+
+// taskState 任务状态与事件日志；changed 在每次追加事件时关闭并替换，唤醒所有订阅者
+type taskState struct {
+	id     string
+	res    sandbox.ResourceLimit
+	done   chan struct{}
+	cancel context.CancelCauseFunc
+
+	mu      sync.Mutex
+	status  *agentv1.TaskStatus
+	events  []*agentv1.TaskEvent
+	nextSeq int64
+	changed chan struct{}
+	stdout  bytes.Buffer
+	stderr  []byte
+}
+
+func newTaskState(id string, tools []*agentv1.ResolvedTool, cancel context.CancelCauseFunc) *taskState {
+	st := &taskState{
+		id:      id,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		nextSeq: 1,
+		changed: make(chan struct{}),
+		status: &agentv1.TaskStatus{
+			TaskId: id, Phase: agentv1.TaskPhase_TASK_PHASE_PENDING, Tools: tools,
+		},
+	}
+	st.mu.Lock()
+	st.appendLocked(agentv1.TaskEventType_TASK_EVENT_TYPE_STATUS, "", st.snapshotLocked())
+	st.mu.Unlock()
+	return st
+}
+
+func (st *taskState) setPhase(phase agentv1.TaskPhase, msg string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.status.Phase, st.status.Message = phase, msg
+	if phase == agentv1.TaskPhase_TASK_PHASE_RUNNING {
+		st.status.StartTime = time.Now().UnixMilli()
+	}
+	st.appendLocked(agentv1.TaskEventType_TASK_EVENT_TYPE_STATUS, "", st.snapshotLocked())
+}
+
+func (st *taskState) complete(phase agentv1.TaskPhase, msg string, code int32) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.status.Phase, st.status.Message, st.status.ExitCode = phase, msg, code
+	st.status.EndTime = time.Now().UnixMilli()
+	st.appendLocked(agentv1.TaskEventType_TASK_EVENT_TYPE_STATUS, "", st.snapshotLocked())
+	close(st.done)
+}
+
+func (st *taskState) write(typ agentv1.TaskEventType, p []byte) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	switch typ {
+	case agentv1.TaskEventType_TASK_EVENT_TYPE_STDOUT:
+		if room := maxTaskOutput - st.stdout.Len(); room > 0 {
+			st.stdout.Write(p[:min(room, len(p))])
+		}
+	case agentv1.TaskEventType_TASK_EVENT_TYPE_STDERR:
+		st.stderr = append(st.stderr, p...)
+		if n := len(st.stderr) - maxTaskStderr; n > 0 {
+			st.stderr = append([]byte(nil), st.stderr[n:]...)
+		}
+	}
+	st.appendLocked(typ, string(p), nil)
+}
+
+func (st *taskState) appendLocked(typ agentv1.TaskEventType, data string, status *agentv1.TaskStatus) {
+	st.events = append(st.events, &agentv1.TaskEvent{
+		TaskId: st.id, Seq: st.nextSeq, Type: typ,
+		Timestamp: time.Now().UnixMilli(), Data: data, Status: status,
+	})
+	st.nextSeq++
+	if n := len(st.events) - maxTaskEvents; n > 0 {
+		st.events = append([]*agentv1.TaskEvent(nil), st.events[n:]...)
+	}
+	close(st.changed)
+	st.changed = make(chan struct{})
+}
+
+// since 返回 seq>=from 的事件、下次变化的通知以及任务是否已结束
+func (st *taskState) since(from int64) ([]*agentv1.TaskEvent, <-chan struct{}, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var out []*agentv1.TaskEvent
+	for _, ev := range st.events {
+		if ev.Seq >= from {
+			out = append(out, ev)
+		}
+	}
+	select {
+	case <-st.done:
+		return out, st.changed, true
+	default:
+		return out, st.changed, false
+	}
+}
+
+func (st *taskState) snapshot() *agentv1.TaskStatus {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.snapshotLocked()
+}
+
+func (st *taskState) snapshotLocked() *agentv1.TaskStatus {
+	out := proto.Clone(st.status).(*agentv1.TaskStatus)
+	out.Output = st.stdout.String()
+	return out
+}
+
+func (st *taskState) stderrTail() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return strings.TrimSpace(string(st.stderr))
+}
+
+// eventWriter 把沙箱输出转为任务事件
+type eventWriter struct {
+	st  *taskState
+	typ agentv1.TaskEventType
+}
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	w.st.write(w.typ, p)
+	return len(p), nil
+}
+
+func chainCancel(cancel context.CancelCauseFunc, stop context.CancelFunc) context.CancelCauseFunc {
+	return func(cause error) {
+		cancel(cause)
+		stop()
+	}
+}
+
+//Personal.AI order the ending
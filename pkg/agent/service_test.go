@@ -0,0 +1,269 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/pkg/apis"
+	agentv1 "github.com/turtacn/agenticai/pkg/gen/api/proto/agent/v1"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+// fakeSandboxes 按镜像名选择行为：echo 回显 stdin，fail 以退出码 3 失败，其余阻塞到被杀
+type fakeSandboxes struct {
+	mu     sync.Mutex
+	specs  []*sandbox.SandboxSpec
+	kills  int
+	closed bool
+}
+
+func (m *fakeSandboxes) Start(_ context.Context, spec *sandbox.SandboxSpec) (sandbox.Sandbox, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.specs = append(m.specs, spec)
+	if spec.ImageRef == "broken" {
+		return nil, fmt.Errorf("image pull failed")
+	}
+	return &fakeSandbox{m: m, spec: spec, killed: make(chan struct{})}, nil
+}
+
+func (m *fakeSandboxes) started() []*sandbox.SandboxSpec {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*sandbox.SandboxSpec(nil), m.specs...)
+}
+
+func (m *fakeSandboxes) Stop(context.Context, string) error            { return nil }
+func (m *fakeSandboxes) List(context.Context) ([]*sandbox.Info, error) { return nil, nil }
+
+func (m *fakeSandboxes) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	return nil
+}
+
+type exitError int
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+type fakeSandbox struct {
+	m      *fakeSandboxes
+	spec   *sandbox.SandboxSpec
+	once   sync.Once
+	killed chan struct{}
+}
+
+func (s *fakeSandbox) Start(context.Context) error { return nil }
+
+func (s *fakeSandbox) Kill(context.Context) error {
+	s.once.Do(func() {
+		s.m.mu.Lock()
+		s.m.kills++
+		s.m.mu.Unlock()
+		close(s.killed)
+	})
+	return nil
+}
+
+func (s *fakeSandbox) Wait(context.Context) error {
+	switch s.spec.ImageRef {
+	case "echo":
+		in, _ := io.ReadAll(s.spec.Stdin)
+		_, _ = s.spec.Stdout.Write(in)
+		return nil
+	case "fail":
+		_, _ = s.spec.Stderr.Write([]byte("boom\n"))
+		return exitError(3)
+	}
+	<-s.killed
+	return fmt.Errorf("killed")
+}
+
+func (s *fakeSandbox) Delete(context.Context) error { return nil }
+
+func (s *fakeSandbox) Info(context.Context) (*sandbox.Info, error) {
+	return &sandbox.Info{ID: s.spec.ImageRef}, nil
+}
+
+// runtimeFixture 经 bufconn 启动运行时，注册 search@1.2.0 一个工具
+func runtimeFixture(t *testing.T) (*Runtime, agentv1.AgentServiceClient, *fakeSandboxes) {
+	reg := tools.NewInMemRegistry()
+	require.NoError(t, reg.Register(context.Background(), &apis.ToolSpec{
+		Name: "search", Version: "1.2.0", CustomExec: &apis.CustomBinding{Image: "search"},
+	}))
+	sb := &fakeSandboxes{}
+	lis := bufconn.Listen(1 << 20)
+	rt, err := New(&AgentSpec{Image: "echo", Envs: map[string]string{"AGENT": "a1"}},
+		WithListener(lis), WithToolRegistry(reg), WithSandboxManager(sb), WithGRPCOptions())
+	require.NoError(t, err)
+	require.NoError(t, rt.Start())
+	t.Cleanup(rt.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return rt, agentv1.NewAgentServiceClient(conn), sb
+}
+
+// collect 读完事件流，任务结束时流正常关闭
+func collect(t *testing.T, c agentv1.AgentServiceClient, id string, from int64) []*agentv1.TaskEvent {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := c.TaskEvents(ctx, &agentv1.TaskEventsRequest{TaskId: id, FromSeq: from})
+	require.NoError(t, err)
+	var out []*agentv1.TaskEvent
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			return out
+		}
+		require.NoError(t, err)
+		out = append(out, ev)
+	}
+}
+
+func phases(events []*agentv1.TaskEvent) []agentv1.TaskPhase {
+	var out []agentv1.TaskPhase
+	for _, ev := range events {
+		if ev.Type == agentv1.TaskEventType_TASK_EVENT_TYPE_STATUS {
+			out = append(out, ev.Status.Phase)
+		}
+	}
+	return out
+}
+
+func TestExecuteTask(t *testing.T) {
+	_, c, sb := runtimeFixture(t)
+	ctx := context.Background()
+
+	st, err := c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{
+		TaskId: "t1", Tools: []string{"search@^1"}, ContextId: "chat-1", Input: "hello",
+		Env: map[string]string{"MODE": "test"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, st.Tools, 1)
+	assert.Equal(t, "search@1.2.0", st.Tools[0].Id)
+
+	events := collect(t, c, "t1", 0)
+	assert.Equal(t, []agentv1.TaskPhase{
+		agentv1.TaskPhase_TASK_PHASE_PENDING, agentv1.TaskPhase_TASK_PHASE_RUNNING, agentv1.TaskPhase_TASK_PHASE_COMPLETED,
+	}, phases(events))
+	var stdout strings.Builder
+	for i, ev := range events {
+		assert.Equal(t, int64(i+1), ev.Seq)
+		if ev.Type == agentv1.TaskEventType_TASK_EVENT_TYPE_STDOUT {
+			stdout.WriteString(ev.Data)
+		}
+	}
+	assert.Equal(t, "hello", stdout.String())
+	// 从中间序号续读
+	assert.Len(t, collect(t, c, "t1", 3), len(events)-2)
+
+	got, err := c.GetTaskStatus(ctx, &agentv1.GetTaskStatusRequest{TaskId: "t1"})
+	require.NoError(t, err)
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_COMPLETED, got.Phase)
+	assert.Equal(t, "hello", got.Output)
+	assert.NotZero(t, got.EndTime)
+
+	// 工具以 name@digest 钉死，镜像与环境变量按运行时配置补齐
+	specs := sb.started()
+	require.Len(t, specs, 1)
+	assert.Equal(t, "echo", specs[0].ImageRef)
+	assert.Equal(t, "search@"+st.Tools[0].Digest, specs[0].Env[constants.EnvPinnedTools])
+	assert.Equal(t, "chat-1", specs[0].Env[constants.EnvContextID])
+	assert.Equal(t, "a1", specs[0].Env["AGENT"])
+	assert.Equal(t, "test", specs[0].Env["MODE"])
+	assert.Equal(t, constants.DefaultSandboxCPU, specs[0].Resource.CPU)
+
+	// 重复提交同一 task_id 不会再起沙箱
+	again, err := c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "t1"}})
+	require.NoError(t, err)
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_COMPLETED, again.Phase)
+	assert.Len(t, sb.started(), 1)
+}
+
+func TestExecuteTaskErrors(t *testing.T) {
+	_, c, sb := runtimeFixture(t)
+	ctx := context.Background()
+
+	_, err := c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "x", Tools: []string{"missing"}}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.GetTaskStatus(ctx, &agentv1.GetTaskStatusRequest{TaskId: "x"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Empty(t, sb.started())
+
+	// 沙箱起不来时任务直接失败
+	st, err := c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "b", ImageRef: "broken"}})
+	require.NoError(t, err)
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_FAILED, st.Phase)
+	assert.Contains(t, st.Message, "image pull failed")
+
+	_, err = c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "f", ImageRef: "fail"}})
+	require.NoError(t, err)
+	events := collect(t, c, "f", 0)
+	last := events[len(events)-1]
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_FAILED, last.Status.Phase)
+	assert.Equal(t, int32(3), last.Status.ExitCode)
+	assert.Contains(t, last.Status.Message, "boom")
+}
+
+func TestCancelTask(t *testing.T) {
+	rt, c, sb := runtimeFixture(t)
+	ctx := context.Background()
+
+	_, err := c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "long", ImageRef: "sleep"}})
+	require.NoError(t, err)
+	st, err := c.CancelTask(ctx, &agentv1.CancelTaskRequest{TaskId: "long", Reason: "user abort"})
+	require.NoError(t, err)
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_CANCELLED, st.Phase)
+	assert.Contains(t, st.Message, "user abort")
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_CANCELLED, phases(collect(t, c, "long", 0))[2])
+
+	// 已结束的任务取消是幂等的
+	st, err = c.CancelTask(ctx, &agentv1.CancelTaskRequest{TaskId: "long"})
+	require.NoError(t, err)
+	assert.Contains(t, st.Message, "user abort")
+
+	// 超时按失败处理
+	_, err = c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "slow", ImageRef: "sleep", TimeoutMs: 50}})
+	require.NoError(t, err)
+	events := collect(t, c, "slow", 0)
+	last := events[len(events)-1].Status
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_FAILED, last.Phase)
+	assert.Contains(t, last.Message, "timed out")
+
+	// 运行时退出时中止在跑的任务并等其收尾
+	_, err = c.ExecuteTask(ctx, &agentv1.ExecuteTaskRequest{Task: &agentv1.Task{TaskId: "orphan", ImageRef: "sleep"}})
+	require.NoError(t, err)
+	srv := &agentServer{Runtime: rt}
+	orphan, err := srv.task("orphan")
+	require.NoError(t, err)
+	rt.Stop()
+	assert.Equal(t, agentv1.TaskPhase_TASK_PHASE_CANCELLED, orphan.snapshot().Phase)
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	assert.Equal(t, 3, sb.kills)
+	assert.True(t, sb.closed)
+}
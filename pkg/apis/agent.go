@@ -3,9 +3,10 @@ package apis
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/turtacn/agenticai/internal/constants"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -24,16 +25,20 @@ var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: Version}
 // Agent CRD 顶层结构
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 type Agent struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec   AgentSpec   `json:"spec"`
+	Spec AgentSpec `json:"spec"`
+	// +optional
 	Status AgentStatus `json:"status"`
 }
 
 // AgentList 列表
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
 type AgentList struct {
 	metav1.TypeMeta `json:",inline"`
 	metav1.ListMeta `json:"metadata,omitempty"`
@@ -105,6 +110,22 @@ type AgentStatus struct {
 
 	// 版本校验
 	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// Runtimes 各 agent-runtime 实例最近一次心跳，按 ID 排序；长期失联的实例由控制器清理
+	Runtimes []RuntimeStatus `json:"runtimes,omitempty"`
+}
+
+// RuntimeStatus agent-runtime 定期上报的实例状态
+type RuntimeStatus struct {
+	ID            string      `json:"id"`
+	LastHeartbeat metav1.Time `json:"lastHeartbeat"`
+	// Capacity 实例可分给任务的资源总量，Free 为扣除运行中任务后的余量
+	Capacity       corev1.ResourceList `json:"capacity,omitempty"`
+	Free           corev1.ResourceList `json:"free,omitempty"`
+	RunningTasks   int32               `json:"runningTasks"`
+	Tools          []string            `json:"tools,omitempty"` // name@version
+	SandboxHealthy bool                `json:"sandboxHealthy"`
+	Message        string              `json:"message,omitempty"`
 }
 
 // AgentPhase 定义可能的生命周期状态
@@ -120,6 +141,20 @@ const (
 	AgentSucceeded AgentPhase = "Succeeded"
 )
 
+// AgentConditionAvailable 至少一个实例心跳正常且沙箱可用
+const AgentConditionAvailable = "Available"
+
+// AgentConditionPolicyCompliant Pod 模板满足全部匹配的 SecurityPolicy
+const AgentConditionPolicyCompliant = "PolicyCompliant"
+
+// HeartbeatTimeout 连续错过 AgentHeartbeatMisses 次心跳的实例视为不可用
+const HeartbeatTimeout = constants.AgentHeartbeatInterval * constants.AgentHeartbeatMisses
+
+// Alive 实例在 now 时刻心跳未超时
+func (s *RuntimeStatus) Alive(now time.Time) bool {
+	return now.Sub(s.LastHeartbeat.Time) <= HeartbeatTimeout
+}
+
 // AgentCondition 扩展 CRD status.conditions
 type AgentCondition struct {
 	Type               string                 `json:"type"`
@@ -136,29 +171,11 @@ type SandboxPolicy struct {
 	Options map[string]string `json:"options,omitempty"`
 }
 
-// Validate 为输入校验钩子
+// Validate 为输入校验钩子，不修改 spec；默认值与跨字段校验由 admission webhook 负责
 func (s *AgentSpec) Validate() error {
 	if s.ImageRef == "" {
 		return fmt.Errorf("imageRef required")
 	}
-	if s.Resources.Requests == nil {
-		s.Resources.Requests = corev1.ResourceList{}
-	}
-	if s.Resources.Limits == nil {
-		s.Resources.Limits = corev1.ResourceList{}
-	}
-	// 补充默认值
-	if s.Sandbox.Type == "" {
-		s.Sandbox.Type = "gvisor"
-	}
-	if s.Resources.Requests.Cpu().IsZero() {
-		defCpu := resource.MustParse(constants.DefaultSandboxCPU)
-		s.Resources.Requests[corev1.ResourceCPU] = defCpu
-	}
-	if s.Resources.Requests.Memory().IsZero() {
-		defMem := resource.MustParse(constants.DefaultSandboxMemory)
-		s.Resources.Requests[corev1.ResourceMemory] = defMem
-	}
 	return nil
 }
 
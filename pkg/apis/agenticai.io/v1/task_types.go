@@ -9,6 +9,8 @@ import (
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 
 // Task is the Schema for the tasks API
 type Task struct {
@@ -29,8 +31,8 @@ type TaskSpec struct {
 	ImageRef    string `json:"imageRef"`
 	Command     []string `json:"command,omitempty"`
 	Args        []string `json:"args,omitempty"`
-	Tools       []string `json:"tools,omitempty"` // 预定义已注册工具的 ID
-	ContextID   string   `json:"contextId,omitempty"`
+	Tools       []string `json:"tools,omitempty"` // 已注册工具引用：id、name、name@^1.2、name@sha256:...
+	ContextID   string   `json:"contextId,omitempty"` // ModelContextService 中的上下文 ID
 
 	// 资源
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
@@ -72,9 +74,13 @@ type TaskStatus struct {
 	GPUUsed    int64            `json:"gpuUsed,omitempty"`
 	TaskResult *TaskResult      `json:"taskResult,omitempty"`
 	Conditions []TaskCondition  `json:"conditions,omitempty"`
+
+	// ResolvedTools 首次调度时钉死的工具版本，之后发布新版本不影响该任务
+	ResolvedTools []ResolvedTool `json:"resolvedTools,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
 
 // TaskList contains a list of Task
 type TaskList struct {
@@ -95,6 +101,9 @@ const (
 	TaskCancelled   TaskPhase = "Cancelled"
 )
 
+// TaskConditionPolicyCompliant is True when the job template satisfies every matching SecurityPolicy.
+const TaskConditionPolicyCompliant = "PolicyCompliant"
+
 // TaskCondition aligns with K8s conditions.
 type TaskCondition struct {
 	Type               string             `json:"type"`
@@ -111,6 +120,15 @@ type TaskResult struct {
 	Artifact string `json:"artifact,omitempty"`
 }
 
+// ResolvedTool records which tool version a TaskSpec.Tools reference resolved to.
+type ResolvedTool struct {
+	Ref     string `json:"ref"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Digest  string `json:"digest"`
+}
+
 // RetryPolicy defines the retry strategy.
 type RetryPolicy struct {
 	Limit   int32           `json:"limit"`
@@ -8,14 +8,24 @@ import (
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 type SecurityPolicy struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	Spec PolicySpec `json:"spec"`
+	// +optional
+	Status SecurityPolicyStatus `json:"status,omitempty"`
+}
+
+// SecurityPolicyConditionReady 策略已通过校验并加载
+const SecurityPolicyConditionReady = "Ready"
 
-	// 可选 status 后期扩展
-	// Status SecurityPolicyStatus `json:"status,omitempty"`
+// SecurityPolicyStatus 由 SecurityPolicy 控制器维护
+type SecurityPolicyStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // PolicySpec 兼容 Kubernetes 约束框架
@@ -24,13 +34,16 @@ type PolicySpec struct {
 	Match       PolicyMatch       `json:"match"`
 	Constraints PolicyConstraints `json:"constraints"`
 	Rules       []RBACRule        `json:"rules,omitempty"`
+	// Bindings 把调用方身份映射到本策略内规则的 Role
+	Bindings []RoleBinding `json:"bindings,omitempty"`
 }
 
+// PolicyMatch 全部条件满足的工作负载受 Constraints 约束
 type PolicyMatch struct {
-	Kind        string            `json:"kind"`        // "Agent" | "Task" ...
+	Kind        string            `json:"kind"`        // "Agent" | "Task" ...，空为全部
 	Labels      map[string]string `json:"labels,omitempty"`
-	Namespaces  []string          `json:"namespaces,omitempty"`
-	Expressions []string          `json:"expressions,omitempty"`
+	Namespaces  []string          `json:"namespaces,omitempty"` // 支持 glob，空为策略所在命名空间
+	Expressions []string          `json:"expressions,omitempty"` // 标签选择器表达式，如 "tier in (prod,staging)"
 }
 type PolicyConstraints struct {
 	// 例：禁止高危系统调用
@@ -41,14 +54,34 @@ type PolicyConstraints struct {
 	AllowPrivileged bool `json:"allowPrivileged"`
 }
 
-// RBACRule 用于内存策略树
+// RuleEffect 规则命中后的效果
+type RuleEffect string
+
+const (
+	EffectAllow RuleEffect = "Allow"
+	EffectDeny  RuleEffect = "Deny" // 任一 Deny 命中即拒绝，优先于 Allow
+)
+
+// RBACRule 用于内存策略树；Role、Verbs、Resources 均为 glob：
+// * 匹配任意字符序列（含 /），? 匹配单个字符，{a,b} 匹配其一
 type RBACRule struct {
 	Role      string   `json:"role"`
 	Verbs     []string `json:"verbs"`  // "create","delete"...
 	Resources []string `json:"resources"` // "Agent","Task",...
+	// +kubebuilder:validation:Enum=Allow;Deny
+	// +optional
+	Effect RuleEffect `json:"effect,omitempty"` // 默认 Allow
+}
+
+// RoleBinding 调用方身份到角色的映射
+type RoleBinding struct {
+	Role string `json:"role"`
+	// Subjects SPIFFE ID 或 JWT sub，支持与规则相同的 glob
+	Subjects []string `json:"subjects"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
 type SecurityPolicyList struct {
 	metav1.TypeMeta `json:",inline"`
 	metav1.ListMeta `json:"metadata,omitempty"`
@@ -9,14 +9,28 @@ import (
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 type Tool struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ToolSpec `json:"spec"`
+	Spec   ToolSpec   `json:"spec"`
+	Status ToolStatus `json:"status,omitempty"`
+}
+
+// ToolStatus 由 ToolReconciler 维护，仅 OpenAPI 导入源（绑定了 SpecURL 且未指定 Operation）有内容
+type ToolStatus struct {
+	ObservedGeneration int64        `json:"observedGeneration,omitempty"`
+	Operations         int32        `json:"operations"`                 // 展开出的工具数
+	SpecDigest         string       `json:"specDigest,omitempty"`       // 最近一次拉取的文档原文摘要
+	LastSyncTime       *metav1.Time `json:"lastSyncTime,omitempty"`
+	ValidationErrors   []string     `json:"validationErrors,omitempty"` // 非空时保留上一次成功展开的工具
+	Message            string       `json:"message,omitempty"`          // 文档拉取失败的原因
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
 type ToolList struct {
 	metav1.TypeMeta `json:",inline"`
 	metav1.ListMeta `json:"metadata,omitempty"`
@@ -35,6 +49,10 @@ type ToolSpec struct {
 	Author      string            `json:"author,omitempty"`
 	Category    string            `json:"category,omitempty"`
 	Tags        map[string]string `json:"tags,omitempty"`
+	// ArgsSchema 任意 JSON Schema，CRD 中不做结构校验
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
 	ArgsSchema  AnyMap `json:"argsSchema,omitempty"`
 	Digest      string            `json:"digest,omitempty"`
 
@@ -50,6 +68,7 @@ type ToolSpec struct {
 	// 质量保障
 	CORS      *CORSPolicy `json:"cors,omitempty"`
 	RateLimit *RateLimit  `json:"rateLimit,omitempty"`
+	Cache     *CachePolicy `json:"cache,omitempty"`
 
 	// 可观测
 	DefaultTimeout metav1.Duration `json:"defaultTimeout,omitempty"`
@@ -67,6 +86,7 @@ type OpenAPIBinding struct {
 	SpecURL     string            `json:"specUrl"`     // OpenAPI/Swagger
 	BaseURL     string            `json:"baseUrl,omitempty"`
 	AuthMethods map[string]string `json:"authMethods,omitempty"` // securitySchemeKey->Value
+	Operation   string            `json:"operation,omitempty"`   // operationId 或 "METHOD /path"，为空时按工具 ID/Name 查找
 }
 
 // CustomBinding 容器化脚本/可执行
@@ -93,10 +113,19 @@ type CORSPolicy struct {
 	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
 }
 
-// RateLimit 网关级限制
+// RateLimit 按 工具×调用方 计算的限制
 type RateLimit struct {
-	Max    int32          `json:"max"`
-	Window *time.Duration `json:"window,omitempty"`
+	Max    int32          `json:"max"`              // 每个 Window 内的调用次数，0 不限
+	Window *time.Duration `json:"window,omitempty"` // 默认 1s
+
+	MaxConcurrent int32          `json:"maxConcurrent,omitempty"` // 同时在途的调用数，0 不限
+	MaxWait       *time.Duration `json:"maxWait,omitempty"`       // 超限时最多排队的时长，为空立即拒绝
+}
+
+// CachePolicy 结果缓存；只应对只读、幂等的工具开启
+type CachePolicy struct {
+	Cacheable bool            `json:"cacheable"`
+	TTL       metav1.Duration `json:"ttl,omitempty"` // 默认 1m
 }
 
 type Metadata struct {
@@ -110,6 +139,37 @@ type ToolResult struct {
 	Output string `json:"output,omitempty"`
 	Error  string `json:"error,omitempty"`
 	Status int32  `json:"status,omitempty"`
+	Cached bool   `json:"cached,omitempty"` // 来自结果缓存或幂等键重放，未实际调用
+
+	// MCP 富内容：text/image/resource 等分块，以及结构化输出
+	Content           []ToolContent `json:"content,omitempty"`
+	StructuredContent AnyMap        `json:"structuredContent,omitempty"`
+}
+
+// ToolEventType 流式调用的事件类型
+type ToolEventType string
+
+const (
+	ToolEventProgress ToolEventType = "progress" // 进度：Progress/Total/Message
+	ToolEventOutput   ToolEventType = "output"   // 增量输出：Chunk
+)
+
+// ToolEvent 流式调用过程中的中间事件，最终结果仍以 ToolResult 返回
+type ToolEvent struct {
+	Type     ToolEventType `json:"type"`
+	Progress float64       `json:"progress,omitempty"`
+	Total    float64       `json:"total,omitempty"` // 0 表示总量未知
+	Message  string        `json:"message,omitempty"`
+	Chunk    string        `json:"chunk,omitempty"`
+}
+
+// ToolContent 对应 MCP content block
+type ToolContent struct {
+	Type     string `json:"type"` // text / image / audio / resource / resource_link
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"` // base64
+	MimeType string `json:"mimeType,omitempty"`
+	URI      string `json:"uri,omitempty"`
 }
 
 type ToolFilter struct {
@@ -119,8 +179,11 @@ type ToolFilter struct {
 }
 
 type MCPServerInfo struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	ProtocolVersion string   `json:"protocolVersion,omitempty"`
+	Instructions    string   `json:"instructions,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"` // tools / resources / prompts / logging ...
 }
 
 type AnyMap map[string]interface{}
@@ -3,8 +3,10 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"go.uber.org/zap"
 	"reflect"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -15,20 +17,34 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
+	"github.com/turtacn/agenticai/internal/config"
 	"github.com/turtacn/agenticai/internal/constants"
 	"github.com/turtacn/agenticai/internal/logger"
 	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/webhook"
 )
 
 // AgentReconciler reconciles a Agent object
 type AgentReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Policies 为 nil 时不执行 SecurityPolicy 约束
+	Policies *PolicyEnforcer
+	// Sandbox 未启用 webhook 时 Agent 缺省的沙箱类型与资源请求，只用于生成 Deployment
+	Sandbox config.Sandbox
+
+	now func() time.Time
 }
 
+// agentRuntimeGC 失联超过该时长的实例从 status.runtimes 中移除
+const agentRuntimeGC = 10 * time.Minute
+
 //+kubebuilder:rbac:groups=agenticai.io,resources=agents,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=agenticai.io,resources=agents/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=agenticai.io,resources=agents/finalizers,verbs=update
@@ -70,6 +86,30 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
+	// 4.1 按匹配的 SecurityPolicy 改写 Pod 模板，违规时不下发，已运行的实例缩容到 0
+	origStatus := agent.Status.DeepCopy()
+	if r.Policies != nil {
+		ok, err := r.enforcePolicies(ctx, &agent, deploy)
+		if err != nil {
+			log.Error("evaluate security policies failed", zap.Error(err))
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		if !ok {
+			log.Warn("pod template violates security policy", zap.String("message", agent.Status.Message))
+			if err := r.scaleToZero(ctx, types.NamespacedName{Name: deploy.Name, Namespace: deploy.Namespace}); err != nil {
+				log.Error("unable to scale down non-compliant deployment", zap.Error(err))
+				return ctrl.Result{}, err
+			}
+			if !reflect.DeepEqual(*origStatus, agent.Status) {
+				if err := r.Status().Update(ctx, &agent); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			// 策略或 Agent 变更时经 watch 重新对齐
+			return ctrl.Result{}, nil
+		}
+	}
+
 	// 5. 设置 ownerRef→保证级联删除
 	if err := controllerutil.SetControllerReference(&agent, deploy, r.Scheme); err != nil {
 		log.Error("unable to set owner ref", zap.Error(err))
@@ -107,7 +147,7 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		log.Error("compute status failed", zap.Error(err))
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
-	if !reflect.DeepEqual(sts, agent.Status) {
+	if !reflect.DeepEqual(*sts, *origStatus) {
 		agent.Status = *sts
 		if err := r.Status().Update(ctx, &agent); err != nil {
 			log.Error("unable to update agent status", zap.Error(err))
@@ -115,7 +155,22 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 	}
 
-	return ctrl.Result{}, nil
+	// 在最早一个在线实例超时的时刻重新检查心跳
+	return ctrl.Result{RequeueAfter: nextHeartbeatCheck(sts.Runtimes, r.clock())}, nil
+}
+
+// scaleToZero 策略在 Agent 运行后才生效时停掉已有实例；保留 Deployment，合规后由正常流程恢复副本数
+func (r *AgentReconciler) scaleToZero(ctx context.Context, key types.NamespacedName) error {
+	var found appsv1.Deployment
+	if err := r.Get(ctx, key, &found); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if found.Spec.Replicas != nil && *found.Spec.Replicas == 0 {
+		return nil
+	}
+	zero := int32(0)
+	found.Spec.Replicas = &zero
+	return r.Update(ctx, &found)
 }
 
 // buildDeployment 拼装业务 + 沙箱 sidecars
@@ -135,14 +190,44 @@ func (r *AgentReconciler) buildDeployment(agent *apis.Agent) (*appsv1.Deployment
 		replicas = 1
 	}
 
+	// webhook 已补齐时不变
+	spec := agent.Spec.DeepCopy()
+	webhook.DefaultAgentSpec(spec, r.Sandbox)
+
 	// 构造 container
+	sec := agent.Spec.Security
 	mainContainer := corev1.Container{
 		Name:            "agent",
 		Image:           agent.Spec.ImageRef,
 		ImagePullPolicy: corev1.PullIfNotPresent,
-		Resources:       agent.Spec.Resources,
+		Resources:       spec.Resources,
+		// 运行时据此把心跳写回本 Agent
+		Env: []corev1.EnvVar{
+			{Name: constants.EnvRuntimeID, ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			}},
+			{Name: constants.EnvAgentName, Value: agent.Name},
+			{Name: constants.EnvAgentNamespace, Value: agent.Namespace},
+			// 未上报容量的实例不会被调度器派发任务
+			{Name: constants.EnvAgentCPU, Value: capacityOf(spec.Resources, corev1.ResourceCPU, constants.DefaultSandboxCPU)},
+			{Name: constants.EnvAgentMemory, Value: capacityOf(spec.Resources, corev1.ResourceMemory, constants.DefaultSandboxMemory)},
+			{Name: constants.EnvSandboxType, Value: spec.Sandbox.Type},
+		},
 		// Env:             envVars(agent.Spec.Env), // TODO: AgentSpec does not have Env
 	}
+	if sec.SecurityContext != nil {
+		mainContainer.SecurityContext = sec.SecurityContext.DeepCopy()
+	}
+	if len(sec.AllowedCapabilities) > 0 {
+		if mainContainer.SecurityContext == nil {
+			mainContainer.SecurityContext = &corev1.SecurityContext{}
+		}
+		if mainContainer.SecurityContext.Capabilities == nil {
+			mainContainer.SecurityContext.Capabilities = &corev1.Capabilities{}
+		}
+		caps := mainContainer.SecurityContext.Capabilities
+		caps.Add = append(caps.Add, sec.AllowedCapabilities...)
+	}
 	// if len(agent.Spec.Command) > 0 { // TODO: AgentSpec does not have Command
 	// 	mainContainer.Command = agent.Spec.Command
 	// }
@@ -152,10 +237,15 @@ func (r *AgentReconciler) buildDeployment(agent *apis.Agent) (*appsv1.Deployment
 
 	// 拼接 volume mounts & sidecar
 	podSpec := corev1.PodSpec{
-		Containers: []corev1.Container{mainContainer},
+		Containers:         []corev1.Container{mainContainer},
+		ServiceAccountName: sec.ServiceAccountName,
 		// sidecar injection 按 agent.Spec.Sandbox.Type
 	}
 
+	if sec.PodSecurityContext != nil {
+		podSpec.SecurityContext = sec.PodSecurityContext.DeepCopy()
+	}
+
 	// label 选择器
 	matchLabels := labels.Set{
 		"app.kubernetes.io/name":      constants.ProjectName,
@@ -180,13 +270,27 @@ func (r *AgentReconciler) buildDeployment(agent *apis.Agent) (*appsv1.Deployment
 	}, nil
 }
 
+// capacityOf 实例容量取 limit，未设置时取 request，都没有时取沙箱默认值
+func capacityOf(res corev1.ResourceRequirements, name corev1.ResourceName, def string) string {
+	if q, ok := res.Limits[name]; ok {
+		return q.String()
+	}
+	if q, ok := res.Requests[name]; ok {
+		return q.String()
+	}
+	return def
+}
+
 // computeStatus 收集底层 deployment 状态到 AgentStatus
 func (r *AgentReconciler) computeStatus(ctx context.Context, agent *apis.Agent, delp *appsv1.Deployment) (*apis.AgentStatus, error) {
 	deployment := &appsv1.Deployment{}
 	if err := r.Get(ctx, types.NamespacedName{
 		Name:      delp.Name,
 		Namespace: delp.Namespace,
-	}, deployment); err != nil && !errors.IsNotFound(err) {
+	}, deployment); errors.IsNotFound(err) {
+		// 刚创建的 Deployment 可能尚未进入缓存，先按期望值计算
+		deployment = delp
+	} else if err != nil {
 		return nil, err
 	}
 
@@ -196,10 +300,106 @@ func (r *AgentReconciler) computeStatus(ctx context.Context, agent *apis.Agent,
 		CurrentVersion:  deployment.Annotations["image-version"], // 自定义
 		Phase:           apis.AgentRunning,
 		Message:         "deployment healthy",
+		Conditions:      agent.Status.Conditions,
+	}
+	now := r.clock()
+	alive, healthy := 0, 0
+	for _, rs := range agent.Status.Runtimes {
+		if now.Sub(rs.LastHeartbeat.Time) > agentRuntimeGC {
+			continue
+		}
+		sts.Runtimes = append(sts.Runtimes, rs)
+		if rs.Alive(now) {
+			alive++
+			if rs.SandboxHealthy {
+				healthy++
+			}
+		}
+	}
+	switch {
+	case healthy > 0:
+		sts.Conditions = setAgentCondition(sts.Conditions, apis.AgentConditionAvailable, corev1.ConditionTrue, "HeartbeatReceived",
+			fmt.Sprintf("%d runtime(s) reporting", healthy), now)
+	case alive > 0:
+		sts.Conditions = setAgentCondition(sts.Conditions, apis.AgentConditionAvailable, corev1.ConditionFalse, "SandboxUnhealthy",
+			"no runtime has a healthy sandbox", now)
+	case len(sts.Runtimes) > 0:
+		sts.Conditions = setAgentCondition(sts.Conditions, apis.AgentConditionAvailable, corev1.ConditionFalse, "HeartbeatMissed",
+			fmt.Sprintf("no heartbeat within %s", apis.HeartbeatTimeout), now)
+	default:
+		sts.Conditions = setAgentCondition(sts.Conditions, apis.AgentConditionAvailable, corev1.ConditionFalse, "NoHeartbeat",
+			"waiting for the first runtime heartbeat", now)
 	}
 	return &sts, nil
 }
 
+// setAgentCondition 只在状态变化时更新 LastTransitionTime
+func setAgentCondition(conds []apis.AgentCondition, condType string, status corev1.ConditionStatus, reason, msg string, now time.Time) []apis.AgentCondition {
+	out := make([]apis.AgentCondition, 0, len(conds)+1)
+	cond := apis.AgentCondition{
+		Type: condType, Status: status, Reason: reason, Message: msg,
+		LastUpdateTime: metav1.NewTime(now), LastTransitionTime: metav1.NewTime(now),
+	}
+	for _, c := range conds {
+		if c.Type != condType {
+			out = append(out, c)
+			continue
+		}
+		if c.Status == status {
+			cond.LastTransitionTime = c.LastTransitionTime
+			if c.Reason == reason && c.Message == msg {
+				cond.LastUpdateTime = c.LastUpdateTime
+			}
+		}
+	}
+	return append(out, cond)
+}
+
+// enforcePolicies 把策略结论写入 PolicyCompliant 条件；违规时同时把 Agent 标记为 Failed
+func (r *AgentReconciler) enforcePolicies(ctx context.Context, agent *apis.Agent, deploy *appsv1.Deployment) (bool, error) {
+	matched, violations, err := r.Policies.Enforce(ctx, "Agent", agent, &deploy.Spec.Template)
+	if err != nil {
+		return false, err
+	}
+	now := r.clock()
+	if len(violations) > 0 {
+		msg := "security policy violation: " + strings.Join(violations, "; ")
+		agent.Status.Conditions = setAgentCondition(agent.Status.Conditions, apis.AgentConditionPolicyCompliant,
+			corev1.ConditionFalse, "PolicyViolation", msg, now)
+		agent.Status.Phase = apis.AgentFailed
+		agent.Status.Message = msg
+		return false, nil
+	}
+	msg := "no security policy matches"
+	if len(matched) > 0 {
+		msg = "applied " + security.PolicyNames(matched)
+	}
+	agent.Status.Conditions = setAgentCondition(agent.Status.Conditions, apis.AgentConditionPolicyCompliant,
+		corev1.ConditionTrue, "PolicyApplied", msg, now)
+	return true, nil
+}
+
+// nextHeartbeatCheck 没有在线实例时不定时重查，等心跳写入 status 触发
+func nextHeartbeatCheck(runtimes []apis.RuntimeStatus, now time.Time) time.Duration {
+	var next time.Duration
+	for _, rs := range runtimes {
+		if !rs.Alive(now) {
+			continue
+		}
+		if d := rs.LastHeartbeat.Add(apis.HeartbeatTimeout).Sub(now) + time.Second; next == 0 || d < next {
+			next = d
+		}
+	}
+	return next
+}
+
+func (r *AgentReconciler) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
 func (r *AgentReconciler) markFailed(ctx context.Context, agent *apis.Agent, err error) {
 	agent.Status.Phase = apis.AgentFailed
 	agent.Status.Message = err.Error()
@@ -215,9 +415,13 @@ func envVars(src []corev1.EnvVar) []corev1.EnvVar {
 
 // SetupWithManager wired into controller-manager
 func (r *AgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&apis.Agent{}).
-		Owns(&appsv1.Deployment{}).
-		Complete(r)
+		Owns(&appsv1.Deployment{})
+	if r.Policies != nil {
+		b = b.Watches(&apis.SecurityPolicy{}, enqueueAll(mgr.GetClient(), &apis.AgentList{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	}
+	return b.Complete(r)
 }
 //Personal.AI order the ending
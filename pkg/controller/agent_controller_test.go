@@ -1,13 +1,24 @@
 package controller
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/constants"
+	agentpkg "github.com/turtacn/agenticai/pkg/agent"
 	"github.com/turtacn/agenticai/pkg/apis"
 )
 
@@ -43,4 +54,117 @@ func TestBuildDeployment(t *testing.T) {
 	assert.Equal(t, int32(2), *deployment.Spec.Replicas)
 	assert.Equal(t, "test-image:latest", deployment.Spec.Template.Spec.Containers[0].Image)
 	assert.Equal(t, "custom-value", deployment.Spec.Template.Labels["custom-label"])
+
+	// 运行时心跳所需的身份经环境变量注入
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	require.Len(t, env, 6)
+	assert.Equal(t, constants.EnvRuntimeID, env[0].Name)
+	assert.Equal(t, "metadata.name", env[0].ValueFrom.FieldRef.FieldPath)
+	assert.Equal(t, "test-agent", env[1].Value)
+	assert.Equal(t, "default", env[2].Value)
+	// 未声明资源时按沙箱默认值上报容量
+	assert.Equal(t, corev1.EnvVar{Name: constants.EnvAgentCPU, Value: constants.DefaultSandboxCPU}, env[3])
+	assert.Equal(t, corev1.EnvVar{Name: constants.EnvAgentMemory, Value: constants.DefaultSandboxMemory}, env[4])
+
+	// 未经 webhook 补齐的 Agent：Deployment 取默认沙箱类型与资源请求，Agent 本身不改
+	assert.Equal(t, corev1.EnvVar{Name: constants.EnvSandboxType, Value: constants.DefaultSandboxType}, env[5])
+	req := deployment.Spec.Template.Spec.Containers[0].Resources.Requests
+	assert.Equal(t, resource.MustParse(constants.DefaultSandboxCPU), req[corev1.ResourceCPU])
+	assert.Equal(t, resource.MustParse(constants.DefaultSandboxMemory), req[corev1.ResourceMemory])
+	assert.Empty(t, agent.Spec.Sandbox.Type)
+	assert.Nil(t, agent.Spec.Resources.Requests)
+
+	// 配置的默认值优先于内置默认值，已声明的类型不变
+	reconciler.Sandbox = config.Sandbox{Type: "kata", CPULimit: "250m"}
+	deployment, err = reconciler.buildDeployment(agent)
+	require.NoError(t, err)
+	env = deployment.Spec.Template.Spec.Containers[0].Env
+	assert.Equal(t, "kata", env[5].Value)
+	assert.Equal(t, "250m", env[3].Value)
+	agent.Spec.Sandbox.Type = "gvisor"
+	deployment, err = reconciler.buildDeployment(agent)
+	require.NoError(t, err)
+	assert.Equal(t, "gvisor", deployment.Spec.Template.Spec.Containers[0].Env[5].Value)
+
+	// limit 优先于 request
+	agent.Spec.Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+	}
+	deployment, err = reconciler.buildDeployment(agent)
+	require.NoError(t, err)
+	env = deployment.Spec.Template.Spec.Containers[0].Env
+	assert.Equal(t, "2", env[3].Value)
+	assert.Equal(t, "1Gi", env[4].Value)
+}
+
+func TestAgentAvailabilityFromHeartbeats(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, apis.AddToScheme(s))
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	agent := &apis.Agent{
+		ObjectMeta: metav1.ObjectMeta{Name: "coder", Namespace: "agents"},
+		Spec:       apis.AgentSpec{ImageRef: "coder:1"},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(agent).WithStatusSubresource(&apis.Agent{}).Build()
+	r := &AgentReconciler{Client: c, Scheme: s, now: func() time.Time { return clock }}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "agents", Name: "coder"}}
+	ctx := context.Background()
+
+	available := func() apis.AgentCondition {
+		var got apis.Agent
+		require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+		for _, cond := range got.Status.Conditions {
+			if cond.Type == apis.AgentConditionAvailable {
+				return cond
+			}
+		}
+		t.Fatal("no Available condition")
+		return apis.AgentCondition{}
+	}
+	beat := func(id string, healthy bool) {
+		var got apis.Agent
+		require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+		got.Status.Runtimes = agentpkg.UpsertRuntimeStatus(got.Status.Runtimes, &apis.RuntimeStatus{
+			ID: id, LastHeartbeat: metav1.NewTime(clock), SandboxHealthy: healthy,
+		})
+		require.NoError(t, c.Status().Update(ctx, &got))
+	}
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, res.RequeueAfter)
+	assert.Equal(t, "NoHeartbeat", available().Reason)
+
+	beat("pod-1", true)
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cond := available()
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	// 在心跳超时的时刻复查
+	assert.Equal(t, apis.HeartbeatTimeout+time.Second, res.RequeueAfter)
+
+	// 沙箱不可用的实例不算可用
+	clock = clock.Add(20 * time.Second)
+	beat("pod-1", false)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "SandboxUnhealthy", available().Reason)
+
+	// 连续错过心跳后标记不可用
+	clock = clock.Add(apis.HeartbeatTimeout + time.Second)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cond = available()
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "HeartbeatMissed", cond.Reason)
+
+	// 长期失联的实例被清理
+	clock = clock.Add(agentRuntimeGC)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	var got apis.Agent
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+	assert.Empty(t, got.Status.Runtimes)
 }
@@ -0,0 +1,151 @@
+// pkg/controller/manager.go
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	crwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/pkg/apis"
+	agenticaiov1 "github.com/turtacn/agenticai/pkg/apis/agenticai.io/v1"
+	"github.com/turtacn/agenticai/pkg/tools"
+	"github.com/turtacn/agenticai/pkg/webhook"
+)
+
+// ManagerOptions 控制器进程的选主、监听与并发参数
+type ManagerOptions struct {
+	// K8sController 工作线程数、选主锁名、监听命名空间（空为全部）与 OpenAPI 文档重拉间隔
+	K8sController config.K8sController
+	// LeaderElection 多副本部署时开启
+	LeaderElection bool
+	// LeaderElectionNamespace 集群外运行时必须指定，集群内取 Pod 所在命名空间
+	LeaderElectionNamespace string
+	// MetricsAddr 为 "0" 时关闭指标端点
+	MetricsAddr string
+	// ProbeAddr healthz/readyz 端点，为空时关闭
+	ProbeAddr string
+	// WebhookPort 准入 webhook 监听端口，0 时不注册 webhook
+	WebhookPort int
+	// WebhookCertDir 存放 tls.crt/tls.key，为空时取 controller-runtime 默认目录
+	WebhookCertDir string
+	// Admission webhook 的默认值与镜像白名单；Tools 与 Reader 由 SetupAll 填充
+	Admission webhook.Options
+}
+
+// NewScheme 包含内置资源与本项目全部 CRD
+func NewScheme() (*runtime.Scheme, error) {
+	s := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		apis.AddToScheme,
+		agenticaiov1.AddToScheme,
+	} {
+		if err := add(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// NewManager 创建 manager 并注册全部控制器与探针，调用方负责 Start
+func NewManager(ctx context.Context, restCfg *rest.Config, opts ManagerOptions) (ctrl.Manager, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	kc := opts.K8sController
+	mgrOpts := ctrl.Options{
+		Scheme:                  scheme,
+		Metrics:                 metricsserver.Options{BindAddress: opts.MetricsAddr},
+		HealthProbeBindAddress:  opts.ProbeAddr,
+		LeaderElection:          opts.LeaderElection,
+		LeaderElectionID:        kc.LeaderElectionID,
+		LeaderElectionNamespace: opts.LeaderElectionNamespace,
+		// 让出锁后立即退出，新主无需等待租约过期
+		LeaderElectionReleaseOnCancel: true,
+		Controller:                    ctrlconfig.Controller{MaxConcurrentReconciles: kc.WorkerThreads},
+	}
+	if opts.WebhookPort > 0 {
+		mgrOpts.WebhookServer = crwebhook.NewServer(crwebhook.Options{Port: opts.WebhookPort, CertDir: opts.WebhookCertDir})
+	}
+	if kc.WatchNamespace != "" {
+		mgrOpts.Cache = cache.Options{DefaultNamespaces: map[string]cache.Config{kc.WatchNamespace: {}}}
+	}
+	mgr, err := ctrl.NewManager(restCfg, mgrOpts)
+	if err != nil {
+		return nil, fmt.Errorf("new manager: %w", err)
+	}
+	if err := SetupAll(ctx, mgr, opts); err != nil {
+		return nil, err
+	}
+	if opts.ProbeAddr != "" {
+		if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+			return nil, err
+		}
+		// 启用 webhook 时等证书加载、端口监听后才就绪
+		ready := healthz.Ping
+		if opts.WebhookPort > 0 {
+			ready = mgr.GetWebhookServer().StartedChecker()
+		}
+		if err := mgr.AddReadyzCheck("readyz", ready); err != nil {
+			return nil, err
+		}
+	}
+	return mgr, nil
+}
+
+// SetupAll 把全部控制器与 webhook 注册到 mgr，新增控制器在此追加
+func SetupAll(ctx context.Context, mgr ctrl.Manager, opts ManagerOptions) error {
+	reg, err := tools.NewCRDRegistry(ctx, mgr.GetClient(), mgr.GetCache(), opts.K8sController.WatchNamespace)
+	if err != nil {
+		return err
+	}
+	// 过期 Tool 对象只由选主成功的控制器删除
+	sweeper, err := tools.NewExpirySweeper(reg)
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(sweeper); err != nil {
+		return err
+	}
+	c, s := mgr.GetClient(), mgr.GetScheme()
+	policies := &PolicyEnforcer{Reader: c, Profiles: &SeccompProfiles{
+		Reader: mgr.GetAPIReader(), Writer: c,
+		Namespace: constants.DefaultNamespace, Name: constants.SeccompProfilesConfigMap,
+	}}
+	for _, r := range []interface{ SetupWithManager(ctrl.Manager) error }{
+		&AgentReconciler{Client: c, Scheme: s, Policies: policies, Sandbox: opts.Admission.Sandbox},
+		&TaskReconciler{Client: c, Scheme: s, Tools: reg, Policies: policies},
+		&ToolReconciler{Client: c, Scheme: s, ResyncInterval: opts.K8sController.ToolResync},
+		// 控制器进程不做 RBAC 判定，只校验策略并维护状态；网关等进程各自加载 RBAC
+		&SecurityPolicyReconciler{Client: c, Scheme: s},
+	} {
+		if err := r.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("setup %T: %w", r, err)
+		}
+	}
+	if opts.WebhookPort > 0 {
+		adm := opts.Admission
+		adm.Tools, adm.Reader = reg, c
+		if err := webhook.Setup(mgr, adm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Personal.AI order the ending
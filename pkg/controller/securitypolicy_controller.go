@@ -0,0 +1,232 @@
+// pkg/controller/securitypolicy_controller.go
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/security"
+)
+
+// SecurityPolicyReconciler 校验 SecurityPolicy 并维护其状态，设置了 RBAC 时同时加载其 RBAC 部分；
+// Constraints 由 Agent/Task 控制器经 PolicyEnforcer 在生成 Pod 模板时执行
+type SecurityPolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// RBAC 供同进程内做判定的组件查询，为 nil 时只校验并维护状态
+	RBAC security.RBAC
+}
+
+//+kubebuilder:rbac:groups=agenticai.io,resources=securitypolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=agenticai.io,resources=securitypolicies/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;update
+
+func (r *SecurityPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logger.WithCtx(ctx).With(zap.String("securitypolicy", req.NamespacedName.String()))
+
+	var pol apis.SecurityPolicy
+	if err := r.Get(ctx, req.NamespacedName, &pol); err != nil {
+		if apierrs.IsNotFound(err) {
+			if r.RBAC != nil {
+				r.RBAC.RemovePolicy(req.Namespace, req.Name)
+			}
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	status := pol.Status.DeepCopy()
+	status.ObservedGeneration = pol.Generation
+	cond := metav1.Condition{
+		Type: apis.SecurityPolicyConditionReady, Status: metav1.ConditionTrue,
+		Reason: "Loaded", Message: "policy loaded", ObservedGeneration: pol.Generation,
+	}
+	err := security.ValidatePolicy(&pol)
+	if err == nil && r.RBAC != nil {
+		err = r.RBAC.UpdatePolicy(&pol)
+	}
+	if err != nil {
+		// RBAC 引擎保留上一版本；匹配到该策略的工作负载被拒绝
+		log.Warn("invalid security policy", zap.Error(err))
+		cond.Status, cond.Reason, cond.Message = metav1.ConditionFalse, "InvalidPolicy", err.Error()
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+
+	if !reflect.DeepEqual(*status, pol.Status) {
+		pol.Status = *status
+		if err := r.Status().Update(ctx, &pol); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager 状态更新不改变 generation，不会触发自身重入
+func (r *SecurityPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apis.SecurityPolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// PolicyEnforcer 按匹配到的 SecurityPolicy 改写或拒绝工作负载的 Pod 模板
+type PolicyEnforcer struct {
+	client.Reader
+	// Profiles 发布 Pod 引用的 seccomp profile，为 nil 时只改写模板
+	Profiles *SeccompProfiles
+}
+
+// SeccompProfiles 把生成的 seccomp profile 以 <name>.json 为键写入一个 ConfigMap，
+// 由 config/seccomp 中的 DaemonSet 同步到各节点 kubelet 的 seccomp 目录
+type SeccompProfiles struct {
+	// Reader 应绕过缓存，避免为 ConfigMap 建立全集群 informer
+	client.Reader
+	client.Writer
+	Namespace string
+	Name      string
+}
+
+// Ensure 内容未变化时不写入
+func (p *SeccompProfiles) Ensure(ctx context.Context, name string, prof *security.SeccompProfile) error {
+	raw, err := json.MarshalIndent(prof, "", "  ")
+	if err != nil {
+		return err
+	}
+	key := name + ".json"
+	var cm corev1.ConfigMap
+	err = p.Get(ctx, client.ObjectKey{Namespace: p.Namespace, Name: p.Name}, &cm)
+	switch {
+	case apierrs.IsNotFound(err):
+		cm = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: p.Namespace, Name: p.Name},
+			Data:       map[string]string{key: string(raw)},
+		}
+		return p.Create(ctx, &cm)
+	case err != nil:
+		return err
+	case cm.Data[key] == string(raw):
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[key] = string(raw)
+	return p.Update(ctx, &cm)
+}
+
+// Enforce 返回命中的策略与违规项；模板被就地改写并注明生效的策略。
+// 匹配条件非法的策略按违规处理，不放行
+func (e *PolicyEnforcer) Enforce(ctx context.Context, kind string, obj client.Object, tpl *corev1.PodTemplateSpec) ([]*apis.SecurityPolicy, []string, error) {
+	var list apis.SecurityPolicyList
+	if err := e.List(ctx, &list); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		a, b := list.Items[i], list.Items[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+
+	target := security.PolicyTarget{Kind: kind, Namespace: obj.GetNamespace(), Labels: map[string]string{}}
+	for k, v := range obj.GetLabels() {
+		target.Labels[k] = v
+	}
+	for k, v := range tpl.Labels {
+		target.Labels[k] = v
+	}
+
+	var matched []*apis.SecurityPolicy
+	var violations []string
+	for i := range list.Items {
+		pol := &list.Items[i]
+		ok, err := security.MatchPolicy(pol, target)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("policy %s/%s: %v", pol.Namespace, pol.Name, err))
+			continue
+		}
+		if ok {
+			matched = append(matched, pol)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, violations, nil
+	}
+
+	c := security.MergeConstraints(matched)
+	// Agent Pod 内由 agent-runtime 启动 runsc/kata，它们需要 mount、ptrace 等调用，
+	// 系统调用限制只经环境变量下发给沙箱，Pod 本身不套 Localhost profile
+	pod := c
+	if kind == "Agent" {
+		pod.SysCallRestriction = nil
+	}
+	violations = append(violations, security.EnforcePodConstraints(tpl, pod)...)
+	if tpl.Annotations == nil {
+		tpl.Annotations = map[string]string{}
+	}
+	tpl.Annotations[constants.AnnotationSecurityPolicies] = security.PolicyNames(matched)
+	if len(c.SysCallRestriction) > 0 {
+		syscalls := strings.Join(c.SysCallRestriction, ",")
+		tpl.Annotations[constants.AnnotationRestrictedSyscalls] = syscalls
+		// agent-runtime 据此写入其启动的沙箱 bundle 的 linux.seccomp
+		for i := range tpl.Spec.Containers {
+			setEnv(&tpl.Spec.Containers[i], constants.EnvRestrictedSyscalls, syscalls)
+		}
+		if e.Profiles != nil && len(pod.SysCallRestriction) > 0 && len(violations) == 0 {
+			if err := e.Profiles.Ensure(ctx, security.SeccompProfileName(c.SysCallRestriction),
+				security.SeccompProfileFor(c.SysCallRestriction)); err != nil {
+				return nil, nil, fmt.Errorf("publish seccomp profile: %w", err)
+			}
+		}
+	}
+	return matched, violations, nil
+}
+
+func setEnv(ctr *corev1.Container, name, value string) {
+	for i := range ctr.Env {
+		if ctr.Env[i].Name == name {
+			ctr.Env[i] = corev1.EnvVar{Name: name, Value: value}
+			return
+		}
+	}
+	ctr.Env = append(ctr.Env, corev1.EnvVar{Name: name, Value: value})
+}
+
+// enqueueAll 任一策略变化时重新对齐 list 中的全部对象
+func enqueueAll(c client.Reader, list client.ObjectList) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, _ client.Object) []reconcile.Request {
+		l := list.DeepCopyObject().(client.ObjectList)
+		if err := c.List(ctx, l); err != nil {
+			return nil
+		}
+		items, err := meta.ExtractList(l)
+		if err != nil {
+			return nil
+		}
+		reqs := make([]reconcile.Request, 0, len(items))
+		for _, it := range items {
+			o := it.(client.Object)
+			reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+		}
+		return reqs
+	})
+}
+
+//Personal.AI order the ending
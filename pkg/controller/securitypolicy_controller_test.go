@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/pkg/apis"
+	agenticaiov1 "github.com/turtacn/agenticai/pkg/apis/agenticai.io/v1"
+	"github.com/turtacn/agenticai/pkg/security"
+)
+
+func policyScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, apis.AddToScheme(s))
+	require.NoError(t, agenticaiov1.AddToScheme(s))
+	return s
+}
+
+func TestSecurityPolicyReconcile(t *testing.T) {
+	s := policyScheme(t)
+	pol := &apis.SecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "agents", Name: "callers", Generation: 2},
+		Spec: apis.PolicySpec{
+			Rules:    []apis.RBACRule{{Role: "caller", Verbs: []string{"invoke"}, Resources: []string{"tools/*"}}},
+			Bindings: []apis.RoleBinding{{Role: "caller", Subjects: []string{"alice"}}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(pol).WithStatusSubresource(&apis.SecurityPolicy{}).Build()
+	rbac := security.NewRBAC()
+	r := &SecurityPolicyReconciler{Client: c, Scheme: s, RBAC: rbac}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pol)}
+
+	ready := func() *metav1.Condition {
+		var got apis.SecurityPolicy
+		require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+		assert.Equal(t, int64(2), got.Status.ObservedGeneration)
+		return meta.FindStatusCondition(got.Status.Conditions, apis.SecurityPolicyConditionReady)
+	}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ConditionTrue, ready().Status)
+	assert.True(t, rbac.Decide(ctx, "alice", "invoke", "tools/search").Allowed)
+
+	// 非法规则：状态报错，RBAC 保留上一版本
+	require.NoError(t, c.Get(ctx, req.NamespacedName, pol))
+	pol.Spec.Match.Expressions = []string{"tier in (("}
+	require.NoError(t, c.Update(ctx, pol))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cond := ready()
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "InvalidPolicy", cond.Reason)
+	assert.True(t, rbac.Decide(ctx, "alice", "invoke", "tools/search").Allowed)
+
+	require.NoError(t, c.Delete(ctx, pol))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, rbac.Decide(ctx, "alice", "invoke", "tools/search").Allowed)
+}
+
+func TestAgentPolicyEnforcement(t *testing.T) {
+	s := policyScheme(t)
+	pol := &apis.SecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "agents", Name: "strict"},
+		Spec: apis.PolicySpec{
+			Match:       apis.PolicyMatch{Kind: "Agent", Labels: map[string]string{"tier": "prod"}},
+			Constraints: apis.PolicyConstraints{ReadOnlyRootFS: true, SysCallRestriction: []string{"ptrace", "mount"}},
+		},
+	}
+	yes := true
+	agent := &apis.Agent{
+		ObjectMeta: metav1.ObjectMeta{Name: "coder", Namespace: "agents"},
+		Spec: apis.AgentSpec{
+			ImageRef: "coder:1", Labels: map[string]string{"tier": "prod"},
+			Security: apis.AgentSecurity{SecurityContext: &corev1.SecurityContext{Privileged: &yes}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(pol, agent).WithStatusSubresource(&apis.Agent{}).Build()
+	profiles := &SeccompProfiles{Reader: c, Writer: c, Namespace: "agenticai-system", Name: constants.SeccompProfilesConfigMap}
+	r := &AgentReconciler{Client: c, Scheme: s, Policies: &PolicyEnforcer{Reader: c, Profiles: profiles}}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "agents", Name: "coder"}}
+	cmKey := types.NamespacedName{Namespace: profiles.Namespace, Name: profiles.Name}
+
+	compliance := func() (*apis.Agent, apis.AgentCondition) {
+		var got apis.Agent
+		require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+		for _, cond := range got.Status.Conditions {
+			if cond.Type == apis.AgentConditionPolicyCompliant {
+				return &got, cond
+			}
+		}
+		t.Fatal("PolicyCompliant condition not set")
+		return nil, apis.AgentCondition{}
+	}
+
+	// 特权容器违反策略：不创建 Deployment
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	got, cond := compliance()
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "PolicyViolation", cond.Reason)
+	assert.Equal(t, apis.AgentFailed, got.Status.Phase)
+	assert.Contains(t, got.Status.Message, "privileged")
+	var deploy appsv1.Deployment
+	assert.True(t, apierrs.IsNotFound(c.Get(ctx, req.NamespacedName, &deploy)))
+	var cm corev1.ConfigMap
+	assert.True(t, apierrs.IsNotFound(c.Get(ctx, cmKey, &cm)), "profile is not published for rejected workloads")
+
+	// 去掉特权后按策略改写模板
+	got.Spec.Security.SecurityContext = nil
+	require.NoError(t, c.Update(ctx, got))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	got, cond = compliance()
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, apis.AgentRunning, got.Status.Phase)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &deploy))
+	tpl := deploy.Spec.Template
+	assert.True(t, *tpl.Spec.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	assert.Equal(t, "agents/strict", tpl.Annotations[constants.AnnotationSecurityPolicies])
+	assert.Equal(t, "mount,ptrace", tpl.Annotations[constants.AnnotationRestrictedSyscalls])
+	assert.Contains(t, tpl.Spec.Containers[0].Env, corev1.EnvVar{Name: constants.EnvRestrictedSyscalls, Value: "mount,ptrace"})
+
+	// Agent Pod 内运行沙箱运行时，不套 Localhost profile，也无需发布
+	assert.Nil(t, tpl.Spec.SecurityContext)
+	assert.Nil(t, tpl.Spec.Containers[0].SecurityContext.SeccompProfile)
+	assert.True(t, apierrs.IsNotFound(c.Get(ctx, cmKey, &cm)))
+}
+
+func TestTaskPolicySeccompProfile(t *testing.T) {
+	s := policyScheme(t)
+	pol := &apis.SecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "strict"},
+		Spec: apis.PolicySpec{
+			Match:       apis.PolicyMatch{Kind: "Task"},
+			Constraints: apis.PolicyConstraints{SysCallRestriction: []string{"ptrace", "unshare"}},
+		},
+	}
+	task := &agenticaiov1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "default"},
+		Spec:       agenticaiov1.TaskSpec{ImageRef: "busybox"},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(pol, task).WithStatusSubresource(&agenticaiov1.Task{}).Build()
+	profiles := &SeccompProfiles{Reader: c, Writer: c, Namespace: "agenticai-system", Name: constants.SeccompProfilesConfigMap}
+	r := &TaskReconciler{Client: c, Scheme: s, Policies: &PolicyEnforcer{Reader: c, Profiles: profiles}}
+	ctx := context.Background()
+
+	// 禁止集合超出 agent-default 时 Job 改用生成的 profile 并发布到 ConfigMap
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(task)})
+	require.NoError(t, err)
+	var job batchv1.Job
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "t1-job"}, &job))
+	sp := job.Spec.Template.Spec.SecurityContext.SeccompProfile
+	name := security.SeccompProfileName([]string{"unshare"})
+	assert.NotEqual(t, security.AgentDefaultProfile, name)
+	assert.Equal(t, corev1.SeccompProfileTypeLocalhost, sp.Type)
+	assert.Equal(t, security.LocalhostProfile(name), *sp.LocalhostProfile)
+	var cm corev1.ConfigMap
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: profiles.Namespace, Name: profiles.Name}, &cm))
+	assert.Contains(t, cm.Data[name+".json"], `"unshare"`)
+}
+
+func TestAgentPolicyAddedAfterRunning(t *testing.T) {
+	s := policyScheme(t)
+	yes := true
+	agent := &apis.Agent{
+		ObjectMeta: metav1.ObjectMeta{Name: "coder", Namespace: "agents"},
+		Spec: apis.AgentSpec{
+			ImageRef: "coder:1", Replicas: 2,
+			Security: apis.AgentSecurity{SecurityContext: &corev1.SecurityContext{Privileged: &yes}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(agent).WithStatusSubresource(&apis.Agent{}).Build()
+	r := &AgentReconciler{Client: c, Scheme: s, Policies: &PolicyEnforcer{Reader: c}}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "agents", Name: "coder"}}
+	replicas := func() int32 {
+		var deploy appsv1.Deployment
+		require.NoError(t, c.Get(ctx, req.NamespacedName, &deploy))
+		return *deploy.Spec.Replicas
+	}
+
+	// 没有策略时正常运行
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), replicas())
+
+	// 之后新增的策略禁止特权容器：已有实例缩容到 0
+	require.NoError(t, c.Create(ctx, &apis.SecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "agents", Name: "strict"},
+		Spec:       apis.PolicySpec{Match: apis.PolicyMatch{Kind: "Agent"}},
+	}))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), replicas())
+	var got apis.Agent
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+	assert.Equal(t, apis.AgentFailed, got.Status.Phase)
+
+	// 合规后恢复副本数
+	got.Spec.Security.SecurityContext = nil
+	require.NoError(t, c.Update(ctx, &got))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), replicas())
+}
+
+func TestSeccompProfilesEnsure(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(policyScheme(t)).Build()
+	p := &SeccompProfiles{Reader: c, Writer: c, Namespace: "agenticai-system", Name: constants.SeccompProfilesConfigMap}
+	ctx := context.Background()
+	require.NoError(t, p.Ensure(ctx, "a", security.SeccompProfileFor(nil)))
+	require.NoError(t, p.Ensure(ctx, "b", security.SeccompProfileFor([]string{"unshare"})))
+
+	var cm corev1.ConfigMap
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: p.Namespace, Name: p.Name}, &cm))
+	assert.Len(t, cm.Data, 2)
+	rv := cm.ResourceVersion
+	// 内容未变化时不写入
+	require.NoError(t, p.Ensure(ctx, "a", security.SeccompProfileFor(nil)))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: p.Namespace, Name: p.Name}, &cm))
+	assert.Equal(t, rv, cm.ResourceVersion)
+}
+
+func TestTaskBlockedByPolicy(t *testing.T) {
+	s := policyScheme(t)
+	pol := &apis.SecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "broken"},
+		Spec:       apis.PolicySpec{Match: apis.PolicyMatch{Kind: "Task", Expressions: []string{"tier in (("}}},
+	}
+	task := &agenticaiov1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "t1", Namespace: "default"},
+		Spec:       agenticaiov1.TaskSpec{ImageRef: "busybox"},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(pol, task).WithStatusSubresource(&agenticaiov1.Task{}).Build()
+	r := &TaskReconciler{Client: c, Scheme: s, Policies: &PolicyEnforcer{Reader: c}}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(task)}
+
+	// 匹配条件非法的策略不放行
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	var got agenticaiov1.Task
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+	assert.Equal(t, agenticaiov1.TaskPending, got.Status.Phase)
+	assert.Equal(t, "blocked by security policy", got.Status.Message)
+	require.Len(t, got.Status.Conditions, 1)
+	assert.Equal(t, metav1.ConditionFalse, got.Status.Conditions[0].Status)
+	var job batchv1.Job
+	assert.True(t, apierrs.IsNotFound(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "t1-job"}, &job)))
+
+	require.NoError(t, c.Delete(ctx, pol))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+	assert.Equal(t, metav1.ConditionTrue, got.Status.Conditions[0].Status)
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "t1-job"}, &job))
+}
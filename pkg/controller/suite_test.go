@@ -0,0 +1,76 @@
+package controller_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/controller"
+)
+
+// TestManagerEnvtest 以真实 apiserver 启动 manager；需要 KUBEBUILDER_ASSETS 指向 setup-envtest 下载的二进制
+func TestManagerEnvtest(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS not set, skipping envtest")
+	}
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := env.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = env.Stop() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr, err := controller.NewManager(ctx, cfg, controller.ManagerOptions{
+		K8sController:           config.K8sController{WorkerThreads: 2, LeaderElectionID: "agenticai-envtest"},
+		LeaderElection:          true,
+		LeaderElectionNamespace: metav1.NamespaceDefault,
+		MetricsAddr:             "0",
+	})
+	require.NoError(t, err)
+	stopped := make(chan error, 1)
+	go func() { stopped <- mgr.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-stopped)
+	})
+	select {
+	case <-mgr.Elected():
+	case <-time.After(30 * time.Second):
+		t.Fatal("manager was not elected leader")
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: mgr.GetScheme()})
+	require.NoError(t, err)
+	agent := &apis.Agent{
+		ObjectMeta: metav1.ObjectMeta{Name: "coder", Namespace: metav1.NamespaceDefault},
+		Spec:       apis.AgentSpec{ImageRef: "agenticai/agent-runtime:dev", Replicas: 1},
+	}
+	require.NoError(t, c.Create(ctx, agent))
+
+	// AgentReconciler 生成带 ownerRef 的 Deployment
+	var deploy appsv1.Deployment
+	require.Eventually(t, func() bool {
+		return c.Get(ctx, types.NamespacedName{Namespace: agent.Namespace, Name: agent.Name}, &deploy) == nil
+	}, 30*time.Second, 200*time.Millisecond)
+	require.Len(t, deploy.OwnerReferences, 1)
+	assert.Equal(t, "Agent", deploy.OwnerReferences[0].Kind)
+
+	require.Eventually(t, func() bool {
+		var got apis.Agent
+		return c.Get(ctx, client.ObjectKeyFromObject(agent), &got) == nil && got.Status.DesiredReplicas == 1
+	}, 30*time.Second, 200*time.Millisecond)
+}
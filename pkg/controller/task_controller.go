@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"go.uber.org/zap"
 	"reflect"
+	"strings"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
@@ -14,19 +15,30 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"k8s.io/utils/pointer"
 
 	agenticaiov1 "github.com/turtacn/agenticai/pkg/apis/agenticai.io/v1"
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
 	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/tools"
 )
 
 // TaskReconciler reconciles a Task object
 type TaskReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Tools 用于把 Spec.Tools 解析为具体版本；为 nil 时不做解析
+	Tools tools.Registry
+	// Policies 为 nil 时不执行 SecurityPolicy 约束
+	Policies *PolicyEnforcer
 }
 
 //+kubebuilder:rbac:groups=agenticai.io,resources=tasks,verbs=get;list;watch;create;update;patch;delete
@@ -67,11 +79,62 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
-	createdJob, err := r.ensureJob(ctx, &task)
+	// 工具版本在首次调度时钉死
+	ready, err = r.resolveTools(ctx, &task)
+	if err != nil {
+		if errors.KindOf(err) == errors.KindValidation {
+			r.updateStatus(ctx, &task, agenticaiov1.TaskFailed, fmt.Sprintf("invalid tool reference: %v", err), 0)
+			return ctrl.Result{}, nil
+		}
+		log.Errorf("resolve tools error: %v", err)
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+	if !ready {
+		log.Infof("tools not registered yet, backoff 10s")
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	// 按匹配的 SecurityPolicy 改写 Job 模板；违规时不创建 Job
+	oldStatus := task.Status.DeepCopy()
+	job := r.buildJob(&task)
+	compliant := true
+	if r.Policies != nil {
+		matched, violations, err := r.Policies.Enforce(ctx, "Task", &task, &job.Spec.Template)
+		if err != nil {
+			log.Errorf("evaluate security policies error: %v", err)
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+		compliant = len(violations) == 0
+		if compliant {
+			msg := "no security policy matches"
+			if len(matched) > 0 {
+				msg = "applied " + security.PolicyNames(matched)
+			}
+			setTaskCondition(&task.Status, agenticaiov1.TaskConditionPolicyCompliant, metav1.ConditionTrue, "PolicyApplied", msg)
+		} else {
+			setTaskCondition(&task.Status, agenticaiov1.TaskConditionPolicyCompliant, metav1.ConditionFalse, "PolicyViolation",
+				strings.Join(violations, "; "))
+		}
+	}
+
+	// 已创建的 Job 模板不可变，违规只阻止新建
+	createdJob, err := r.ensureJob(ctx, job, compliant)
 	if err != nil {
 		log.Errorf("ensure job error: %v", err)
 		return ctrl.Result{RequeueAfter: 20 * time.Second}, nil
 	}
+	if createdJob == nil {
+		log.Infof("job blocked by security policy")
+		task.Status.Phase = agenticaiov1.TaskPending
+		task.Status.Message = "blocked by security policy"
+		if !reflect.DeepEqual(oldStatus, &task.Status) {
+			if err := r.Status().Update(ctx, &task); err != nil {
+				return ctrl.Result{Requeue: true, RequeueAfter: 2 * time.Second}, nil
+			}
+		}
+		// 策略或 Task 变更时经 watch 重新对齐
+		return ctrl.Result{}, nil
+	}
 
 	// 读取 Job 的 Pod 状态来更新
 	podResult, err := r.loadRunningPodStatus(ctx, createdJob)
@@ -81,7 +144,6 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	}
 
 	// 更新 Task.Status
-	oldStatus := task.Status.DeepCopy()
 	task.Status.Phase = podResult.Phase
 	task.Status.Message = podResult.Message
 	task.Status.Progress = podResult.Progress
@@ -96,14 +158,23 @@ func (r *TaskReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 }
 
-// ensureJob：根据 Task.Spec 创建 Batch Job
-func (r *TaskReconciler) ensureJob(ctx context.Context, task *agenticaiov1.Task) (*batchv1.Job, error) {
+// buildJob：根据 Task.Spec 生成 Batch Job
+func (r *TaskReconciler) buildJob(task *agenticaiov1.Task) *batchv1.Job {
 	jobName := fmt.Sprintf("%s-job", task.Name)
 	ns := task.Namespace
 	if ns == "" {
 		ns = metav1.NamespaceDefault
 	}
 
+	env := task.Spec.Env
+	if len(task.Status.ResolvedTools) > 0 {
+		refs := make([]string, len(task.Status.ResolvedTools))
+		for i, t := range task.Status.ResolvedTools {
+			refs[i] = tools.PinRef(t.ID, t.Name, t.Digest)
+		}
+		env = append(append([]corev1.EnvVar{}, env...), corev1.EnvVar{Name: constants.EnvPinnedTools, Value: strings.Join(refs, ",")})
+	}
+
 	jobSpec := batchv1.JobSpec{
 		Template: corev1.PodTemplateSpec{
 			ObjectMeta: metav1.ObjectMeta{
@@ -118,7 +189,7 @@ func (r *TaskReconciler) ensureJob(ctx context.Context, task *agenticaiov1.Task)
 						Command:   task.Spec.Command,
 						Args:      task.Spec.Args,
 						Resources: task.Spec.Resources,
-						Env:       task.Spec.Env,
+						Env:       env,
 					},
 				},
 			},
@@ -139,12 +210,18 @@ func (r *TaskReconciler) ensureJob(ctx context.Context, task *agenticaiov1.Task)
 
 	// ownerRef 确保任务删除时 Job 级联
 	_ = controllerutil.SetControllerReference(task, job, r.Scheme)
+	return job
+}
 
+// ensureJob：Job 已存在时原样返回，否则在 create 为 true 时创建；不创建时返回 nil
+func (r *TaskReconciler) ensureJob(ctx context.Context, job *batchv1.Job, create bool) (*batchv1.Job, error) {
 	found := &batchv1.Job{}
-	err := r.Get(ctx, types.NamespacedName{Name: jobName, Namespace: ns}, found)
+	err := r.Get(ctx, client.ObjectKeyFromObject(job), found)
 	switch {
 	case err == nil:
 		return found, nil
+	case apierrs.IsNotFound(err) && !create:
+		return nil, nil
 	case apierrs.IsNotFound(err):
 		if err := r.Create(ctx, job); err != nil {
 			return nil, err
@@ -169,6 +246,49 @@ func (r *TaskReconciler) checkDependencies(ctx context.Context, task *agenticaio
 	return true, nil
 }
 
+// resolveTools：把 Spec.Tools 中的引用解析为具体版本并写入 Status.ResolvedTools；
+// 已钉住的引用与 Spec.Tools 逐项一致时不再重新解析，工具未注册时返回 false 等待重试
+func (r *TaskReconciler) resolveTools(ctx context.Context, task *agenticaiov1.Task) (bool, error) {
+	if r.Tools == nil || pinnedFor(task.Status.ResolvedTools, task.Spec.Tools) {
+		return true, nil
+	}
+	resolved := make([]agenticaiov1.ResolvedTool, 0, len(task.Spec.Tools))
+	for _, ref := range task.Spec.Tools {
+		spec, err := r.Tools.Resolve(ctx, ref)
+		if err != nil {
+			if errors.KindOf(err) == errors.KindNotFound {
+				return false, nil
+			}
+			return false, err
+		}
+		resolved = append(resolved, agenticaiov1.ResolvedTool{
+			Ref:     ref,
+			ID:      spec.ID,
+			Name:    spec.Name,
+			Version: spec.Version,
+			Digest:  spec.Digest,
+		})
+	}
+	task.Status.ResolvedTools = resolved
+	if err := r.Status().Update(ctx, task); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// pinnedFor：resolved 是否恰好对应 refs（顺序一致）
+func pinnedFor(resolved []agenticaiov1.ResolvedTool, refs []string) bool {
+	if len(resolved) != len(refs) {
+		return false
+	}
+	for i, ref := range refs {
+		if resolved[i].Ref != ref {
+			return false
+		}
+	}
+	return true
+}
+
 type podStatusResult struct {
 	Phase    agenticaiov1.TaskPhase
 	Message  string
@@ -238,11 +358,33 @@ func (r *TaskReconciler) updateStatus(ctx context.Context, task *agenticaiov1.Ta
 	_ = r.Status().Update(ctx, task)
 }
 
+// setTaskCondition 只在状态变化时更新 LastTransitionTime
+func setTaskCondition(st *agenticaiov1.TaskStatus, condType string, status metav1.ConditionStatus, reason, msg string) {
+	for i := range st.Conditions {
+		c := &st.Conditions[i]
+		if c.Type != condType {
+			continue
+		}
+		if c.Status != status {
+			c.LastTransitionTime = metav1.Now()
+		}
+		c.Status, c.Reason, c.Message = status, reason, msg
+		return
+	}
+	st.Conditions = append(st.Conditions, agenticaiov1.TaskCondition{
+		Type: condType, Status: status, Reason: reason, Message: msg, LastTransitionTime: metav1.Now(),
+	})
+}
+
 // SetupWithManager attach watch
 func (r *TaskReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&agenticaiov1.Task{}).
-		Owns(&batchv1.Job{}).
-		Complete(r)
+		Owns(&batchv1.Job{})
+	if r.Policies != nil {
+		b = b.Watches(&apis.SecurityPolicy{}, enqueueAll(mgr.GetClient(), &agenticaiov1.TaskList{}),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	}
+	return b.Complete(r)
 }
 //Personal.AI order the ending
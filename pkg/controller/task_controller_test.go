@@ -4,12 +4,17 @@ import (
 	"context"
 	"testing"
 
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
 	agenticaiov1 "github.com/turtacn/agenticai/pkg/apis/agenticai.io/v1"
+	"github.com/turtacn/agenticai/pkg/tools"
 	"github.com/stretchr/testify/assert"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -143,3 +148,70 @@ func TestLoadRunningPodStatus(t *testing.T) {
 	assert.NotNil(t, result.Result)
 	assert.Equal(t, int32(1), result.Result.ExitCode)
 }
+
+func TestResolveTools(t *testing.T) {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	agenticaiov1.AddToScheme(s)
+	ctx := context.Background()
+
+	reg := tools.NewInMemRegistry()
+	assert.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "search", Version: "1.2.0"}))
+	assert.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "search", Version: "1.3.0"}))
+
+	task := &agenticaiov1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "task1", Namespace: "default"},
+		Spec:       agenticaiov1.TaskSpec{ImageRef: "busybox", Tools: []string{"search@^1.2", "fetch"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(task).WithStatusSubresource(task).Build()
+	reconciler := &TaskReconciler{Client: c, Scheme: s, Tools: reg}
+
+	// Test case 1: fetch not registered yet
+	ready, err := reconciler.resolveTools(ctx, task)
+	assert.NoError(t, err)
+	assert.False(t, ready)
+	assert.Empty(t, task.Status.ResolvedTools)
+
+	// Test case 2: all resolved and pinned in status
+	assert.NoError(t, reg.Register(ctx, &apis.ToolSpec{ID: "fetch", Name: "fetch"}))
+	ready, err = reconciler.resolveTools(ctx, task)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	var stored agenticaiov1.Task
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "task1", Namespace: "default"}, &stored))
+	assert.Len(t, stored.Status.ResolvedTools, 2)
+	assert.Equal(t, "1.3.0", stored.Status.ResolvedTools[0].Version)
+	assert.Equal(t, "search@^1.2", stored.Status.ResolvedTools[0].Ref)
+	assert.NotEmpty(t, stored.Status.ResolvedTools[0].Digest)
+
+	// Test case 3: a newer version does not change a pinned task
+	assert.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "search", Version: "1.4.0"}))
+	ready, err = reconciler.resolveTools(ctx, &stored)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	assert.Equal(t, "1.3.0", stored.Status.ResolvedTools[0].Version)
+
+	// Test case 3b: an edited reference with the same count is re-resolved
+	stored.Spec.Tools = []string{"search@~1.2", "fetch"}
+	assert.NoError(t, c.Update(ctx, &stored))
+	ready, err = reconciler.resolveTools(ctx, &stored)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	assert.Equal(t, "search@~1.2", stored.Status.ResolvedTools[0].Ref)
+	assert.Equal(t, "1.2.0", stored.Status.ResolvedTools[0].Version)
+
+	job, err := reconciler.ensureJob(ctx, reconciler.buildJob(&stored), true)
+	assert.NoError(t, err)
+	env := job.Spec.Template.Spec.Containers[0].Env
+	assert.Len(t, env, 1)
+	assert.Equal(t, constants.EnvPinnedTools, env[0].Name)
+	assert.Contains(t, env[0].Value, "search@sha256:")
+
+	// Test case 4: malformed reference
+	bad := &agenticaiov1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "task2", Namespace: "default"},
+		Spec:       agenticaiov1.TaskSpec{ImageRef: "busybox", Tools: []string{"search@"}},
+	}
+	_, err = reconciler.resolveTools(ctx, bad)
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+}
@@ -0,0 +1,201 @@
+// pkg/controller/tool_controller.go
+package controller
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"go.uber.org/zap"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+const (
+	// LabelOpenAPISource 展开出的 Tool 对象指向其导入源（同命名空间的 Tool 对象名）
+	LabelOpenAPISource = "agenticai.io/openapi-source"
+	// DefaultToolResync 重新拉取 OpenAPI 文档的默认间隔
+	DefaultToolResync = 10 * time.Minute
+	// toolFetchRetry 文档拉取失败后的重试间隔
+	toolFetchRetry = 30 * time.Second
+)
+
+// ToolReconciler 把绑定了 OpenAPI 文档（SpecURL 非空且未指定 Operation）的 Tool 展开为
+// 每个 operation 一个子 Tool；子对象带 ownerRef，导入源删除时被级联回收
+type ToolReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// HTTPClient 拉取文档使用，为 nil 时用 http.DefaultClient
+	HTTPClient *http.Client
+	// ResyncInterval 文档未变更时的重新拉取间隔，<=0 时取 DefaultToolResync
+	ResyncInterval time.Duration
+
+	now func() time.Time
+}
+
+//+kubebuilder:rbac:groups=agenticai.io,resources=tools,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=agenticai.io,resources=tools/status,verbs=get;update;patch
+
+func (r *ToolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logger.WithCtx(ctx).With(zap.String("tool", req.NamespacedName.String()))
+
+	var tool apis.Tool
+	if err := r.Get(ctx, req.NamespacedName, &tool); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !isOpenAPISource(&tool) {
+		return ctrl.Result{}, nil
+	}
+
+	children, err := r.children(ctx, &tool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// 规格未变、子对象齐全且未到重拉时间时不访问上游
+	if wait := r.untilResync(&tool, len(children)); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	status := tool.Status.DeepCopy()
+	status.ObservedGeneration = tool.Generation
+	now := metav1.NewTime(r.clock())
+	status.LastSyncTime = &now
+	requeue := r.resync()
+
+	imp, err := tools.ImportOpenAPI(ctx, r.HTTPClient, &tool.Spec)
+	switch {
+	case err != nil:
+		// 拉取失败保留已有子对象与摘要
+		log.Warn("fetch openapi spec failed", zap.Error(err))
+		status.Message = err.Error()
+		requeue = toolFetchRetry
+	case len(imp.ValidationErrors) > 0:
+		log.Warn("openapi spec invalid, keeping previous operations", zap.Strings("errors", imp.ValidationErrors))
+		status.SpecDigest = imp.SpecDigest
+		status.ValidationErrors = imp.ValidationErrors
+		status.Message = ""
+	default:
+		if err := r.syncChildren(ctx, &tool, imp.Tools, children); err != nil {
+			return ctrl.Result{}, err
+		}
+		status.SpecDigest = imp.SpecDigest
+		status.Operations = int32(len(imp.Tools))
+		status.ValidationErrors = nil
+		status.Message = ""
+	}
+
+	if !reflect.DeepEqual(*status, tool.Status) {
+		tool.Status = *status
+		if err := r.Status().Update(ctx, &tool); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
+}
+
+// isOpenAPISource 子对象钉死了 Operation，不会被再次展开
+func isOpenAPISource(tool *apis.Tool) bool {
+	b := tool.Spec.OpenAPI
+	return b != nil && b.SpecURL != "" && b.Operation == "" && tool.Labels[LabelOpenAPISource] == ""
+}
+
+func (r *ToolReconciler) children(ctx context.Context, tool *apis.Tool) ([]apis.Tool, error) {
+	var list apis.ToolList
+	if err := r.List(ctx, &list, client.InNamespace(tool.Namespace),
+		client.MatchingLabels{LabelOpenAPISource: tool.Name}); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// untilResync 返回距下次拉取的剩余时间，<=0 表示现在就该拉取
+func (r *ToolReconciler) untilResync(tool *apis.Tool, children int) time.Duration {
+	st := tool.Status
+	if st.LastSyncTime == nil || st.ObservedGeneration != tool.Generation || st.Message != "" ||
+		(len(st.ValidationErrors) == 0 && int(st.Operations) != children) {
+		return 0
+	}
+	return st.LastSyncTime.Add(r.resync()).Sub(r.clock())
+}
+
+// syncChildren 按对象名对齐期望集合：新建、更新变化的、删除文档中已不存在的 operation
+func (r *ToolReconciler) syncChildren(ctx context.Context, src *apis.Tool, want []*apis.ToolSpec, have []apis.Tool) error {
+	existing := make(map[string]*apis.Tool, len(have))
+	for i := range have {
+		existing[have[i].Name] = &have[i]
+	}
+	for _, spec := range want {
+		name := tools.ToolObjectName(spec.ID)
+		cur, ok := existing[name]
+		delete(existing, name)
+		if ok {
+			// 按内容摘要比较：经 API Server 往返后 schema 中的数字类型会变
+			if cur.Spec.Digest == spec.Digest && tools.ComputeDigest(&cur.Spec) == spec.Digest {
+				continue
+			}
+			cur.Spec = *spec
+			if err := r.Update(ctx, cur); err != nil {
+				return err
+			}
+			continue
+		}
+		child := &apis.Tool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: src.Namespace,
+				Labels:    map[string]string{LabelOpenAPISource: src.Name},
+			},
+			Spec: *spec,
+		}
+		if err := controllerutil.SetControllerReference(src, child, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, child); err != nil && !apierrs.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	for _, stale := range existing {
+		if err := r.Delete(ctx, stale); err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ToolReconciler) resync() time.Duration {
+	if r.ResyncInterval > 0 {
+		return r.ResyncInterval
+	}
+	return DefaultToolResync
+}
+
+func (r *ToolReconciler) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// SetupWithManager 导入源只响应 spec 变化；子对象的增删改回到导入源上对齐
+func (r *ToolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apis.Tool{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&apis.Tool{}).
+		Complete(r)
+}
+
+//Personal.AI order the ending
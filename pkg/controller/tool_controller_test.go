@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+const petsV1 = `
+openapi: 3.0.3
+info: {title: pets, version: 1.0.0}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses: {"200": {description: ok}}
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object, properties: {name: {type: string}}}
+      responses: {"201": {description: created}}
+`
+
+// petsV2 删掉 createPet，新增无 operationId 的 GET /pets/{id}
+const petsV2 = `
+openapi: 3.0.3
+info: {title: pets, version: 2.0.0}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: list all pets
+      responses: {"200": {description: ok}}
+  /pets/{id}:
+    get:
+      parameters: [{name: id, in: path, required: true, schema: {type: integer}}]
+      responses: {"200": {description: ok}}
+`
+
+// specServer 可替换返回内容并统计拉取次数
+type specServer struct {
+	mu      sync.Mutex
+	body    string
+	status  int
+	fetches int
+}
+
+func (s *specServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches++
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	_, _ = w.Write([]byte(s.body))
+}
+
+func (s *specServer) set(body string, status int) {
+	s.mu.Lock()
+	s.body, s.status = body, status
+	s.mu.Unlock()
+}
+
+func TestToolReconcilerImportsOpenAPI(t *testing.T) {
+	ctx := context.Background()
+	specs := &specServer{body: petsV1}
+	ts := httptest.NewServer(specs)
+	defer ts.Close()
+
+	s := runtime.NewScheme()
+	require.NoError(t, apis.AddToScheme(s))
+	src := &apis.Tool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pets", Namespace: "tools", Generation: 1, UID: "uid-pets"},
+		Spec: apis.ToolSpec{
+			Name: "pets", Tags: map[string]string{"team": "zoo"},
+			OpenAPI: &apis.OpenAPIBinding{SpecURL: ts.URL + "/openapi.yaml", BaseURL: "https://pets.example.com"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(src).WithStatusSubresource(&apis.Tool{}).Build()
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := &ToolReconciler{Client: c, Scheme: s, ResyncInterval: time.Hour, now: func() time.Time { return clock }}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "tools", Name: "pets"}}
+
+	children := func() map[string]apis.Tool {
+		var list apis.ToolList
+		require.NoError(t, c.List(ctx, &list, client.InNamespace("tools"), client.MatchingLabels{LabelOpenAPISource: "pets"}))
+		out := make(map[string]apis.Tool)
+		for _, item := range list.Items {
+			out[item.Spec.Name] = item
+		}
+		return out
+	}
+	names := func(m map[string]apis.Tool) []string {
+		var out []string
+		for k := range m {
+			out = append(out, k)
+		}
+		sort.Strings(out)
+		return out
+	}
+	status := func() apis.ToolStatus {
+		var got apis.Tool
+		require.NoError(t, c.Get(ctx, req.NamespacedName, &got))
+		return got.Status
+	}
+
+	res, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, res.RequeueAfter)
+	got := children()
+	assert.Equal(t, []string{"pets_createPet", "pets_listPets"}, names(got))
+	list := got["pets_listPets"]
+	assert.Equal(t, "GET /pets", list.Spec.OpenAPI.Operation)
+	assert.Equal(t, "https://pets.example.com", list.Spec.OpenAPI.BaseURL)
+	assert.Equal(t, "1.0.0", list.Spec.Version)
+	assert.Equal(t, "pets_listPets@1.0.0", list.Spec.ID)
+	assert.Equal(t, "zoo", list.Spec.Tags["team"])
+	assert.NotEmpty(t, list.Spec.Digest)
+	require.Len(t, list.OwnerReferences, 1)
+	assert.Equal(t, "pets", list.OwnerReferences[0].Name)
+	st := status()
+	assert.Equal(t, int32(2), st.Operations)
+	assert.True(t, strings.HasPrefix(st.SpecDigest, "sha256:"))
+	assert.Equal(t, int64(1), st.ObservedGeneration)
+	assert.Empty(t, st.ValidationErrors)
+
+	// 未到重拉时间不访问上游
+	clock = clock.Add(10 * time.Minute)
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 50*time.Minute, res.RequeueAfter)
+	assert.Equal(t, 1, specs.fetches)
+
+	// 子对象被误删时立即补齐
+	victim := got["pets_createPet"]
+	require.NoError(t, c.Delete(ctx, &victim))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Len(t, children(), 2)
+	assert.Equal(t, 2, specs.fetches)
+
+	// 文档变化在重拉时生效，消失的 operation 被删除
+	specs.set(petsV2, 0)
+	clock = clock.Add(2 * time.Hour)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	got = children()
+	assert.Equal(t, []string{"pets_get_pets_id", "pets_listPets"}, names(got))
+	assert.Equal(t, "list all pets", got["pets_listPets"].Spec.DisplayName)
+	assert.Equal(t, "2.0.0", got["pets_listPets"].Spec.Version)
+	digestV2 := status().SpecDigest
+	assert.NotEqual(t, st.SpecDigest, digestV2)
+
+	// 不合法的文档保留上一次的子对象
+	specs.set("openapi: 3.0.3\ninfo: {title: broken}\npaths: {}\n", 0)
+	clock = clock.Add(2 * time.Hour)
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	st = status()
+	assert.NotEmpty(t, st.ValidationErrors)
+	assert.Equal(t, int32(2), st.Operations)
+	assert.Len(t, children(), 2)
+
+	// 拉取失败短间隔重试
+	specs.set("", http.StatusBadGateway)
+	clock = clock.Add(2 * time.Hour)
+	res, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, toolFetchRetry, res.RequeueAfter)
+	assert.Contains(t, status().Message, "502")
+	assert.Len(t, children(), 2)
+}
+
+func TestToolReconcilerSkipsNonSources(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, apis.AddToScheme(s))
+	single := &apis.Tool{
+		ObjectMeta: metav1.ObjectMeta{Name: "single", Namespace: "tools"},
+		Spec: apis.ToolSpec{Name: "single", OpenAPI: &apis.OpenAPIBinding{
+			SpecURL: "http://127.0.0.1:1/openapi.yaml", Operation: "GET /pets",
+		}},
+	}
+	custom := &apis.Tool{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: "tools"},
+		Spec:       apis.ToolSpec{Name: "custom", CustomExec: &apis.CustomBinding{Image: "x"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(single, custom).WithStatusSubresource(&apis.Tool{}).Build()
+	r := &ToolReconciler{Client: c, Scheme: s}
+	for _, name := range []string{"single", "custom", "missing"} {
+		res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "tools", Name: name}})
+		require.NoError(t, err)
+		assert.Zero(t, res.RequeueAfter)
+	}
+}
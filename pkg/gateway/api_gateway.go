@@ -15,68 +15,112 @@ import (
 
 	"github.com/turtacn/agenticai/internal/config"
 	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+// TraceHeader 响应中回传 trace id 的头
+const TraceHeader = "X-Trace-Id"
+
+// 各路由每个调用方每秒的默认请求数，突发量为其两倍
+const (
+	defaultReadRate   = 20
+	defaultInvokeRate = 5
 )
 
 type Gateway struct {
 	*http.Server
-	ctx       context.Context
-	mu        sync.RWMutex
-	rateLimit *rate.Limiter
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // 进行中的异步调用
+
+	reg         tools.Registry
+	inv         tools.Invoker
+	mc          *middlewareChain
+	invocations *invocationStore
+	readRate    rate.Limit
+	invokeRate  rate.Limit
 }
 
-func New(cfg *config.GatewayConfig) *Gateway {
-	r := gin.New()
-	r.Use(otelgin.Middleware("gateway"), gin.Recovery())
+// Option 网关可选项
+type Option func(*Gateway)
+
+// WithAuthenticator 替换调用方认证，默认取 mTLS 对端的 SPIFFE ID
+func WithAuthenticator(a Authenticator) Option {
+	return func(g *Gateway) { g.mc.authn = a }
+}
+
+// WithAuthorizer 按 RBAC 授权，未设置时不做授权
+func WithAuthorizer(r security.RBAC) Option {
+	return func(g *Gateway) { g.mc.rbac = r }
+}
+
+// WithRateLimits 每个调用方在查询类与调用类路由上的每秒请求数
+func WithRateLimits(read, invoke rate.Limit) Option {
+	return func(g *Gateway) { g.readRate, g.invokeRate = read, invoke }
+}
+
+func New(cfg *config.GatewayConfig, reg tools.Registry, inv tools.Invoker, opts ...Option) *Gateway {
+	ctx, cancel := context.WithCancel(context.Background())
 	g := &Gateway{
-		ctx:       context.Background(),
-		rateLimit: rate.NewLimiter(rate.Every(time.Second), 100),
+		ctx:         ctx,
+		cancel:      cancel,
+		reg:         reg,
+		inv:         inv,
+		mc:          &middlewareChain{authn: SPIFFEAuthenticator()},
+		invocations: newInvocationStore(),
+		readRate:    defaultReadRate,
+		invokeRate:  defaultInvokeRate,
 		Server: &http.Server{
-			Addr:    cfg.Listen,
-			Handler: r,
+			Addr:              cfg.Listen,
+			ReadHeaderTimeout: 10 * time.Second,
 		},
 	}
+	for _, o := range opts {
+		o(g)
+	}
+	r := gin.New()
+	r.Use(otelgin.Middleware("gateway"), g.mc.Recover(), g.mc.TraceID(TraceHeader), g.mc.Logging())
 	g.setupRoutes(r)
+	g.Handler = r
 	return g
 }
 
 func (g *Gateway) setupRoutes(r *gin.Engine) {
 	r.GET("/healthz", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
-	// api := r.Group("/api/v1", g.rateMiddleware())
-	// api.POST("/agents/deploy", g.handleDeployAgent())
-	// api.GET("/tasks/status/:id", g.handleTaskStatus())
-}
 
-func (g *Gateway) rateMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if !g.rateLimit.Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limited"})
-			return
-		}
-		c.Next()
-	}
+	// 每条路由各自一个限流器；:tool 由 resolveTool 换成解析出的工具名再授权
+	api := r.Group("/api/v1", g.mc.Auth())
+	api.GET("/tools", g.rate(g.readRate), g.mc.Authorize("list", "tools"), g.handleListTools())
+	api.GET("/tools/:tool", g.rate(g.readRate), g.resolveTool(),
+		g.mc.Authorize("get", "tools/:tool"), g.handleDescribeTool())
+	api.POST("/tools/:tool/invocations", g.rate(g.invokeRate), g.resolveTool(),
+		g.mc.Authorize(tools.ToolVerbInvoke, "tools/:tool"), g.handleInvoke())
+	api.GET("/invocations/:id", g.rate(g.readRate), g.handleInvocationStatus())
 }
 
-/*
-func (g *Gateway) handleTaskStatus() gin.HandlerFunc {
-	client := NewAgentClient() // 内部实现
-	return func(c *gin.Context) {
-		id := c.Param("id")
-		res, err := client.GetTaskStatus(c.Request.Context(), id)
-		if err != nil {
-			_ = c.Error(err)
-			return
-		}
-		c.JSON(http.StatusOK, res)
+func (g *Gateway) rate(limit rate.Limit) gin.HandlerFunc {
+	burst := 1
+	if limit != rate.Inf {
+		burst = max(1, int(2*limit))
 	}
+	return rateMiddleware(limit, burst)
 }
-*/
 
 func (g *Gateway) Start() error {
 	logger.Info(g.ctx, "gateway starting", zap.String("addr", g.Addr))
 	return g.ListenAndServe()
 }
+
+// Stop 停止接收请求，并取消仍在执行的异步调用
 func (g *Gateway) Stop() error {
-	return g.Shutdown(g.ctx)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	err := g.Shutdown(ctx)
+	g.cancel()
+	g.wg.Wait()
+	return err
 }
+
 //Personal.AI order the ending
@@ -0,0 +1,275 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/tools"
+	"github.com/turtacn/agenticai/pkg/types"
+)
+
+func init() { gin.SetMode(gin.TestMode) }
+
+// fakeInvoker 回显参数；参数 "block" 为 true 时等到 release 关闭
+type fakeInvoker struct {
+	mu      sync.Mutex
+	calls   []string // caller ref key
+	release chan struct{}
+	err     error
+}
+
+func (f *fakeInvoker) Invoke(ctx context.Context, ref string, args map[string]interface{}) (*apis.ToolResult, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, security.CallerFromContext(ctx)+" "+ref+" "+tools.IdempotencyKeyFromContext(ctx))
+	f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	if args["block"] == true {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return nil, errors.E(errors.KindCancelled, "cancelled", ctx.Err())
+		}
+	}
+	if args["fail"] == true {
+		return &apis.ToolResult{Error: "tool failed"}, nil
+	}
+	out, _ := json.Marshal(args)
+	return &apis.ToolResult{Output: string(out)}, nil
+}
+
+func (f *fakeInvoker) InvokeStream(ctx context.Context, ref string, args map[string]interface{}, _ tools.ToolEventHandler) (*apis.ToolResult, error) {
+	return f.Invoke(ctx, ref, args)
+}
+
+func (f *fakeInvoker) Close() error { return nil }
+
+// headerAuth 以 X-User 作为调用方
+var headerAuth = AuthenticatorFunc(func(r *http.Request) (*types.AuthResult, error) {
+	if u := r.Header.Get("X-User"); u != "" {
+		return &types.AuthResult{Subject: u}, nil
+	}
+	return nil, errors.E(errors.KindPermission, "no user")
+})
+
+func newTestGateway(t *testing.T, opts ...Option) (*Gateway, *fakeInvoker, *apis.ToolSpec) {
+	reg := tools.NewInMemRegistry()
+	require.NoError(t, reg.Register(context.Background(), &apis.ToolSpec{
+		Name: "search", Version: "1.2.0", Description: "web search", Category: "web",
+		ArgsSchema: apis.AnyMap{"type": "object"},
+		MCP:        &apis.MCPBinding{ServerURL: "http://mcp.internal", Headers: map[string]string{"Authorization": "Bearer secret"}},
+	}))
+	spec, err := reg.Resolve(context.Background(), "search")
+	require.NoError(t, err)
+	inv := &fakeInvoker{release: make(chan struct{})}
+	g := New(&config.GatewayConfig{}, reg, inv, append([]Option{WithAuthenticator(headerAuth)}, opts...)...)
+	t.Cleanup(func() { _ = g.Stop() })
+	return g, inv, spec
+}
+
+func do(g *Gateway, method, path, user string, body interface{}, hdr ...string) *httptest.ResponseRecorder {
+	var rd bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&rd).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &rd)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	for i := 0; i+1 < len(hdr); i += 2 {
+		req.Header.Set(hdr[i], hdr[i+1])
+	}
+	rec := httptest.NewRecorder()
+	g.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	var out T
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out), rec.Body.String())
+	return out
+}
+
+func TestGatewayTools(t *testing.T) {
+	g, inv, spec := newTestGateway(t)
+
+	assert.Equal(t, http.StatusUnauthorized, do(g, "GET", "/api/v1/tools", "", nil).Code)
+
+	rec := do(g, "GET", "/api/v1/tools?category=web", "alice", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	list := decode[struct{ Items []apis.Metadata }](t, rec)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "search", list.Items[0].Name)
+	assert.Empty(t, decode[struct{ Items []apis.Metadata }](t, do(g, "GET", "/api/v1/tools?category=db", "alice", nil)).Items)
+
+	// 描述包含参数 schema，不暴露绑定中的凭据
+	rec = do(g, "GET", "/api/v1/tools/search@^1.0", "alice", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "secret")
+	view := decode[toolView](t, rec)
+	assert.Equal(t, "mcp", view.Binding)
+	assert.Equal(t, "object", view.ArgsSchema["type"])
+	assert.Equal(t, http.StatusNotFound, do(g, "GET", "/api/v1/tools/missing", "alice", nil).Code)
+
+	// 同步调用按 name@digest 钉死版本，携带调用方与幂等键
+	rec = do(g, "POST", "/api/v1/tools/search/invocations", "alice",
+		map[string]interface{}{"arguments": map[string]interface{}{"q": "go"}}, HeaderIdempotencyKey, "k1")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	got := decode[Invocation](t, rec)
+	assert.Equal(t, InvocationSucceeded, got.State)
+	assert.JSONEq(t, `{"q":"go"}`, got.Result.Output)
+	assert.Equal(t, []string{"alice search@" + spec.Digest + " k1"}, inv.calls)
+
+	assert.Equal(t, http.StatusOK, do(g, "GET", rec.Header().Get("Location"), "alice", nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(g, "GET", rec.Header().Get("Location"), "bob", nil).Code)
+
+	// 工具自身报错仍是一次完成的调用
+	rec = do(g, "POST", "/api/v1/tools/search/invocations", "alice",
+		map[string]interface{}{"arguments": map[string]interface{}{"fail": true}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	got = decode[Invocation](t, rec)
+	assert.Equal(t, InvocationFailed, got.State)
+	assert.Equal(t, "tool failed", got.Error)
+}
+
+func TestGatewayAsyncInvocation(t *testing.T) {
+	g, inv, _ := newTestGateway(t)
+
+	rec := do(g, "POST", "/api/v1/tools/search/invocations", "alice",
+		map[string]interface{}{"arguments": map[string]interface{}{"block": true}}, "Prefer", "respond-async")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	loc := rec.Header().Get("Location")
+	assert.Equal(t, InvocationRunning, decode[Invocation](t, rec).State)
+	assert.Equal(t, InvocationRunning, decode[Invocation](t, do(g, "GET", loc, "alice", nil)).State)
+
+	close(inv.release)
+	require.Eventually(t, func() bool {
+		return decode[Invocation](t, do(g, "GET", loc, "alice", nil)).State == InvocationSucceeded
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+// captureAudit 让 AuditLog 同步写入内存，返回读取已记录事件的函数
+func captureAudit(t *testing.T) func() []types.AuditEvent {
+	var buf bytes.Buffer
+	a, err := security.NewAuditor(security.NewWriterSink(&buf))
+	require.NoError(t, err)
+	security.SetAuditor(a)
+	t.Cleanup(func() { security.SetAuditor(nil) })
+	return func() []types.AuditEvent {
+		var out []types.AuditEvent
+		dec := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+		for dec.More() {
+			var rec security.AuditRecord
+			require.NoError(t, dec.Decode(&rec))
+			var ev types.AuditEvent
+			require.NoError(t, json.Unmarshal(rec.Event, &ev))
+			out = append(out, ev)
+		}
+		return out
+	}
+}
+
+func TestGatewayAuthorize(t *testing.T) {
+	events := captureAudit(t)
+	rbac := security.NewRBAC()
+	require.NoError(t, rbac.UpdatePolicy(&apis.SecurityPolicy{Spec: apis.PolicySpec{
+		Rules:    []apis.RBACRule{{Role: "searcher", Verbs: []string{tools.ToolVerbInvoke}, Resources: []string{"tools/search"}}},
+		Bindings: []apis.RoleBinding{{Role: "searcher", Subjects: []string{"alice"}}},
+	}}))
+	g, inv, _ := newTestGateway(t, WithAuthorizer(rbac))
+
+	// 按 id 引用时同样以解析出的工具名授权
+	for _, ref := range []string{"search", "search@1.2.0"} {
+		assert.Equal(t, http.StatusOK, do(g, "POST", "/api/v1/tools/"+ref+"/invocations", "alice", nil).Code, ref)
+	}
+	rec := do(g, "POST", "/api/v1/tools/search/invocations", "bob", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, string(errors.KindPermission), decode[map[string]string](t, rec)["kind"])
+	assert.Equal(t, http.StatusForbidden, do(g, "GET", "/api/v1/tools", "alice", nil).Code)
+	assert.Len(t, inv.calls, 2)
+
+	var got []string
+	for _, ev := range events() {
+		got = append(got, ev.Actor+" "+ev.Action+" "+ev.Resource+" "+ev.Outcome)
+	}
+	assert.Equal(t, []string{
+		"alice INVOKE tools/search success", "alice INVOKE tools/search success",
+		"bob INVOKE tools/search denied", "alice LIST tools denied",
+	}, got)
+}
+
+func TestGatewayListFiltersByRBAC(t *testing.T) {
+	rbac := security.NewRBAC()
+	require.NoError(t, rbac.UpdatePolicy(&apis.SecurityPolicy{Spec: apis.PolicySpec{
+		Rules: []apis.RBACRule{
+			{Role: "browser", Verbs: []string{"list"}, Resources: []string{"tools"}},
+			{Role: "browser", Verbs: []string{"get"}, Resources: []string{"tools/search"}},
+		},
+		Bindings: []apis.RoleBinding{{Role: "browser", Subjects: []string{"alice"}}},
+	}}))
+	g, _, _ := newTestGateway(t, WithAuthorizer(rbac))
+	require.NoError(t, g.reg.Register(context.Background(), &apis.ToolSpec{Name: "fetch", Version: "1.0.0"}))
+
+	// 能列出但看不到无权查看的工具
+	rec := do(g, "GET", "/api/v1/tools", "alice", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	list := decode[struct{ Items []apis.Metadata }](t, rec)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "search", list.Items[0].Name)
+	assert.Equal(t, http.StatusForbidden, do(g, "GET", "/api/v1/tools/fetch", "alice", nil).Code)
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	g, inv, _ := newTestGateway(t, WithRateLimits(rate.Inf, 0.5))
+
+	assert.Equal(t, http.StatusOK, do(g, "POST", "/api/v1/tools/search/invocations", "alice", nil).Code)
+	rec := do(g, "POST", "/api/v1/tools/search/invocations", "alice", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	// 按调用方分桶，查询类路由不受调用限流影响
+	assert.Equal(t, http.StatusOK, do(g, "POST", "/api/v1/tools/search/invocations", "bob", nil).Code)
+	assert.Equal(t, http.StatusOK, do(g, "GET", "/api/v1/tools", "alice", nil).Code)
+
+	// 调用器自身的限流同样转成 429 与 Retry-After
+	inv.err = errors.RateLimited(1500*time.Millisecond, "tool quota exceeded")
+	rec = do(g, "POST", "/api/v1/tools/search/invocations", "carol", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+}
+
+func TestSubjectLimitersEvictIdle(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newSubjectLimiters(0.01, 2)
+	s.now = func() time.Time { return now }
+	assert.Equal(t, 200*time.Second, s.idle, "kept until the bucket has refilled")
+
+	a := s.get("alice")
+	s.get("bob")
+	now = now.Add(150 * time.Second)
+	assert.Same(t, a, s.get("alice"))
+	now = now.Add(60 * time.Second)
+	// bob 空闲已超过补满时长，alice 仍在使用
+	s.get("carol")
+	assert.Len(t, s.m, 2)
+	assert.Contains(t, s.m, "alice")
+	assert.NotContains(t, s.m, "bob")
+	assert.Same(t, a, s.get("alice"))
+
+	assert.Equal(t, minLimiterIdle, newSubjectLimiters(rate.Inf, 1).idle)
+}
@@ -0,0 +1,109 @@
+// pkg/gateway/auth.go
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/types"
+)
+
+// gin.Context 中的认证结果与调用方
+const (
+	ctxKeyAuth    = "auth"
+	ctxKeySubject = "subject"
+)
+
+// Authenticator 从请求中识别调用方，任何错误都按 401 处理
+type Authenticator interface {
+	Authenticate(r *http.Request) (*types.AuthResult, error)
+}
+
+// AuthenticatorFunc 函数形式的 Authenticator
+type AuthenticatorFunc func(r *http.Request) (*types.AuthResult, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*types.AuthResult, error) { return f(r) }
+
+// TokenVerifier 校验 bearer token，由 *security.JWTVerifier 实现
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*types.AuthResult, error)
+}
+
+// AuthResultFrom 取 Auth 中间件写入的认证结果，未认证时为 nil
+func AuthResultFrom(c *gin.Context) *types.AuthResult {
+	v, _ := c.Get(ctxKeyAuth)
+	res, _ := v.(*types.AuthResult)
+	return res
+}
+
+// NewAuthenticator 带 Authorization: Bearer 时只按 JWT 认证，失败不再回退；
+// 否则取 mTLS 对端证书中的 SPIFFE ID。tokens 为 nil 时拒绝所有 bearer token
+func NewAuthenticator(tokens TokenVerifier) Authenticator {
+	spiffe := SPIFFEAuthenticator()
+	return AuthenticatorFunc(func(r *http.Request) (*types.AuthResult, error) {
+		if token, ok := bearerToken(r); ok {
+			if tokens == nil {
+				return nil, errors.E(errors.KindPermission, "bearer tokens not accepted")
+			}
+			return tokens.Verify(r.Context(), token)
+		}
+		return spiffe.Authenticate(r)
+	})
+}
+
+// SPIFFEAuthenticator 取 mTLS 对端证书中的 SPIFFE ID
+func SPIFFEAuthenticator() Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*types.AuthResult, error) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			return nil, errors.E(errors.KindPermission, "client certificate required")
+		}
+		id, err := security.PeerID(r.TLS)
+		if err != nil {
+			return nil, errors.Permission(err, "peer SPIFFE ID")
+		}
+		return &types.AuthResult{Subject: id.String()}, nil
+	})
+}
+
+// Anonymous 所有请求都视为 subject，只应在没有 mTLS 的开发环境使用
+func Anonymous(subject string) Authenticator {
+	return AuthenticatorFunc(func(*http.Request) (*types.AuthResult, error) {
+		return &types.AuthResult{Subject: subject}, nil
+	})
+}
+
+// AuthenticateHTTP 供 gin 之外的 handler（如 MCP 端点）使用的认证中间件：
+// 认证失败或 subject 为空时返回 401，否则把调用方写入请求 ctx
+func AuthenticateHTTP(a Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := a.Authenticate(r)
+		if err != nil || res == nil || res.Subject == "" {
+			logger.Debug(r.Context(), "gateway: unauthenticated", zap.Error(err))
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(security.WithCaller(r.Context(), res.Subject)))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if h == "" {
+		return "", false
+	}
+	scheme, token, _ := strings.Cut(h, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,89 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/types"
+)
+
+type stubTokens map[string]*types.AuthResult
+
+func (s stubTokens) Verify(_ context.Context, token string) (*types.AuthResult, error) {
+	if res, ok := s[token]; ok {
+		return res, nil
+	}
+	return nil, errors.E(errors.KindPermission, "bad token")
+}
+
+func TestAuthenticate(t *testing.T) {
+	tokens := stubTokens{"good": {Subject: "alice", AllowedRoles: []string{"reader"}}}
+	mc := &middlewareChain{authn: NewAuthenticator(tokens)}
+	r := gin.New()
+	r.GET("/whoami", mc.Auth(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, AuthResultFrom(c))
+	})
+	call := func(authz string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/whoami", nil)
+		if authz != "" {
+			req.Header.Set("Authorization", authz)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("bearer good")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"subject":"alice","allowed_roles":["reader"]}`, rec.Body.String())
+
+	for _, authz := range []string{"", "Bearer bad", "Basic YWxpY2U6eA=="} {
+		rec = call(authz)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, authz)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	}
+
+	// 未配置 token 校验时 bearer 一律拒绝
+	mc.authn = NewAuthenticator(nil)
+	assert.Equal(t, http.StatusUnauthorized, call("Bearer good").Code)
+}
+
+func TestAuthenticateHTTP(t *testing.T) {
+	tokens := stubTokens{"good": {Subject: "alice"}}
+	h := AuthenticateHTTP(NewAuthenticator(tokens), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(security.CallerFromContext(r.Context())))
+	}))
+	call := func(authz string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/mcp", nil)
+		if authz != "" {
+			req.Header.Set("Authorization", authz)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("Bearer good")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "alice", rec.Body.String())
+
+	// 没有客户端证书也没有有效 token 时不放行
+	for _, authz := range []string{"", "Bearer bad"} {
+		rec = call(authz)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, authz)
+		assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	}
+
+	// subject 为空视同未认证
+	empty := AuthenticateHTTP(Anonymous(""), http.NotFoundHandler())
+	rec = httptest.NewRecorder()
+	empty.ServeHTTP(rec, httptest.NewRequest("POST", "/mcp", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -2,39 +2,98 @@
 package gateway
 
 import (
+	"math"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/types"
 )
 
-type middlewareChain struct{}
+type middlewareChain struct {
+	authn Authenticator
+	rbac  security.RBAC // 为 nil 时不做授权
+}
 
-// Auth 认证：提取 JWT 或 SPIFFE header
+// Auth 认证：认证结果写入 gin.Context，调用方另写入请求 ctx，供调用器按调用方限流与去重
 func (mc *middlewareChain) Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
-		if token != "Bearer valid-token" { // TODO: 支持 SPIFFE
+		res, err := mc.authn.Authenticate(c.Request)
+		if err != nil || res == nil || res.Subject == "" {
+			logger.Debug(c.Request.Context(), "gateway: unauthenticated", zap.Error(err))
+			c.Header("WWW-Authenticate", "Bearer")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 			return
 		}
-		c.Set("subject", "user@example.com")
+		c.Set(ctxKeyAuth, res)
+		c.Set(ctxKeySubject, res.Subject)
+		c.Request = c.Request.WithContext(security.WithCaller(c.Request.Context(), res.Subject))
 		c.Next()
 	}
 }
 
-// Authorize 授权：RBAC 检查
+// Authorize 授权：RBAC 检查，结果写入审计日志。resource 中的 :name 依次取 c.Keys 与路径参数，
+// 前置中间件可借此换成解析后的值（如工具引用换成工具名）
 func (mc *middlewareChain) Authorize(action, resource string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: Fix this when security.MustRBAC is implemented
+		res := expandResource(c, resource)
+		if mc.rbac != nil {
+			if err := mc.rbac.Authorize(c.Request.Context(), c.GetString(ctxKeySubject), action, res); err != nil {
+				audit(c, action, res, "denied")
+				writeError(c, errors.Permission(err, action+" "+res))
+				return
+			}
+		}
+		audit(c, action, res, "success")
 		c.Next()
 	}
 }
 
+// allowed 只做判断不写审计，用于按条过滤列表
+func (mc *middlewareChain) allowed(c *gin.Context, action, resource string) bool {
+	return mc.rbac == nil || mc.rbac.Authorize(c.Request.Context(), c.GetString(ctxKeySubject), action, resource) == nil
+}
+
+func audit(c *gin.Context, action, resource, outcome string) {
+	security.AuditLog(c.Request.Context(), &types.AuditEvent{
+		Actor: c.GetString(ctxKeySubject), Resource: resource, Action: strings.ToUpper(action),
+		Outcome: outcome, IP: c.ClientIP(),
+		Meta: map[string]interface{}{"via": "gateway", "method": c.Request.Method, "path": c.FullPath()},
+	})
+}
+
+func expandResource(c *gin.Context, resource string) string {
+	parts := strings.Split(resource, "/")
+	for i, p := range parts {
+		if !strings.HasPrefix(p, ":") {
+			continue
+		}
+		key := p[1:]
+		if v := c.GetString(key); v != "" {
+			parts[i] = v
+		} else {
+			parts[i] = c.Param(key)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
 // Recover 统一 panic 处理
 func (mc *middlewareChain) Recover() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		// TODO: Fix this when logger.ErrorWithCtx is implemented
+		logger.Error(c.Request.Context(), "gateway: panic",
+			zap.Any("panic", recovered), zap.ByteString("stack", debug.Stack()))
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 	})
 }
@@ -42,17 +101,104 @@ func (mc *middlewareChain) Recover() gin.HandlerFunc {
 // Logging 记录请求/响应链
 func (mc *middlewareChain) Logging() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: Fix this when logger.InfoWithCtx is implemented
+		start := time.Now()
 		c.Next()
+		logger.Info(c.Request.Context(), "gateway request",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.FullPath()),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("subject", c.GetString(ctxKeySubject)),
+		)
 	}
 }
 
 // TraceID 注入
 func (mc *middlewareChain) TraceID(traceHeader string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx := trace.SpanContextFromContext(c)
-		c.Header(traceHeader, ctx.TraceID().String())
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+			c.Header(traceHeader, sc.TraceID().String())
+		}
 		c.Next()
 	}
 }
+
+// minLimiterIdle 调用方桶至少保留的空闲时长
+const minLimiterIdle = time.Minute
+
+// subjectLimiters 按调用方分桶。空闲到令牌补满的桶与新建的等价，
+// 定期清掉，调用方（如 JWT subject）不断增多时内存不会无限增长
+type subjectLimiters struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	idle      time.Duration
+	now       func() time.Time
+	lastSweep time.Time
+	m         map[string]*subjectLimiter
+}
+
+type subjectLimiter struct {
+	*rate.Limiter
+	seen time.Time
+}
+
+func newSubjectLimiters(limit rate.Limit, burst int) *subjectLimiters {
+	idle := minLimiterIdle
+	if limit > 0 && limit != rate.Inf {
+		idle = max(idle, time.Duration(float64(burst)/float64(limit)*float64(time.Second)))
+	}
+	return &subjectLimiters{limit: limit, burst: burst, idle: idle, now: time.Now, m: make(map[string]*subjectLimiter)}
+}
+
+func (s *subjectLimiters) get(subject string) *rate.Limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.lastSweep) >= s.idle {
+		for k, l := range s.m {
+			if now.Sub(l.seen) >= s.idle {
+				delete(s.m, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	l, ok := s.m[subject]
+	if !ok {
+		l = &subjectLimiter{Limiter: rate.NewLimiter(s.limit, s.burst)}
+		s.m[subject] = l
+	}
+	l.seen = now
+	return l.Limiter
+}
+
+// rateMiddleware 每条路由独立限流，同一路由内按调用方分桶
+func rateMiddleware(limit rate.Limit, burst int) gin.HandlerFunc {
+	limiters := newSubjectLimiters(limit, burst)
+	return func(c *gin.Context) {
+		r := limiters.get(c.GetString(ctxKeySubject)).Reserve()
+		if d := r.Delay(); d > 0 {
+			r.Cancel()
+			writeError(c, errors.RateLimited(d, "rate limited"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// writeError 按错误分类映射状态码；限流错误带 Retry-After（秒，向上取整）
+func writeError(c *gin.Context, err error) {
+	kind := errors.KindOf(err)
+	status := (&errors.Error{Kind: kind}).HTTPStatus()
+	if d := errors.RetryAfterOf(err); d > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+	}
+	msg := err.Error()
+	if status == http.StatusInternalServerError {
+		logger.Error(c.Request.Context(), "gateway: internal error", zap.Error(err))
+		msg = "internal error"
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": msg, "kind": kind})
+}
+
 //Personal.AI order the ending
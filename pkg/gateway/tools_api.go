@@ -0,0 +1,276 @@
+// pkg/gateway/tools_api.go
+package gateway
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+const (
+	// HeaderIdempotencyKey 同一调用方以相同键重试时只执行一次
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// ctxKeyToolSpec resolveTool 写入的解析结果；ctxKeyTool 为其工具名，供 Authorize 展开 :tool
+	ctxKeyToolSpec = "toolSpec"
+	ctxKeyTool     = "tool"
+	// maxInvocations 保留可查询的调用记录数，超出时先淘汰最早结束的
+	maxInvocations = 1024
+	// invocationRetention 已结束的调用记录保留时长
+	invocationRetention = time.Hour
+)
+
+// toolView 工具的对外描述，不含绑定中的地址与凭据
+type toolView struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Version        string            `json:"version"`
+	Digest         string            `json:"digest,omitempty"`
+	DisplayName    string            `json:"displayName,omitempty"`
+	Description    string            `json:"description,omitempty"`
+	Category       string            `json:"category,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	Binding        string            `json:"binding"`
+	ArgsSchema     apis.AnyMap       `json:"argsSchema,omitempty"`
+	DefaultTimeout metav1.Duration   `json:"defaultTimeout,omitempty"`
+}
+
+func viewOf(spec *apis.ToolSpec) *toolView {
+	v := &toolView{
+		ID: spec.ID, Name: spec.Name, Version: spec.Version, Digest: spec.Digest,
+		DisplayName: spec.DisplayName, Description: spec.Description, Category: spec.Category,
+		Tags: spec.Tags, ArgsSchema: spec.ArgsSchema, DefaultTimeout: spec.DefaultTimeout,
+	}
+	switch {
+	case spec.MCP != nil:
+		v.Binding = "mcp"
+	case spec.OpenAPI != nil:
+		v.Binding = "openapi"
+	case spec.CustomExec != nil:
+		v.Binding = "custom"
+	}
+	return v
+}
+
+// handleListTools ?name=&category=&tag=k=v（可重复，只写 k 表示存在即可）。
+// 与 MCP tools/list 一样只列出调用方有权查看（get tools/<name>）的工具
+func (g *Gateway) handleListTools() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := &apis.ToolFilter{Name: c.Query("name"), Category: c.Query("category")}
+		for _, t := range c.QueryArray("tag") {
+			if filter.Tags == nil {
+				filter.Tags = make(map[string]string)
+			}
+			k, v, _ := strings.Cut(t, "=")
+			filter.Tags[k] = v
+		}
+		items, err := g.reg.List(c.Request.Context(), filter)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		visible := make([]*apis.Metadata, 0, len(items))
+		for _, m := range items {
+			if g.mc.allowed(c, "get", tools.ToolResource(m.Name)) {
+				visible = append(visible, m)
+			}
+		}
+		items = visible
+		c.JSON(http.StatusOK, gin.H{"items": items})
+	}
+}
+
+// resolveTool 路径中的 :tool 可为 id、name、name@<semver 约束> 或 name@sha256:<hex>
+func (g *Gateway) resolveTool() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		spec, err := g.reg.Resolve(c.Request.Context(), c.Param("tool"))
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		c.Set(ctxKeyToolSpec, spec)
+		c.Set(ctxKeyTool, spec.Name)
+		c.Next()
+	}
+}
+
+func (g *Gateway) handleDescribeTool() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, viewOf(c.MustGet(ctxKeyToolSpec).(*apis.ToolSpec)))
+	}
+}
+
+// invokeRequest 调用请求体
+type invokeRequest struct {
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// handleInvoke 默认同步返回结果；带 Prefer: respond-async 时立即返回 202，
+// 结果经 Location 指向的 /api/v1/invocations/:id 查询。工具以 name@digest 钉死，
+// 授权与实际调用的是同一版本
+func (g *Gateway) handleInvoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req invokeRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				writeError(c, errors.Validation(err, "invalid request body"))
+				return
+			}
+		}
+		spec := c.MustGet(ctxKeyToolSpec).(*apis.ToolSpec)
+		ref := tools.PinRef(spec.ID, spec.Name, spec.Digest)
+		caller := c.GetString(ctxKeySubject)
+		key := c.GetHeader(HeaderIdempotencyKey)
+
+		if !strings.Contains(c.GetHeader("Prefer"), "respond-async") {
+			ctx := tools.WithIdempotencyKey(c.Request.Context(), key)
+			res, err := g.inv.Invoke(ctx, ref, req.Arguments)
+			if err != nil {
+				writeError(c, err)
+				return
+			}
+			inv := g.invocations.add(ref, caller)
+			inv = g.invocations.finish(inv.ID, res, nil)
+			c.Header("Location", "/api/v1/invocations/"+inv.ID)
+			c.JSON(http.StatusOK, inv)
+			return
+		}
+
+		// 异步调用脱离请求生命周期，保留调用方、幂等键与 trace
+		ctx := trace.ContextWithSpanContext(g.ctx, trace.SpanContextFromContext(c.Request.Context()))
+		ctx = tools.WithIdempotencyKey(security.WithCaller(ctx, caller), key)
+		inv := g.invocations.add(ref, caller)
+		g.wg.Add(1)
+		go func() {
+			defer g.wg.Done()
+			res, err := g.inv.Invoke(ctx, ref, req.Arguments)
+			g.invocations.finish(inv.ID, res, err)
+		}()
+		c.Header("Location", "/api/v1/invocations/"+inv.ID)
+		c.JSON(http.StatusAccepted, inv)
+	}
+}
+
+// handleInvocationStatus 只有发起者能查询，其他调用方一律 404
+func (g *Gateway) handleInvocationStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inv, ok := g.invocations.get(c.Param("id"), c.GetString(ctxKeySubject))
+		if !ok {
+			writeError(c, errors.E(errors.KindNotFound, fmt.Sprintf("invocation %q not found", c.Param("id"))))
+			return
+		}
+		c.JSON(http.StatusOK, inv)
+	}
+}
+
+// InvocationState 调用状态
+type InvocationState string
+
+const (
+	InvocationRunning   InvocationState = "running"
+	InvocationSucceeded InvocationState = "succeeded"
+	InvocationFailed    InvocationState = "failed" // 调用未完成，或工具自身报告了错误
+)
+
+// Invocation 一次工具调用的记录
+type Invocation struct {
+	ID        string           `json:"id"`
+	Tool      string           `json:"tool"` // name@digest
+	State     InvocationState  `json:"state"`
+	Result    *apis.ToolResult `json:"result,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	Kind      errors.Kind      `json:"kind,omitempty"` // 调用未完成时的错误分类
+	StartTime time.Time        `json:"startTime"`
+	EndTime   *time.Time       `json:"endTime,omitempty"`
+
+	caller string
+}
+
+// invocationStore 内存中的调用记录
+type invocationStore struct {
+	mu    sync.Mutex
+	items map[string]*Invocation
+	order []string // 按创建顺序
+	now   func() time.Time
+}
+
+func newInvocationStore() *invocationStore {
+	return &invocationStore{items: make(map[string]*Invocation), now: time.Now}
+}
+
+func (s *invocationStore) add(tool, caller string) Invocation {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	inv := &Invocation{
+		ID: hex.EncodeToString(b), Tool: tool, State: InvocationRunning,
+		StartTime: s.now().UTC(), caller: caller,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked()
+	s.items[inv.ID] = inv
+	s.order = append(s.order, inv.ID)
+	return *inv
+}
+
+func (s *invocationStore) finish(id string, res *apis.ToolResult, err error) Invocation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.items[id]
+	if !ok {
+		return Invocation{ID: id}
+	}
+	end := s.now().UTC()
+	inv.EndTime = &end
+	inv.Result = res
+	switch {
+	case err != nil:
+		inv.State, inv.Error, inv.Kind = InvocationFailed, err.Error(), errors.KindOf(err)
+	case res != nil && res.Error != "":
+		inv.State, inv.Error = InvocationFailed, res.Error
+	default:
+		inv.State = InvocationSucceeded
+	}
+	return *inv
+}
+
+func (s *invocationStore) get(id, caller string) (Invocation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.items[id]
+	if !ok || inv.caller != caller {
+		return Invocation{}, false
+	}
+	return *inv, true
+}
+
+// sweepLocked 清理过期记录；仍超上限时淘汰最早的已结束记录，进行中的调用不淘汰
+func (s *invocationStore) sweepLocked() {
+	cutoff := s.now().Add(-invocationRetention)
+	excess := len(s.order) + 1 - maxInvocations
+	kept := s.order[:0]
+	for _, id := range s.order {
+		inv := s.items[id]
+		if inv.EndTime != nil && (inv.EndTime.Before(cutoff) || excess > 0) {
+			delete(s.items, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,812 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: mcp/model_context.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ContextEventType int32
+
+const (
+	ContextEventType_CONTEXT_EVENT_TYPE_UNSPECIFIED ContextEventType = 0
+	ContextEventType_CONTEXT_EVENT_TYPE_UPDATED     ContextEventType = 1
+	ContextEventType_CONTEXT_EVENT_TYPE_DELETED     ContextEventType = 2
+)
+
+// Enum value maps for ContextEventType.
+var (
+	ContextEventType_name = map[int32]string{
+		0: "CONTEXT_EVENT_TYPE_UNSPECIFIED",
+		1: "CONTEXT_EVENT_TYPE_UPDATED",
+		2: "CONTEXT_EVENT_TYPE_DELETED",
+	}
+	ContextEventType_value = map[string]int32{
+		"CONTEXT_EVENT_TYPE_UNSPECIFIED": 0,
+		"CONTEXT_EVENT_TYPE_UPDATED":     1,
+		"CONTEXT_EVENT_TYPE_DELETED":     2,
+	}
+)
+
+func (x ContextEventType) Enum() *ContextEventType {
+	p := new(ContextEventType)
+	*p = x
+	return p
+}
+
+func (x ContextEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ContextEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_mcp_model_context_proto_enumTypes[0].Descriptor()
+}
+
+func (ContextEventType) Type() protoreflect.EnumType {
+	return &file_mcp_model_context_proto_enumTypes[0]
+}
+
+func (x ContextEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ContextEventType.Descriptor instead.
+func (ContextEventType) EnumDescriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{0}
+}
+
+type GetContextRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContextId     string                 `protobuf:"bytes,1,opt,name=context_id,json=contextId,proto3" json:"context_id,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetContextRequest) Reset() {
+	*x = GetContextRequest{}
+	mi := &file_mcp_model_context_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetContextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetContextRequest) ProtoMessage() {}
+
+func (x *GetContextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetContextRequest.ProtoReflect.Descriptor instead.
+func (*GetContextRequest) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetContextRequest) GetContextId() string {
+	if x != nil {
+		return x.ContextId
+	}
+	return ""
+}
+
+func (x *GetContextRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type GetContextResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          string                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetContextResponse) Reset() {
+	*x = GetContextResponse{}
+	mi := &file_mcp_model_context_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetContextResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetContextResponse) ProtoMessage() {}
+
+func (x *GetContextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetContextResponse.ProtoReflect.Descriptor instead.
+func (*GetContextResponse) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetContextResponse) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *GetContextResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *GetContextResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type UpdateRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ContextId       string                 `protobuf:"bytes,1,opt,name=context_id,json=contextId,proto3" json:"context_id,omitempty"`
+	Data            string                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	ExpectedVersion *int64                 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_mcp_model_context_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UpdateRequest) GetContextId() string {
+	if x != nil {
+		return x.ContextId
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type UpdateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateResponse) Reset() {
+	*x = UpdateResponse{}
+	mi := &file_mcp_model_context_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateResponse) ProtoMessage() {}
+
+func (x *UpdateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateResponse.ProtoReflect.Descriptor instead.
+func (*UpdateResponse) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *UpdateResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ListVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContextId     string                 `protobuf:"bytes,1,opt,name=context_id,json=contextId,proto3" json:"context_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsRequest) Reset() {
+	*x = ListVersionsRequest{}
+	mi := &file_mcp_model_context_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsRequest) ProtoMessage() {}
+
+func (x *ListVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListVersionsRequest) GetContextId() string {
+	if x != nil {
+		return x.ContextId
+	}
+	return ""
+}
+
+type ContextVersion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContextVersion) Reset() {
+	*x = ContextVersion{}
+	mi := &file_mcp_model_context_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContextVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContextVersion) ProtoMessage() {}
+
+func (x *ContextVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContextVersion.ProtoReflect.Descriptor instead.
+func (*ContextVersion) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ContextVersion) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ContextVersion) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ContextVersion) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type ListVersionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []*ContextVersion      `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsResponse) Reset() {
+	*x = ListVersionsResponse{}
+	mi := &file_mcp_model_context_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsResponse) ProtoMessage() {}
+
+func (x *ListVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListVersionsResponse) GetVersions() []*ContextVersion {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type DeleteContextRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ContextId       string                 `protobuf:"bytes,1,opt,name=context_id,json=contextId,proto3" json:"context_id,omitempty"`
+	ExpectedVersion *int64                 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteContextRequest) Reset() {
+	*x = DeleteContextRequest{}
+	mi := &file_mcp_model_context_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteContextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteContextRequest) ProtoMessage() {}
+
+func (x *DeleteContextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteContextRequest.ProtoReflect.Descriptor instead.
+func (*DeleteContextRequest) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteContextRequest) GetContextId() string {
+	if x != nil {
+		return x.ContextId
+	}
+	return ""
+}
+
+func (x *DeleteContextRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteContextResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DeletedVersions int64                  `protobuf:"varint,1,opt,name=deleted_versions,json=deletedVersions,proto3" json:"deleted_versions,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteContextResponse) Reset() {
+	*x = DeleteContextResponse{}
+	mi := &file_mcp_model_context_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteContextResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteContextResponse) ProtoMessage() {}
+
+func (x *DeleteContextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteContextResponse.ProtoReflect.Descriptor instead.
+func (*DeleteContextResponse) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteContextResponse) GetDeletedVersions() int64 {
+	if x != nil {
+		return x.DeletedVersions
+	}
+	return 0
+}
+
+type WatchContextRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ContextId     string                 `protobuf:"bytes,1,opt,name=context_id,json=contextId,proto3" json:"context_id,omitempty"`
+	SinceVersion  int64                  `protobuf:"varint,2,opt,name=since_version,json=sinceVersion,proto3" json:"since_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchContextRequest) Reset() {
+	*x = WatchContextRequest{}
+	mi := &file_mcp_model_context_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchContextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchContextRequest) ProtoMessage() {}
+
+func (x *WatchContextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchContextRequest.ProtoReflect.Descriptor instead.
+func (*WatchContextRequest) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WatchContextRequest) GetContextId() string {
+	if x != nil {
+		return x.ContextId
+	}
+	return ""
+}
+
+func (x *WatchContextRequest) GetSinceVersion() int64 {
+	if x != nil {
+		return x.SinceVersion
+	}
+	return 0
+}
+
+type ContextEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          ContextEventType       `protobuf:"varint,1,opt,name=type,proto3,enum=agenticai.mcp.v1.ContextEventType" json:"type,omitempty"`
+	ContextId     string                 `protobuf:"bytes,2,opt,name=context_id,json=contextId,proto3" json:"context_id,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	Data          string                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContextEvent) Reset() {
+	*x = ContextEvent{}
+	mi := &file_mcp_model_context_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContextEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContextEvent) ProtoMessage() {}
+
+func (x *ContextEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_mcp_model_context_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContextEvent.ProtoReflect.Descriptor instead.
+func (*ContextEvent) Descriptor() ([]byte, []int) {
+	return file_mcp_model_context_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ContextEvent) GetType() ContextEventType {
+	if x != nil {
+		return x.Type
+	}
+	return ContextEventType_CONTEXT_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *ContextEvent) GetContextId() string {
+	if x != nil {
+		return x.ContextId
+	}
+	return ""
+}
+
+func (x *ContextEvent) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ContextEvent) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *ContextEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+var File_mcp_model_context_proto protoreflect.FileDescriptor
+
+const file_mcp_model_context_proto_rawDesc = "" +
+	"\n" +
+	"\x17mcp/model_context.proto\x12\x10agenticai.mcp.v1\"L\n" +
+	"\x11GetContextRequest\x12\x1d\n" +
+	"\n" +
+	"context_id\x18\x01 \x01(\tR\tcontextId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"`\n" +
+	"\x12GetContextResponse\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\tR\x04data\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"\x87\x01\n" +
+	"\rUpdateRequest\x12\x1d\n" +
+	"\n" +
+	"context_id\x18\x01 \x01(\tR\tcontextId\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\tR\x04data\x12.\n" +
+	"\x10expected_version\x18\x03 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"b\n" +
+	"\x0eUpdateResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\"4\n" +
+	"\x13ListVersionsRequest\x12\x1d\n" +
+	"\n" +
+	"context_id\x18\x01 \x01(\tR\tcontextId\"\\\n" +
+	"\x0eContextVersion\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\"T\n" +
+	"\x14ListVersionsResponse\x12<\n" +
+	"\bversions\x18\x01 \x03(\v2 .agenticai.mcp.v1.ContextVersionR\bversions\"z\n" +
+	"\x14DeleteContextRequest\x12\x1d\n" +
+	"\n" +
+	"context_id\x18\x01 \x01(\tR\tcontextId\x12.\n" +
+	"\x10expected_version\x18\x02 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"B\n" +
+	"\x15DeleteContextResponse\x12)\n" +
+	"\x10deleted_versions\x18\x01 \x01(\x03R\x0fdeletedVersions\"Y\n" +
+	"\x13WatchContextRequest\x12\x1d\n" +
+	"\n" +
+	"context_id\x18\x01 \x01(\tR\tcontextId\x12#\n" +
+	"\rsince_version\x18\x02 \x01(\x03R\fsinceVersion\"\xb1\x01\n" +
+	"\fContextEvent\x126\n" +
+	"\x04type\x18\x01 \x01(\x0e2\".agenticai.mcp.v1.ContextEventTypeR\x04type\x12\x1d\n" +
+	"\n" +
+	"context_id\x18\x02 \x01(\tR\tcontextId\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\tR\x04data\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp*v\n" +
+	"\x10ContextEventType\x12\"\n" +
+	"\x1eCONTEXT_EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1e\n" +
+	"\x1aCONTEXT_EVENT_TYPE_UPDATED\x10\x01\x12\x1e\n" +
+	"\x1aCONTEXT_EVENT_TYPE_DELETED\x10\x022\xdc\x03\n" +
+	"\x13ModelContextService\x12W\n" +
+	"\n" +
+	"GetContext\x12#.agenticai.mcp.v1.GetContextRequest\x1a$.agenticai.mcp.v1.GetContextResponse\x12R\n" +
+	"\rUpdateContext\x12\x1f.agenticai.mcp.v1.UpdateRequest\x1a .agenticai.mcp.v1.UpdateResponse\x12]\n" +
+	"\fListVersions\x12%.agenticai.mcp.v1.ListVersionsRequest\x1a&.agenticai.mcp.v1.ListVersionsResponse\x12`\n" +
+	"\rDeleteContext\x12&.agenticai.mcp.v1.DeleteContextRequest\x1a'.agenticai.mcp.v1.DeleteContextResponse\x12W\n" +
+	"\fWatchContext\x12%.agenticai.mcp.v1.WatchContextRequest\x1a\x1e.agenticai.mcp.v1.ContextEvent0\x01B7Z5github.com/turtacn/agenticai/pkg/gen/api/proto/mcp/v1b\x06proto3"
+
+var (
+	file_mcp_model_context_proto_rawDescOnce sync.Once
+	file_mcp_model_context_proto_rawDescData []byte
+)
+
+func file_mcp_model_context_proto_rawDescGZIP() []byte {
+	file_mcp_model_context_proto_rawDescOnce.Do(func() {
+		file_mcp_model_context_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mcp_model_context_proto_rawDesc), len(file_mcp_model_context_proto_rawDesc)))
+	})
+	return file_mcp_model_context_proto_rawDescData
+}
+
+var file_mcp_model_context_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_mcp_model_context_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_mcp_model_context_proto_goTypes = []any{
+	(ContextEventType)(0),         // 0: agenticai.mcp.v1.ContextEventType
+	(*GetContextRequest)(nil),     // 1: agenticai.mcp.v1.GetContextRequest
+	(*GetContextResponse)(nil),    // 2: agenticai.mcp.v1.GetContextResponse
+	(*UpdateRequest)(nil),         // 3: agenticai.mcp.v1.UpdateRequest
+	(*UpdateResponse)(nil),        // 4: agenticai.mcp.v1.UpdateResponse
+	(*ListVersionsRequest)(nil),   // 5: agenticai.mcp.v1.ListVersionsRequest
+	(*ContextVersion)(nil),        // 6: agenticai.mcp.v1.ContextVersion
+	(*ListVersionsResponse)(nil),  // 7: agenticai.mcp.v1.ListVersionsResponse
+	(*DeleteContextRequest)(nil),  // 8: agenticai.mcp.v1.DeleteContextRequest
+	(*DeleteContextResponse)(nil), // 9: agenticai.mcp.v1.DeleteContextResponse
+	(*WatchContextRequest)(nil),   // 10: agenticai.mcp.v1.WatchContextRequest
+	(*ContextEvent)(nil),          // 11: agenticai.mcp.v1.ContextEvent
+}
+var file_mcp_model_context_proto_depIdxs = []int32{
+	6,  // 0: agenticai.mcp.v1.ListVersionsResponse.versions:type_name -> agenticai.mcp.v1.ContextVersion
+	0,  // 1: agenticai.mcp.v1.ContextEvent.type:type_name -> agenticai.mcp.v1.ContextEventType
+	1,  // 2: agenticai.mcp.v1.ModelContextService.GetContext:input_type -> agenticai.mcp.v1.GetContextRequest
+	3,  // 3: agenticai.mcp.v1.ModelContextService.UpdateContext:input_type -> agenticai.mcp.v1.UpdateRequest
+	5,  // 4: agenticai.mcp.v1.ModelContextService.ListVersions:input_type -> agenticai.mcp.v1.ListVersionsRequest
+	8,  // 5: agenticai.mcp.v1.ModelContextService.DeleteContext:input_type -> agenticai.mcp.v1.DeleteContextRequest
+	10, // 6: agenticai.mcp.v1.ModelContextService.WatchContext:input_type -> agenticai.mcp.v1.WatchContextRequest
+	2,  // 7: agenticai.mcp.v1.ModelContextService.GetContext:output_type -> agenticai.mcp.v1.GetContextResponse
+	4,  // 8: agenticai.mcp.v1.ModelContextService.UpdateContext:output_type -> agenticai.mcp.v1.UpdateResponse
+	7,  // 9: agenticai.mcp.v1.ModelContextService.ListVersions:output_type -> agenticai.mcp.v1.ListVersionsResponse
+	9,  // 10: agenticai.mcp.v1.ModelContextService.DeleteContext:output_type -> agenticai.mcp.v1.DeleteContextResponse
+	11, // 11: agenticai.mcp.v1.ModelContextService.WatchContext:output_type -> agenticai.mcp.v1.ContextEvent
+	7,  // [7:12] is the sub-list for method output_type
+	2,  // [2:7] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_mcp_model_context_proto_init() }
+func file_mcp_model_context_proto_init() {
+	if File_mcp_model_context_proto != nil {
+		return
+	}
+	file_mcp_model_context_proto_msgTypes[2].OneofWrappers = []any{}
+	file_mcp_model_context_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mcp_model_context_proto_rawDesc), len(file_mcp_model_context_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mcp_model_context_proto_goTypes,
+		DependencyIndexes: file_mcp_model_context_proto_depIdxs,
+		EnumInfos:         file_mcp_model_context_proto_enumTypes,
+		MessageInfos:      file_mcp_model_context_proto_msgTypes,
+	}.Build()
+	File_mcp_model_context_proto = out.File
+	file_mcp_model_context_proto_goTypes = nil
+	file_mcp_model_context_proto_depIdxs = nil
+}
@@ -0,0 +1,277 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: mcp/model_context.proto
+
+package v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ModelContextService_GetContext_FullMethodName    = "/agenticai.mcp.v1.ModelContextService/GetContext"
+	ModelContextService_UpdateContext_FullMethodName = "/agenticai.mcp.v1.ModelContextService/UpdateContext"
+	ModelContextService_ListVersions_FullMethodName  = "/agenticai.mcp.v1.ModelContextService/ListVersions"
+	ModelContextService_DeleteContext_FullMethodName = "/agenticai.mcp.v1.ModelContextService/DeleteContext"
+	ModelContextService_WatchContext_FullMethodName  = "/agenticai.mcp.v1.ModelContextService/WatchContext"
+)
+
+// ModelContextServiceClient is the client API for ModelContextService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ModelContextServiceClient interface {
+	GetContext(ctx context.Context, in *GetContextRequest, opts ...grpc.CallOption) (*GetContextResponse, error)
+	UpdateContext(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
+	ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error)
+	DeleteContext(ctx context.Context, in *DeleteContextRequest, opts ...grpc.CallOption) (*DeleteContextResponse, error)
+	WatchContext(ctx context.Context, in *WatchContextRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ContextEvent], error)
+}
+
+type modelContextServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewModelContextServiceClient(cc grpc.ClientConnInterface) ModelContextServiceClient {
+	return &modelContextServiceClient{cc}
+}
+
+func (c *modelContextServiceClient) GetContext(ctx context.Context, in *GetContextRequest, opts ...grpc.CallOption) (*GetContextResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetContextResponse)
+	err := c.cc.Invoke(ctx, ModelContextService_GetContext_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *modelContextServiceClient) UpdateContext(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateResponse)
+	err := c.cc.Invoke(ctx, ModelContextService_UpdateContext_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *modelContextServiceClient) ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVersionsResponse)
+	err := c.cc.Invoke(ctx, ModelContextService_ListVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *modelContextServiceClient) DeleteContext(ctx context.Context, in *DeleteContextRequest, opts ...grpc.CallOption) (*DeleteContextResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteContextResponse)
+	err := c.cc.Invoke(ctx, ModelContextService_DeleteContext_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *modelContextServiceClient) WatchContext(ctx context.Context, in *WatchContextRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ContextEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ModelContextService_ServiceDesc.Streams[0], ModelContextService_WatchContext_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchContextRequest, ContextEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ModelContextService_WatchContextClient = grpc.ServerStreamingClient[ContextEvent]
+
+// ModelContextServiceServer is the server API for ModelContextService service.
+// All implementations must embed UnimplementedModelContextServiceServer
+// for forward compatibility.
+type ModelContextServiceServer interface {
+	GetContext(context.Context, *GetContextRequest) (*GetContextResponse, error)
+	UpdateContext(context.Context, *UpdateRequest) (*UpdateResponse, error)
+	ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error)
+	DeleteContext(context.Context, *DeleteContextRequest) (*DeleteContextResponse, error)
+	WatchContext(*WatchContextRequest, grpc.ServerStreamingServer[ContextEvent]) error
+	mustEmbedUnimplementedModelContextServiceServer()
+}
+
+// UnimplementedModelContextServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedModelContextServiceServer struct{}
+
+func (UnimplementedModelContextServiceServer) GetContext(context.Context, *GetContextRequest) (*GetContextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetContext not implemented")
+}
+func (UnimplementedModelContextServiceServer) UpdateContext(context.Context, *UpdateRequest) (*UpdateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateContext not implemented")
+}
+func (UnimplementedModelContextServiceServer) ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVersions not implemented")
+}
+func (UnimplementedModelContextServiceServer) DeleteContext(context.Context, *DeleteContextRequest) (*DeleteContextResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteContext not implemented")
+}
+func (UnimplementedModelContextServiceServer) WatchContext(*WatchContextRequest, grpc.ServerStreamingServer[ContextEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchContext not implemented")
+}
+func (UnimplementedModelContextServiceServer) mustEmbedUnimplementedModelContextServiceServer() {}
+func (UnimplementedModelContextServiceServer) testEmbeddedByValue()                             {}
+
+// UnsafeModelContextServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ModelContextServiceServer will
+// result in compilation errors.
+type UnsafeModelContextServiceServer interface {
+	mustEmbedUnimplementedModelContextServiceServer()
+}
+
+func RegisterModelContextServiceServer(s grpc.ServiceRegistrar, srv ModelContextServiceServer) {
+	// If the following call pancis, it indicates UnimplementedModelContextServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ModelContextService_ServiceDesc, srv)
+}
+
+func _ModelContextService_GetContext_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetContextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelContextServiceServer).GetContext(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelContextService_GetContext_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelContextServiceServer).GetContext(ctx, req.(*GetContextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ModelContextService_UpdateContext_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelContextServiceServer).UpdateContext(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelContextService_UpdateContext_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelContextServiceServer).UpdateContext(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ModelContextService_ListVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelContextServiceServer).ListVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelContextService_ListVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelContextServiceServer).ListVersions(ctx, req.(*ListVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ModelContextService_DeleteContext_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteContextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModelContextServiceServer).DeleteContext(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ModelContextService_DeleteContext_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModelContextServiceServer).DeleteContext(ctx, req.(*DeleteContextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ModelContextService_WatchContext_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchContextRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ModelContextServiceServer).WatchContext(m, &grpc.GenericServerStream[WatchContextRequest, ContextEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ModelContextService_WatchContextServer = grpc.ServerStreamingServer[ContextEvent]
+
+// ModelContextService_ServiceDesc is the grpc.ServiceDesc for ModelContextService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ModelContextService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "agenticai.mcp.v1.ModelContextService",
+	HandlerType: (*ModelContextServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetContext",
+			Handler:    _ModelContextService_GetContext_Handler,
+		},
+		{
+			MethodName: "UpdateContext",
+			Handler:    _ModelContextService_UpdateContext_Handler,
+		},
+		{
+			MethodName: "ListVersions",
+			Handler:    _ModelContextService_ListVersions_Handler,
+		},
+		{
+			MethodName: "DeleteContext",
+			Handler:    _ModelContextService_DeleteContext_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchContext",
+			Handler:       _ModelContextService_WatchContext_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mcp/model_context.proto",
+}
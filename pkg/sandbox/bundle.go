@@ -0,0 +1,281 @@
+// pkg/sandbox/bundle.go
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/turtacn/agenticai/pkg/security"
+)
+
+// crane 导出镜像文件系统与读取镜像配置，可替换以便测试
+var crane = "crane"
+
+// defaultPath 镜像未声明 PATH 时使用
+const defaultPath = "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// cpuPeriod CFS 周期（微秒），quota 按 CPU 核数折算
+const cpuPeriod = 100000
+
+// newSandboxID 每次启动一个新 ID，同一镜像的并发调用互不冲突
+func newSandboxID(kind string) string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "ag-" + kind + "-" + hex.EncodeToString(b)
+}
+
+// imageConfig OCI 镜像配置中影响进程的部分
+type imageConfig struct {
+	Entrypoint []string `json:"Entrypoint"`
+	Cmd        []string `json:"Cmd"`
+	Env        []string `json:"Env"`
+	WorkingDir string   `json:"WorkingDir"`
+}
+
+// prepareBundle 把镜像解包到 dir/rootfs，并按镜像配置与 spec 写出 dir/config.json
+func prepareBundle(ctx context.Context, dir string, spec *SandboxSpec) error {
+	linux, err := linuxConfig(spec)
+	if err != nil {
+		return err
+	}
+	img, err := pullImageConfig(ctx, spec.ImageRef)
+	if err != nil {
+		return err
+	}
+	if err := unpackImage(ctx, spec.ImageRef, filepath.Join(dir, "rootfs")); err != nil {
+		return err
+	}
+	config, err := ociSpec(spec, img, linux)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "config.json"), config, 0644)
+}
+
+// linuxConfig OCI spec 的 linux 段：seccomp、命名空间与资源上限。
+// spec.Network 为 false 时进入新的网络命名空间，只有 loopback
+func linuxConfig(spec *SandboxSpec) (map[string]interface{}, error) {
+	namespaces := []map[string]string{{"type": "pid"}, {"type": "ipc"}, {"type": "uts"}, {"type": "mount"}}
+	if !spec.Network {
+		namespaces = append(namespaces, map[string]string{"type": "network"})
+	}
+	linux := map[string]interface{}{
+		"namespaces": namespaces,
+		"seccomp":    security.SeccompProfileFor(spec.SysCallRestriction),
+	}
+	res, err := resourcesOf(spec.Resource)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) > 0 {
+		linux["resources"] = res
+	}
+	return linux, nil
+}
+
+// resourcesOf CPU、内存按 Kubernetes 数量格式解析（如 500m、512Mi），空或 0 为不限制
+func resourcesOf(r ResourceLimit) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	if r.CPU != "" {
+		q, err := resource.ParseQuantity(r.CPU)
+		if err != nil {
+			return nil, fmt.Errorf("sandbox cpu %q: %w", r.CPU, err)
+		}
+		if q.Sign() > 0 {
+			res["cpu"] = map[string]interface{}{"quota": q.MilliValue() * cpuPeriod / 1000, "period": cpuPeriod}
+		}
+	}
+	if r.Mem != "" {
+		q, err := resource.ParseQuantity(r.Mem)
+		if err != nil {
+			return nil, fmt.Errorf("sandbox memory %q: %w", r.Mem, err)
+		}
+		if q.Sign() > 0 {
+			res["memory"] = map[string]interface{}{"limit": q.Value()}
+		}
+	}
+	return res, nil
+}
+
+func pullImageConfig(ctx context.Context, ref string) (*imageConfig, error) {
+	out, err := exec.CommandContext(ctx, crane, "config", ref).Output()
+	if err != nil {
+		return nil, fmt.Errorf("image config %s: %w", ref, err)
+	}
+	var cfg struct {
+		Config imageConfig `json:"config"`
+	}
+	if err := json.Unmarshal(out, &cfg); err != nil {
+		return nil, fmt.Errorf("image config %s: %w", ref, err)
+	}
+	return &cfg.Config, nil
+}
+
+// unpackImage 导出镜像合并后的文件系统并解包到 rootfs
+func unpackImage(ctx context.Context, ref, rootfs string) error {
+	if err := os.MkdirAll(rootfs, 0755); err != nil {
+		return err
+	}
+	export := exec.CommandContext(ctx, crane, "export", ref, "-")
+	untar := exec.CommandContext(ctx, "tar", "-x", "-C", rootfs)
+	pipe, err := export.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	untar.Stdin = pipe
+	if err := export.Start(); err != nil {
+		return fmt.Errorf("export image %s: %w", ref, err)
+	}
+	untarErr := untar.Run()
+	if err := export.Wait(); err != nil {
+		return fmt.Errorf("export image %s: %w", ref, err)
+	}
+	if untarErr != nil {
+		return fmt.Errorf("unpack image %s: %w", ref, untarErr)
+	}
+	return nil
+}
+
+// ociSpec spec.Cmd 为空时取镜像的 Entrypoint+Cmd；spec.Env 覆盖镜像中的同名变量
+func ociSpec(spec *SandboxSpec, img *imageConfig, linux map[string]interface{}) ([]byte, error) {
+	args := spec.Cmd
+	if len(args) == 0 {
+		args = append(append([]string{}, img.Entrypoint...), img.Cmd...)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("image %s: no command", spec.ImageRef)
+	}
+	cwd := img.WorkingDir
+	if cwd == "" {
+		cwd = "/"
+	}
+	return json.Marshal(map[string]interface{}{
+		"ociVersion": "1.0.0",
+		"root":       map[string]interface{}{"path": "rootfs"},
+		"process":    map[string]interface{}{"cwd": cwd, "env": mergeEnv(img.Env, spec.Env), "args": args},
+		"linux":      linux,
+	})
+}
+
+func mergeEnv(base []string, override map[string]string) []string {
+	env := make([]string, 0, len(base)+len(override)+1)
+	hasPath := false
+	for _, kv := range base {
+		k, _, _ := strings.Cut(kv, "=")
+		if _, ok := override[k]; ok {
+			continue
+		}
+		hasPath = hasPath || k == "PATH"
+		env = append(env, kv)
+	}
+	keys := make([]string, 0, len(override))
+	for k := range override {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		hasPath = hasPath || k == "PATH"
+		env = append(env, k+"="+override[k])
+	}
+	if !hasPath {
+		env = append(env, defaultPath)
+	}
+	return env
+}
+
+// stdio 容器进程的标准输入输出。运行时 create 返回后容器仍持有管道，
+// 因此不能交给 exec.Cmd 拷贝（Run 会一直等到容器退出），由这里自行拷贝
+type stdio struct {
+	child   [3]*os.File // 交给运行时的一端，create 后关闭
+	parents []*os.File
+	wg      sync.WaitGroup
+}
+
+func openStdio(spec *SandboxSpec) (*stdio, error) {
+	s := &stdio{}
+	if spec.Stdin != nil {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		s.child[0] = r
+		s.parents = append(s.parents, w)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			_, _ = io.Copy(w, spec.Stdin)
+			_ = w.Close()
+		}()
+	}
+	for i, dst := range []io.Writer{spec.Stdout, spec.Stderr} {
+		if dst == nil {
+			continue
+		}
+		r, w, err := os.Pipe()
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		s.child[i+1] = w
+		s.parents = append(s.parents, r)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			_, _ = io.Copy(dst, r)
+		}()
+	}
+	return s, nil
+}
+
+func (s *stdio) attach(cmd *exec.Cmd) {
+	// 未连接的流保持 nil，exec 会接到 /dev/null
+	if s.child[0] != nil {
+		cmd.Stdin = s.child[0]
+	}
+	if s.child[1] != nil {
+		cmd.Stdout = s.child[1]
+	}
+	if s.child[2] != nil {
+		cmd.Stderr = s.child[2]
+	}
+}
+
+func (s *stdio) closeChild() {
+	for i, f := range s.child {
+		if f != nil {
+			_ = f.Close()
+			s.child[i] = nil
+		}
+	}
+}
+
+// drain 容器退出后等输出拷贝完
+func (s *stdio) drain() { s.wg.Wait() }
+
+// close 关闭所有管道，拷贝随之结束
+func (s *stdio) close() {
+	s.closeChild()
+	for _, f := range s.parents {
+		_ = f.Close()
+	}
+}
+
+// exitError 容器进程非零退出，ExitCode 供调用方取退出码
+type exitError struct{ code int }
+
+func (e *exitError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+func (e *exitError) ExitCode() int { return e.code }
+
+//Personal.AI order the ending
@@ -0,0 +1,101 @@
+package sandbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOCISpec(t *testing.T) {
+	img := &imageConfig{
+		Entrypoint: []string{"/entry"}, Cmd: []string{"--serve"},
+		Env: []string{"PATH=/app/bin", "MODE=prod"}, WorkingDir: "/app",
+	}
+	decode := func(spec *SandboxSpec) map[string]interface{} {
+		raw, err := ociSpec(spec, img, map[string]interface{}{"seccomp": "x"})
+		require.NoError(t, err)
+		var out map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &out))
+		return out
+	}
+
+	// 未指定命令时取镜像的 Entrypoint+Cmd
+	out := decode(&SandboxSpec{ImageRef: "img"})
+	proc := out["process"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"/entry", "--serve"}, proc["args"])
+	assert.Equal(t, "/app", proc["cwd"])
+	assert.Equal(t, []interface{}{"PATH=/app/bin", "MODE=prod"}, proc["env"])
+	assert.Equal(t, map[string]interface{}{"path": "rootfs"}, out["root"])
+	assert.Equal(t, map[string]interface{}{"seccomp": "x"}, out["linux"])
+
+	// spec 的命令与环境变量优先
+	out = decode(&SandboxSpec{ImageRef: "img", Cmd: []string{"/add", "--json"}, Env: map[string]string{"MODE": "test", "A": "1"}})
+	proc = out["process"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"/add", "--json"}, proc["args"])
+	assert.Equal(t, []interface{}{"PATH=/app/bin", "A=1", "MODE=test"}, proc["env"])
+
+	_, err := ociSpec(&SandboxSpec{ImageRef: "img"}, &imageConfig{}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, mergeEnv(nil, nil), defaultPath)
+}
+
+func TestLinuxConfig(t *testing.T) {
+	namespaces := func(linux map[string]interface{}) []string {
+		var out []string
+		for _, ns := range linux["namespaces"].([]map[string]string) {
+			out = append(out, ns["type"])
+		}
+		return out
+	}
+
+	// 默认不联网：独立的网络命名空间；未给资源时不写 resources
+	linux, err := linuxConfig(&SandboxSpec{ImageRef: "img"})
+	require.NoError(t, err)
+	assert.Contains(t, namespaces(linux), "network")
+	assert.NotContains(t, linux, "resources")
+	assert.NotNil(t, linux["seccomp"])
+
+	linux, err = linuxConfig(&SandboxSpec{ImageRef: "img", Network: true, Resource: ResourceLimit{CPU: "500m", Mem: "512Mi"}})
+	require.NoError(t, err)
+	assert.NotContains(t, namespaces(linux), "network")
+	assert.Equal(t, map[string]interface{}{
+		"cpu":    map[string]interface{}{"quota": int64(50000), "period": cpuPeriod},
+		"memory": map[string]interface{}{"limit": int64(512 << 20)},
+	}, linux["resources"])
+
+	_, err = linuxConfig(&SandboxSpec{ImageRef: "img", Resource: ResourceLimit{CPU: "half"}})
+	assert.Error(t, err)
+}
+
+func TestSandboxIDUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for range 100 {
+		id := newSandboxID("gvisor")
+		assert.True(t, strings.HasPrefix(id, "ag-gvisor-"))
+		assert.False(t, seen[id])
+		seen[id] = true
+	}
+}
+
+// 运行时 create 退出后容器仍持有管道，输出要在容器退出后才读完
+func TestStdioOutlivesCreate(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	s, err := openStdio(&SandboxSpec{Stdin: strings.NewReader("ping"), Stdout: &stdout, Stderr: &stderr})
+	require.NoError(t, err)
+	defer s.close()
+
+	// 模拟 create：启动后立即返回，后台进程继承管道
+	cmd := exec.Command("sh", "-c", "exec 3<&0; (sleep 0.05; cat <&3; echo err >&2) &")
+	s.attach(cmd)
+	require.NoError(t, cmd.Run())
+	s.closeChild()
+
+	s.drain()
+	assert.Equal(t, "ping", stdout.String())
+	assert.Equal(t, "err\n", stderr.String())
+}
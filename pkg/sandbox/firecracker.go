@@ -22,7 +22,7 @@ type firecrackerRunner struct {
 }
 
 func newFirecrackerRunner(spec *SandboxSpec) Sandbox {
-	return &firecrackerRunner{ID: spec.ImageRef + "-fc"}
+	return &firecrackerRunner{ID: newSandboxID("fc")}
 }
 
 func (fc *firecrackerRunner) Start(ctx context.Context) error {
@@ -68,6 +68,9 @@ func (fc *firecrackerRunner) Kill(ctx context.Context) error {
 func (fc *firecrackerRunner) Wait(ctx context.Context) error {
 	return fc.proc.Wait(ctx)
 }
+func (fc *firecrackerRunner) Delete(ctx context.Context) error {
+	return os.RemoveAll("/var/run/ag/fc/" + fc.ID)
+}
 func (fc *firecrackerRunner) Info(ctx context.Context) (*Info, error) {
 	// TODO: Populate with actual info from the machine
 	return &Info{ID: fc.ID, StartTime: time.Now()}, nil
@@ -3,6 +3,7 @@ package sandbox
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -19,41 +20,90 @@ type gvisor struct {
 	ID   string
 	dir  string
 	bin  string
-	cmd  *exec.Cmd
+	spec *SandboxSpec
+	io   *stdio
 }
 
 const runsc = "/usr/local/bin/runsc"
 
 func newGvisorRunner(spec *SandboxSpec) Sandbox {
-	return &gvisor{ID: spec.ImageRef + "-gvisor", bin: runsc}
+	return &gvisor{ID: newSandboxID("gvisor"), bin: runsc, spec: spec}
 }
 
-func (g *gvisor) Start(ctx context.Context) error {
+// Start 解包镜像后 create 再 start；失败时清理已创建的容器与目录
+func (g *gvisor) Start(ctx context.Context) (err error) {
 	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("sandbox").Start(ctx, "gvisor.start")
 	defer span.End()
 	span.SetAttributes(attribute.String("sandbox.id", g.ID))
 
 	g.dir = "/var/run/ag/sb/" + g.ID
-	_ = os.MkdirAll(g.dir, 0755)
-	if err := g.createBundle(); err != nil {
+	if err := os.MkdirAll(g.dir, 0755); err != nil {
 		return err
 	}
-	g.cmd = exec.CommandContext(ctx, g.bin, "create",
-		"--bundle", g.dir, "--pid-file", "pid", g.ID)
-	g.cmd.Dir = g.dir
-	if err := g.cmd.Run(); err != nil {
+	defer func() {
+		if err != nil {
+			_ = g.Delete(context.Background())
+		}
+	}()
+	if err := prepareBundle(ctx, g.dir, g.spec); err != nil {
+		return err
+	}
+	if g.io, err = openStdio(g.spec); err != nil {
 		return err
 	}
+	// runsc 默认忽略 linux.seccomp，需显式开启
+	create := exec.CommandContext(ctx, g.bin, "--oci-seccomp", "create",
+		"--bundle", g.dir, "--pid-file", "pid", g.ID)
+	create.Dir = g.dir
+	// runsc create 把自身的标准输入输出交给容器进程
+	g.io.attach(create)
+	err = create.Run()
+	g.io.closeChild()
+	if err != nil {
+		return fmt.Errorf("runsc create: %w", err)
+	}
+	if err := exec.CommandContext(ctx, g.bin, "start", g.ID).Run(); err != nil {
+		return fmt.Errorf("runsc start: %w", err)
+	}
 	logger.Info(ctx, "gvisor started", zap.String("ID", g.ID))
 	return nil
 }
 
 func (g *gvisor) Kill(_ context.Context) error {
-	return exec.Command(g.bin, "kill", g.ID).Run()
+	return exec.Command(g.bin, "kill", g.ID, "KILL").Run()
+}
+
+// Wait 等容器退出并读完输出，非零退出码以 ExitCode() 返回
+func (g *gvisor) Wait(ctx context.Context) error {
+	out, err := exec.CommandContext(ctx, g.bin, "wait", g.ID).Output()
+	if g.io != nil {
+		g.io.drain()
+	}
+	if err != nil {
+		return fmt.Errorf("runsc wait: %w", err)
+	}
+	var res struct {
+		ExitStatus int `json:"exitStatus"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		return fmt.Errorf("runsc wait: %w", err)
+	}
+	if res.ExitStatus != 0 {
+		return &exitError{code: res.ExitStatus}
+	}
+	return nil
 }
 
-func (g *gvisor) Wait(_ context.Context) error {
-	return exec.Command(g.bin, "wait", g.ID).Run()
+// Delete 强制删除容器并清理 bundle，可重复调用
+func (g *gvisor) Delete(_ context.Context) error {
+	err := exec.Command(g.bin, "delete", "--force", g.ID).Run()
+	if g.io != nil {
+		g.io.close()
+	}
+	if g.dir != "" {
+		_ = os.RemoveAll(g.dir)
+	}
+	return err
 }
 
 func (g *gvisor) Info(_ context.Context) (*Info, error) {
@@ -62,9 +112,4 @@ func (g *gvisor) Info(_ context.Context) (*Info, error) {
 	fmt.Sscan(string(pidBytes), &pid)
 	return &Info{ID: g.ID, Pid: pid, StartTime: time.Now()}, nil
 }
-
-func (g *gvisor) createBundle() error {
-	config := fmt.Sprintf(`{"ociVersion":"1.0.0","process":{"cwd":"/","env":[],"args":["/bin/echo","hello"]},"linux,omitempty":{}}`)
-	return os.WriteFile(filepath.Join(g.dir, "config.json"), []byte(config), 0644)
-}
 //Personal.AI order the ending
@@ -15,49 +15,75 @@ import (
 
 type kata struct {
 	ID   string
+	dir  string
 	spec *SandboxSpec
-	cmd  *exec.Cmd
+	io   *stdio
 }
 
 func newKataRunner(spec *SandboxSpec) Sandbox {
-	return &kata{ID: spec.ImageRef + "-kata", spec: spec}
+	return &kata{ID: newSandboxID("kata"), spec: spec}
 }
 
-func (k *kata) Start(ctx context.Context) error {
+// Start 解包镜像后 create 再 start；失败时清理已创建的容器与目录
+func (k *kata) Start(ctx context.Context) (err error) {
 	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("sandbox").Start(ctx, "kata.start")
 	defer span.End()
 
-	dir := "/var/run/ag/kata/" + k.ID
-	_ = os.MkdirAll(dir, 0755)
-	config := k.generateSpec()
-	cfgFile := dir + "/config.json"
-	if err := os.WriteFile(cfgFile, []byte(config), 0644); err != nil {
+	k.dir = "/var/run/ag/kata/" + k.ID
+	if err := os.MkdirAll(k.dir, 0755); err != nil {
 		return err
 	}
-	k.cmd = exec.CommandContext(ctx, "kata-runtime", "--config", cfgFile, "create", "--bundle", dir, k.ID)
-	if err := k.cmd.Start(); err != nil {
+	defer func() {
+		if err != nil {
+			_ = k.Delete(context.Background())
+		}
+	}()
+	// seccomp 由 guest 内的 kata-agent 执行，需保持 disable_guest_seccomp=false；
+	// linux.resources 决定热插给 VM 的 CPU 与内存
+	if err := prepareBundle(ctx, k.dir, k.spec); err != nil {
 		return err
 	}
+	if k.io, err = openStdio(k.spec); err != nil {
+		return err
+	}
+	// bundle 中的 config.json 即 OCI spec，--config 是 kata 自身的配置，不能指向它
+	create := exec.CommandContext(ctx, "kata-runtime", "create", "--bundle", k.dir, k.ID)
+	k.io.attach(create)
+	err = create.Run()
+	k.io.closeChild()
+	if err != nil {
+		return fmt.Errorf("kata-runtime create: %w", err)
+	}
+	if err := exec.CommandContext(ctx, "kata-runtime", "start", k.ID).Run(); err != nil {
+		return fmt.Errorf("kata-runtime start: %w", err)
+	}
 	logger.Info(ctx, "kata started", zap.String("ID", k.ID))
 	return nil
 }
 
 func (k *kata) Kill(_ context.Context) error {
-	return exec.Command("kata-runtime", "kill", k.ID).Run()
+	return exec.Command("kata-runtime", "kill", k.ID, "KILL").Run()
 }
-func (k *kata) Wait(_ context.Context) error {
-	return exec.Command("kata-runtime", "wait", k.ID).Run()
+func (k *kata) Wait(ctx context.Context) error {
+	err := exec.CommandContext(ctx, "kata-runtime", "wait", k.ID).Run()
+	if k.io != nil {
+		k.io.drain()
+	}
+	return err
+}
+
+// Delete 强制删除容器并清理 bundle，可重复调用
+func (k *kata) Delete(_ context.Context) error {
+	err := exec.Command("kata-runtime", "delete", "--force", k.ID).Run()
+	if k.io != nil {
+		k.io.close()
+	}
+	if k.dir != "" {
+		_ = os.RemoveAll(k.dir)
+	}
+	return err
 }
 func (k *kata) Info(_ context.Context) (*Info, error) {
 	return &Info{ID: k.ID, StartTime: time.Now()}, nil
 }
-
-func (k *kata) generateSpec() string {
-	return fmt.Sprintf(`{
-		"ociVersion":"1.0.0",
-		"process":{"cwd":"/","args":["/bin/sh"]},
-		"linux":{"resources":{"memory":{"limit":1000000000},"cpu":{"quota":100000}},
-		         "annotations":{"com.io.kata.vcpus":"1","com.io.kata.memory":"1G"}}
-	}`)
-}
 //Personal.AI order the ending
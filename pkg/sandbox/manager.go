@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
@@ -26,6 +27,13 @@ type SandboxSpec struct {
 	Resource ResourceLimit
 	Network  bool
 	Volume   string
+	// SysCallRestriction 在内置 agent-default 之外额外禁止的系统调用，写入 OCI seccomp
+	SysCallRestriction []string
+
+	// 沙箱进程的标准输入输出，nil 表示不连接
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 type ResourceLimit struct {
@@ -38,6 +46,8 @@ type Sandbox interface {
 	Kill(ctx context.Context) error
 	Wait(ctx context.Context) error
 	Info(ctx context.Context) (*Info, error)
+	// Delete 释放运行时状态与 bundle，结束后（包括超时 Kill 后）必须调用
+	Delete(ctx context.Context) error
 }
 
 type Info struct {
@@ -10,7 +10,6 @@ import (
 
 	"go.opentelemetry.io/otel"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	e "github.com/turtacn/agenticai/internal/errors"
@@ -21,7 +20,8 @@ import (
 
 // ------- 内存账本结构 -------
 type resourceRecord struct {
-	Allocatable corev1.ResourceList
+	Allocatable corev1.ResourceList // 在线实例上报的容量之和
+	Free        corev1.ResourceList // 在线实例上报的余量之和
 	Reserved    corev1.ResourceList
 	LastSeen    time.Time
 }
@@ -73,8 +73,9 @@ func (m *manager) PredicateAgents(ctx context.Context, task *agenticaiov1.Task)
 
 	var out []*AgentSnapshot
 	for k, rec := range m.table {
-		// CPU Mem GPU 检查
-		avail := subResource(rec.Allocatable, rec.Reserved)
+		// CPU Mem GPU 检查：账本预留与心跳余量取更紧的一个，
+		// 前者覆盖两次心跳之间的派发，后者覆盖账本之外的占用
+		avail := minResource(subResource(rec.Allocatable, rec.Reserved), rec.Free)
 		if needSatisfied(avail, task.Spec.Resources.Limits) {
 			out = append(out, &AgentSnapshot{
 				Name:        k,
@@ -168,13 +169,20 @@ func (m *manager) syncFromApiserver(ctx context.Context) {
 	newTable := make(map[string]*resourceRecord)
 
 	for _, a := range agentList.Items {
-		var allocatable corev1.ResourceList
-		if a.Status.DesiredReplicas > 0 && len(a.Status.Conditions) > 0 && readyCondTrue(a.Status.Conditions) {
-			allocatable = corev1.ResourceList{
-				corev1.ResourceCPU:    *resource.NewScaledQuantity(1000, resource.Milli),
-				corev1.ResourceMemory: *resource.NewScaledQuantity(512, resource.Mega),
+		if a.Status.DesiredReplicas == 0 || !readyCondTrue(a.Status.Conditions) {
+			continue
+		}
+		// 只统计心跳未超时且沙箱健康的实例
+		allocatable, free := corev1.ResourceList{}, corev1.ResourceList{}
+		for i := range a.Status.Runtimes {
+			rs := &a.Status.Runtimes[i]
+			if !rs.Alive(now) || !rs.SandboxHealthy {
+				continue
 			}
-		} else {
+			allocatable = addResource(allocatable, rs.Capacity)
+			free = addResource(free, rs.Free)
+		}
+		if len(allocatable) == 0 {
 			continue
 		}
 
@@ -189,6 +197,7 @@ func (m *manager) syncFromApiserver(ctx context.Context) {
 
 		newTable[key] = &resourceRecord{
 			Allocatable: allocatable,
+			Free:        free,
 			Reserved:    reserved,
 			LastSeen:    now,
 		}
@@ -231,9 +240,20 @@ func subResource(a, b corev1.ResourceList) corev1.ResourceList {
 	return out
 }
 
+// minResource 逐项取较小值，b 中没有的资源沿用 a
+func minResource(a, b corev1.ResourceList) corev1.ResourceList {
+	out := a.DeepCopy()
+	for k, v := range b {
+		if q, ok := out[k]; ok && v.Cmp(q) < 0 {
+			out[k] = v.DeepCopy()
+		}
+	}
+	return out
+}
+
 func readyCondTrue(conds []apis.AgentCondition) bool {
 	for _, c := range conds {
-		if c.Type == "Available" && c.Status == "True" {
+		if c.Type == apis.AgentConditionAvailable && c.Status == corev1.ConditionTrue {
 			return true
 		}
 	}
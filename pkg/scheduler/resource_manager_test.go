@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/turtacn/agenticai/pkg/apis"
+	agenticaiov1 "github.com/turtacn/agenticai/pkg/apis/agenticai.io/v1"
+)
+
+func cpu(s string) corev1.ResourceList {
+	return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(s)}
+}
+
+func availableAgent(name string, runtimes ...apis.RuntimeStatus) *apis.Agent {
+	return &apis.Agent{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "agents"},
+		Status: apis.AgentStatus{
+			DesiredReplicas: int32(len(runtimes)),
+			Conditions:      []apis.AgentCondition{{Type: apis.AgentConditionAvailable, Status: corev1.ConditionTrue}},
+			Runtimes:        runtimes,
+		},
+	}
+}
+
+func TestSyncUsesHeartbeatCapacity(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, apis.AddToScheme(s))
+	fresh := metav1.Now()
+	stale := metav1.NewTime(time.Now().Add(-2 * apis.HeartbeatTimeout))
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		// 两个在线实例合计 4 核，心跳余量只剩 1 核
+		availableAgent("busy",
+			apis.RuntimeStatus{ID: "a", LastHeartbeat: fresh, SandboxHealthy: true, Capacity: cpu("2"), Free: cpu("500m")},
+			apis.RuntimeStatus{ID: "b", LastHeartbeat: fresh, SandboxHealthy: true, Capacity: cpu("2"), Free: cpu("500m")}),
+		availableAgent("idle",
+			apis.RuntimeStatus{ID: "a", LastHeartbeat: fresh, SandboxHealthy: true, Capacity: cpu("2"), Free: cpu("2")},
+			// 超时与沙箱异常的实例不计入
+			apis.RuntimeStatus{ID: "b", LastHeartbeat: stale, SandboxHealthy: true, Capacity: cpu("8"), Free: cpu("8")},
+			apis.RuntimeStatus{ID: "c", LastHeartbeat: fresh, SandboxHealthy: false, Capacity: cpu("8"), Free: cpu("8")}),
+		availableAgent("silent"),
+	).Build()
+
+	m := NewResourceManager(c).(*manager)
+	ctx := context.Background()
+	m.syncFromApiserver(ctx)
+	require.Len(t, m.table, 2)
+	assert.True(t, m.table["agents/idle"].Allocatable.Cpu().Equal(resource.MustParse("2")))
+
+	names := func(limit string) []string {
+		task := &agenticaiov1.Task{Spec: agenticaiov1.TaskSpec{Resources: corev1.ResourceRequirements{Limits: cpu(limit)}}}
+		snaps, err := m.PredicateAgents(ctx, task)
+		require.NoError(t, err)
+		var out []string
+		for _, s := range snaps {
+			out = append(out, s.Name)
+		}
+		return out
+	}
+	assert.ElementsMatch(t, []string{"agents/busy", "agents/idle"}, names("1"))
+	assert.Equal(t, []string{"agents/idle"}, names("1500m"))
+
+	// 账本预留比心跳更紧时以预留为准
+	task := &agenticaiov1.Task{Spec: agenticaiov1.TaskSpec{Resources: corev1.ResourceRequirements{Limits: cpu("1500m")}}}
+	require.NoError(t, m.Reserve(ctx, "agents/idle", task))
+	assert.Equal(t, []string{"agents/busy"}, names("1"))
+}
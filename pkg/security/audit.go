@@ -2,15 +2,31 @@
 package security
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/zap"
 
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/errors"
 	"github.com/turtacn/agenticai/internal/logger"
 	api "github.com/turtacn/agenticai/pkg/types"
 )
@@ -20,27 +36,187 @@ var (
 		Name: "agenticai_audit_events_total",
 		Help: "audit events",
 	}, []string{"result", "resource"})
+	// 链头 seq 随指标采集留在审计文件之外，可据此发现结尾被截断
+	auditHeadSeq = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "agenticai_audit_chain_head_seq",
+		Help: "seq of the last audit record written",
+	})
 )
 
-// Audit 单例
-var (
-	fp       *os.File
-	encoder *json.Encoder
-)
+// AuditRecord 审计日志中的一行。Hash 覆盖 Seq、PrevHash 与 Event 的原始字节，
+// 前后记录以 PrevHash 串成链，删改中间任一条都会使其后的校验失败。
+// 未配置密钥时 Hash 是普通 SHA-256，能写文件的人可以整条重算；
+// 链本身也不能发现开头或结尾被整段删除，见 AuditVerifier
+type AuditRecord struct {
+	Seq      uint64          `json:"seq"`
+	Event    json.RawMessage `json:"event"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+}
+
+// chainHash key 非空时为 HMAC-SHA256
+func chainHash(key []byte, seq uint64, prev string, event []byte) string {
+	h := sha256.New()
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	}
+	h.Write([]byte(prev))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(strconv.FormatUint(seq, 10)))
+	h.Write([]byte{'\n'})
+	h.Write(event)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AuditSink 审计记录的去向，Write 须按调用顺序持久化
+type AuditSink interface {
+	Write(ctx context.Context, rec *AuditRecord) error
+	Close() error
+}
+
+// auditTail 能读回最后一条记录的 sink，重启后据此续接哈希链
+type auditTail interface {
+	Last() (*AuditRecord, error)
+}
+
+// auditQueueSize AuditLog 后台写入队列的长度，队列满时 AuditLog 阻塞直到 sink 跟上
+const auditQueueSize = 1024
 
-func init() {
-	var err error
-	fp, err = os.OpenFile("/var/log/audit.jsonl", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+// Auditor 为事件编号、计算哈希链并写入 sink。Log 同步写入，持锁期间等待 sink；
+// StartAsync 后 Enqueue 交给单个后台 goroutine 按入队顺序写入，请求路径不等慢 sink
+type Auditor struct {
+	mu   sync.Mutex
+	sink AuditSink
+	key  []byte
+	seq  uint64
+	prev string
+
+	qmu    sync.RWMutex // 保护 queue 的关闭
+	queue  chan auditItem
+	done   chan struct{}
+	closed bool
+}
+
+type auditItem struct {
+	ctx context.Context
+	ev  *api.AuditEvent
+}
+
+// AuditorOption 配置 Auditor
+type AuditorOption func(*Auditor)
+
+// WithAuditKey 用 HMAC-SHA256 计算哈希链，没有密钥无法伪造整条链
+func WithAuditKey(key []byte) AuditorOption {
+	return func(a *Auditor) { a.key = key }
+}
+
+// NewAuditor sink 能读回已有记录时从链尾继续，否则从 seq 1 开始新链
+func NewAuditor(sink AuditSink, opts ...AuditorOption) (*Auditor, error) {
+	a := &Auditor{sink: sink}
+	for _, o := range opts {
+		o(a)
+	}
+	if t, ok := sink.(auditTail); ok {
+		last, err := t.Last()
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			a.seq, a.prev = last.Seq, last.Hash
+		}
+	}
+	return a, nil
+}
+
+// Log 写入失败时不推进链，后续记录仍接在最后一条成功写入的记录之后
+func (a *Auditor) Log(ctx context.Context, ev *api.AuditEvent) error {
+	if ev.EventTime.IsZero() {
+		ev.EventTime = time.Now().UTC()
+	}
+	raw, err := json.Marshal(ev)
 	if err != nil {
-		panic(err)
+		return errors.Internal(err, "audit: encode event")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rec := &AuditRecord{Seq: a.seq + 1, Event: raw, PrevHash: a.prev}
+	rec.Hash = chainHash(a.key, rec.Seq, rec.PrevHash, raw)
+	if err := a.sink.Write(ctx, rec); err != nil {
+		return err
 	}
-	encoder = json.NewEncoder(fp)
+	a.seq, a.prev = rec.Seq, rec.Hash
+	auditHeadSeq.Set(float64(rec.Seq))
+	return nil
 }
 
-func AuditLog(ctx context.Context, ev *api.AuditEvent) {
+// Head 最后一条写入成功的记录，未写入时 seq 为 0
+func (a *Auditor) Head() (uint64, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.seq, a.prev
+}
+
+// StartAsync 启动后台写入，只应调用一次
+func (a *Auditor) StartAsync(size int) {
+	a.queue = make(chan auditItem, size)
+	a.done = make(chan struct{})
+	go func() {
+		defer close(a.done)
+		for it := range a.queue {
+			if err := a.Log(it.ctx, it.ev); err != nil {
+				logger.Error(it.ctx, "audit write fail", zap.Error(err))
+			}
+		}
+	}()
+}
+
+// Enqueue 未启动后台写入时等同 Log；否则事件时间取入队时刻，写入错误只记日志
+func (a *Auditor) Enqueue(ctx context.Context, ev *api.AuditEvent) error {
+	a.qmu.RLock()
+	defer a.qmu.RUnlock()
+	if a.queue == nil {
+		return a.Log(ctx, ev)
+	}
+	if a.closed {
+		return errors.E(errors.KindUnavailable, "audit: auditor closed")
+	}
 	ev.EventTime = time.Now().UTC()
-	if err := encoder.Encode(ev); err != nil {
-		logger.Error(ctx, "audit write fail", zap.Error(err))
+	// 请求结束后仍要写完
+	a.queue <- auditItem{ctx: context.WithoutCancel(ctx), ev: ev}
+	return nil
+}
+
+// Close 写完已入队的事件后关闭 sink，并把链头记到进程日志，
+// 供 actl audit verify --head 发现审计文件结尾被截断
+func (a *Auditor) Close() error {
+	a.qmu.Lock()
+	if a.queue != nil && !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.qmu.Unlock()
+	if a.done != nil {
+		<-a.done
+	}
+	if seq, hash := a.Head(); seq > 0 {
+		logger.Info(context.Background(), "audit chain head", zap.Uint64("seq", seq), zap.String("hash", hash))
+	}
+	return a.sink.Close()
+}
+
+var defaultAuditor atomic.Pointer[Auditor]
+
+// SetAuditor 设置 AuditLog 使用的 Auditor；未设置时 AuditLog 只计数
+func SetAuditor(a *Auditor) {
+	defaultAuditor.Store(a)
+}
+
+// AuditLog Auditor 已 StartAsync 时不等待 sink，见 Auditor.Enqueue
+func AuditLog(ctx context.Context, ev *api.AuditEvent) {
+	if a := defaultAuditor.Load(); a != nil {
+		if err := a.Enqueue(ctx, ev); err != nil {
+			logger.Error(ctx, "audit write fail", zap.Error(err))
+		}
 	}
 	result := "success"
 	if ev.Outcome == "denied" || ev.Outcome == "error" {
@@ -48,4 +224,455 @@ func AuditLog(ctx context.Context, ev *api.AuditEvent) {
 	}
 	auditCounter.WithLabelValues(result, ev.Resource).Inc()
 }
+
+// InitAudit 按配置设置 AuditLog 的默认 Auditor，返回的函数在退出前调用以关闭 sink
+func InitAudit(cfg config.Audit) (func() error, error) {
+	return InitAuditTo(cfg, os.Stdout)
+}
+
+// InitAuditTo stdout sink 改写 out（如 MCP stdio 模式下的 stderr）。
+// file sink 打不开（非 root、只读根文件系统）时告警并退回 out，不阻止启动
+func InitAuditTo(cfg config.Audit, out io.Writer) (func() error, error) {
+	noop := func() error { return nil }
+	var key []byte
+	if cfg.KeyFile != "" {
+		var err error
+		if key, err = LoadAuditKey(cfg.KeyFile); err != nil {
+			return noop, err
+		}
+	}
+	sink, err := newAuditSink(cfg, out)
+	var a *Auditor
+	if err == nil && sink != nil {
+		if a, err = NewAuditor(sink, WithAuditKey(key)); err != nil {
+			sink.Close()
+		}
+	}
+	if err != nil && cfg.Sink == "file" {
+		logger.Warn(context.Background(), "audit: file sink unavailable, writing audit records to stdout",
+			zap.String("path", cfg.Path), zap.Error(err))
+		sink = NewWriterSink(out)
+		a, err = NewAuditor(sink, WithAuditKey(key))
+	}
+	if err != nil || sink == nil {
+		return noop, err
+	}
+	a.StartAsync(auditQueueSize)
+	SetAuditor(a)
+	return a.Close, nil
+}
+
+// LoadAuditKey 读取哈希链密钥文件，首尾空白不计入密钥
+func LoadAuditKey(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Internal(err, "audit: read key")
+	}
+	key := bytes.TrimSpace(raw)
+	if len(key) == 0 {
+		return nil, errors.E(errors.KindValidation, "audit: key file "+path+" is empty")
+	}
+	return key, nil
+}
+
+// NewAuditSink 按 config.Security.Audit 创建 sink；sink 为 none 或空时返回 nil
+func NewAuditSink(cfg config.Audit) (AuditSink, error) {
+	return newAuditSink(cfg, os.Stdout)
+}
+
+func newAuditSink(cfg config.Audit, out io.Writer) (AuditSink, error) {
+	switch cfg.Sink {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return NewWriterSink(out), nil
+	case "file":
+		return NewFileSink(cfg)
+	case "webhook":
+		return NewWebhookSink(cfg.WebhookURL, cfg.WebhookTimeout), nil
+	}
+	return nil, errors.E(errors.KindValidation, fmt.Sprintf("audit: unknown sink %q", cfg.Sink))
+}
+
+// WriterSink 每条记录一行 JSON
+type WriterSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{enc: json.NewEncoder(w)}
+}
+
+func (s *WriterSink) Write(_ context.Context, rec *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(rec)
+}
+
+func (s *WriterSink) Close() error { return nil }
+
+// WebhookSink 每条记录单独 POST，非 2xx 视为失败
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *WebhookSink) Write(ctx context.Context, rec *AuditRecord) error {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Internal(err, "audit: encode record")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Validation(err, "audit: webhook url")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Unavailable(err, "audit: webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return errors.E(errors.KindUnavailable, fmt.Sprintf("audit: webhook: status %d", resp.StatusCode))
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error { return nil }
+
+// auditBackupLayout 轮转文件名后缀，定宽以便按字典序排序
+const auditBackupLayout = "20060102T150405.000000000Z"
+
+// FileSink 追加写入 JSONL，超过 MaxSizeMB 或打开超过 RotateInterval 时
+// 把当前文件改名为 <path>.<时间戳>，只保留最近 MaxBackups 个
+type FileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	now        func() time.Time
+
+	f       *os.File
+	size    int64
+	created time.Time // 当前文件第一条记录的时间，决定按时间轮转
+}
+
+func NewFileSink(cfg config.Audit) (*FileSink, error) {
+	s := &FileSink{
+		path:       cfg.Path,
+		maxSize:    int64(cfg.MaxSizeMB) << 20,
+		interval:   cfg.RotateInterval,
+		maxBackups: cfg.MaxBackups,
+		now:        time.Now,
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, errors.Internal(err, "audit: create log dir")
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open 打开当前文件；上次写入中断留下的半行以换行结束，不与新记录粘连
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return errors.Internal(err, "audit: open log")
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Internal(err, "audit: stat log")
+	}
+	s.f, s.size, s.created = f, st.Size(), s.now()
+	if s.size == 0 {
+		return nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, s.size-1); err != nil {
+		return errors.Internal(err, "audit: read log")
+	}
+	if last[0] != '\n' {
+		n, err := f.Write([]byte{'\n'})
+		s.size += int64(n)
+		if err != nil {
+			return errors.Internal(err, "audit: write log")
+		}
+	}
+	first, _, err := scanAuditFile(s.path)
+	if err != nil {
+		return err
+	}
+	if first != nil {
+		var ev api.AuditEvent
+		if json.Unmarshal(first.Event, &ev) == nil && !ev.EventTime.IsZero() {
+			s.created = ev.EventTime
+		}
+	}
+	return nil
+}
+
+func (s *FileSink) Write(_ context.Context, rec *AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Internal(err, "audit: encode record")
+	}
+	line = append(line, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return errors.E(errors.KindInternal, "audit: sink closed")
+	}
+	if s.shouldRotate(int64(len(line))) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return errors.Internal(err, "audit: write log")
+	}
+	return nil
+}
+
+func (s *FileSink) shouldRotate(n int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	return (s.maxSize > 0 && s.size+n > s.maxSize) ||
+		(s.interval > 0 && s.now().Sub(s.created) >= s.interval)
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return errors.Internal(err, "audit: close log")
+	}
+	s.f = nil
+	if err := os.Rename(s.path, s.path+"."+s.now().UTC().Format(auditBackupLayout)); err != nil {
+		return errors.Internal(err, "audit: rotate log")
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	return s.prune()
+}
+
+func (s *FileSink) prune() error {
+	if s.maxBackups <= 0 {
+		return nil
+	}
+	backups, err := auditBackups(s.path)
+	if err != nil {
+		return err
+	}
+	for len(backups) > s.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return errors.Internal(err, "audit: remove old log")
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Last 当前文件为空（刚轮转）时取最近的轮转文件
+func (s *FileSink) Last() (*AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, err := AuditFiles(s.path)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		_, last, err := scanAuditFile(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			return last, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+// scanAuditFile 返回首尾两条可解析的记录，跳过中断写入留下的半行
+func scanAuditFile(path string) (first, last *AuditRecord, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, errors.Internal(err, "audit: open log")
+	}
+	defer f.Close()
+	sc := newAuditScanner(f)
+	for sc.Scan() {
+		var rec AuditRecord
+		if json.Unmarshal(sc.Bytes(), &rec) != nil || rec.Hash == "" {
+			continue
+		}
+		if first == nil {
+			first = &rec
+		}
+		last = &rec
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, errors.Internal(err, "audit: read log")
+	}
+	return first, last, nil
+}
+
+func newAuditScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 4<<20)
+	return sc
+}
+
+func auditBackups(path string) ([]string, error) {
+	backups, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, errors.Internal(err, "audit: list rotated logs")
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// AuditFiles path 的轮转文件由旧到新，最后是 path 本身（存在时）
+func AuditFiles(path string) ([]string, error) {
+	files, err := auditBackups(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// AuditVerifier 顺序校验一个或多个文件中的记录，每条须哈希正确并紧接前一条。
+// 写入中断留下的半行（见 FileSink.open）只在紧随其后的记录接上链时跳过。
+// 链本身只能发现中间记录被删改：
+//   - 未设 Key 时能写文件的人可以整条重算，设 Key 后没有密钥无法伪造；
+//   - 默认第一条记录作为锚点（更早的轮转文件可能已按 MaxBackups 清理），
+//     删除开头的文件或记录要靠 From 发现；
+//   - 删除结尾的记录要靠 HeadSeq/HeadHash 发现，取自链外记录的链头
+//     （Auditor.Close 的日志或 agenticai_audit_chain_head_seq 指标）
+type AuditVerifier struct {
+	Key      []byte // 与写入时 security.audit.key_file 的内容一致
+	From     uint64 // 非零时第一条记录的 seq 必须是它
+	HeadSeq  uint64 // 非零时链必须延续到该 seq
+	HeadHash string // 非空时 HeadSeq 处记录的哈希必须是它
+
+	First, Last uint64 // 已校验记录的 seq 范围
+	Records     int
+	prev        string
+	torn        string // 待确认的半行位置
+}
+
+// Verify 返回的错误指出首条不可信记录所在的行
+func (v *AuditVerifier) Verify(name string, r io.Reader) error {
+	sc := newAuditScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		var rec AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil || rec.Hash == "" {
+			if v.torn != "" {
+				return errors.E(errors.KindValidation, fmt.Sprintf("audit: %s: malformed record", v.torn))
+			}
+			v.torn = fmt.Sprintf("%s:%d", name, line)
+			continue
+		}
+		if want := chainHash(v.Key, rec.Seq, rec.PrevHash, rec.Event); rec.Hash != want {
+			return errors.E(errors.KindValidation, fmt.Sprintf("audit: %s:%d: seq %d: hash mismatch", name, line, rec.Seq))
+		}
+		if v.Records > 0 {
+			if rec.Seq != v.Last+1 || rec.PrevHash != v.prev {
+				if v.torn != "" {
+					return errors.E(errors.KindValidation, fmt.Sprintf("audit: %s: malformed record", v.torn))
+				}
+				if rec.Seq != v.Last+1 {
+					return errors.E(errors.KindValidation, fmt.Sprintf("audit: %s:%d: expected seq %d, got %d", name, line, v.Last+1, rec.Seq))
+				}
+				return errors.E(errors.KindValidation, fmt.Sprintf("audit: %s:%d: seq %d: broken chain", name, line, rec.Seq))
+			}
+		} else {
+			if v.From != 0 && rec.Seq != v.From {
+				return errors.E(errors.KindValidation, fmt.Sprintf("audit: %s:%d: chain starts at seq %d, expected %d", name, line, rec.Seq, v.From))
+			}
+			v.First = rec.Seq
+		}
+		if rec.Seq == v.HeadSeq && v.HeadHash != "" && rec.Hash != v.HeadHash {
+			return errors.E(errors.KindValidation, fmt.Sprintf("audit: %s:%d: seq %d: hash differs from the recorded head", name, line, rec.Seq))
+		}
+		v.Last, v.prev, v.torn = rec.Seq, rec.Hash, ""
+		v.Records++
+	}
+	if err := sc.Err(); err != nil {
+		return errors.Internal(err, "audit: read "+name)
+	}
+	return nil
+}
+
+// Done 在最后一个文件之后调用：结尾的半行后面没有接上链的记录，无法确认只是写入中断；
+// 设了 From 或 HeadSeq 时还要求确有记录且延续到链头
+func (v *AuditVerifier) Done() error {
+	if v.torn != "" {
+		return errors.E(errors.KindValidation, fmt.Sprintf("audit: %s: malformed record", v.torn))
+	}
+	if v.Records == 0 && (v.From != 0 || v.HeadSeq != 0) {
+		return errors.E(errors.KindValidation, "audit: no records")
+	}
+	if v.Last < v.HeadSeq {
+		return errors.E(errors.KindValidation, fmt.Sprintf("audit: chain ends at seq %d, head is %d", v.Last, v.HeadSeq))
+	}
+	return nil
+}
+
+// VerifyFiles 依次校验 files，链可以跨文件延续
+func (v *AuditVerifier) VerifyFiles(files ...string) error {
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return errors.Internal(err, "audit: open "+name)
+		}
+		err = v.Verify(name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return v.Done()
+}
+
+// VerifyAuditFiles 不带密钥与链外锚点的 VerifyFiles
+func VerifyAuditFiles(files ...string) (*AuditVerifier, error) {
+	v := &AuditVerifier{}
+	return v, v.VerifyFiles(files...)
+}
+
+// ParseAuditHead 解析 SEQ 或 SEQ:HASH 形式的链头
+func ParseAuditHead(s string) (uint64, string, error) {
+	seqStr, hash, _ := strings.Cut(s, ":")
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq == 0 {
+		return 0, "", errors.E(errors.KindValidation, fmt.Sprintf("audit: invalid head %q, want SEQ[:HASH]", s))
+	}
+	return seq, hash, nil
+}
+
 //Personal.AI order the ending
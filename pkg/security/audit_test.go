@@ -0,0 +1,333 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/config"
+	api "github.com/turtacn/agenticai/pkg/types"
+)
+
+// logEvents 事件时间固定，记录长度不变，按大小轮转的边界可预期
+func logEvents(t *testing.T, a *Auditor, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.NoError(t, a.Log(context.Background(), &api.AuditEvent{
+			EventTime: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Actor: "alice", Resource: "tools/search", Action: "INVOKE", Outcome: "success",
+			Meta: map[string]interface{}{"i": i, "q": "<a&b>"},
+		}))
+	}
+}
+
+func TestAuditFileSinkChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	cfg := config.Audit{Sink: "file", Path: path, MaxSizeMB: 1}
+	sink, err := NewFileSink(cfg)
+	require.NoError(t, err)
+	sink.maxSize = 1 << 10 // 每个文件几条记录
+	a, err := NewAuditor(sink)
+	require.NoError(t, err)
+	logEvents(t, a, 20)
+	require.NoError(t, a.Close())
+
+	// 重启后从链尾继续
+	sink, err = NewFileSink(cfg)
+	require.NoError(t, err)
+	sink.maxSize = 1 << 10
+	a, err = NewAuditor(sink)
+	require.NoError(t, err)
+	logEvents(t, a, 5)
+	require.NoError(t, a.Close())
+
+	files, err := AuditFiles(path)
+	require.NoError(t, err)
+	require.Greater(t, len(files), 2, "size rotation")
+	assert.Equal(t, path, files[len(files)-1])
+	v, err := VerifyAuditFiles(files...)
+	require.NoError(t, err)
+	assert.Equal(t, 25, v.Records)
+	assert.Equal(t, uint64(1), v.First)
+	assert.Equal(t, uint64(25), v.Last)
+
+	// 最早的轮转文件被清理后，剩余部分仍可校验
+	v, err = VerifyAuditFiles(files[1:]...)
+	require.NoError(t, err)
+	assert.Greater(t, v.First, uint64(1))
+
+	// 改动事件内容
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, bytes.Replace(raw, []byte(`"alice"`), []byte(`"mallory"`), 1), 0o600))
+	_, err = VerifyAuditFiles(files...)
+	assert.ErrorContains(t, err, "hash mismatch")
+
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	// 删除中间一个轮转文件的第一条，与前一个文件接不上。
+	// 当前文件可能只有一条，删掉就成了截断结尾，见 TestAuditVerifierAnchors
+	mid := files[len(files)-2]
+	raw, err = os.ReadFile(mid)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(string(raw), "\n")
+	require.NoError(t, os.WriteFile(mid, []byte(strings.Join(lines[1:], "")), 0o600))
+	_, err = VerifyAuditFiles(files...)
+	assert.ErrorContains(t, err, "expected seq")
+}
+
+func TestAuditVerifierAnchors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(config.Audit{Path: path})
+	require.NoError(t, err)
+	key := []byte("k1")
+	a, err := NewAuditor(sink, WithAuditKey(key))
+	require.NoError(t, err)
+	logEvents(t, a, 5)
+	seq, hash := a.Head()
+	require.NoError(t, a.Close())
+	assert.Equal(t, uint64(5), seq)
+
+	verify := func(v *AuditVerifier) error {
+		t.Helper()
+		return v.VerifyFiles(path)
+	}
+	require.NoError(t, verify(&AuditVerifier{Key: key, From: 1, HeadSeq: seq, HeadHash: hash}))
+
+	// 带密钥的链：不带或用错密钥都校验不过
+	assert.ErrorContains(t, verify(&AuditVerifier{}), "hash mismatch")
+	assert.ErrorContains(t, verify(&AuditVerifier{Key: []byte("k2")}), "hash mismatch")
+
+	// 不知道密钥时改写事件后整条重算，仍被发现
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	var forged bytes.Buffer
+	enc := json.NewEncoder(&forged)
+	prev := ""
+	for _, l := range lines {
+		var rec AuditRecord
+		require.NoError(t, json.Unmarshal([]byte(l), &rec))
+		rec.Event = bytes.Replace(rec.Event, []byte(`"alice"`), []byte(`"mallory"`), 1)
+		rec.PrevHash = prev
+		rec.Hash = chainHash(nil, rec.Seq, rec.PrevHash, rec.Event)
+		prev = rec.Hash
+		require.NoError(t, enc.Encode(&rec))
+	}
+	assert.ErrorContains(t, (&AuditVerifier{Key: key}).Verify("forged", &forged), "hash mismatch")
+
+	// 删除开头：只有给出 From 才能发现
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines[2:], "\n")+"\n"), 0o600))
+	require.NoError(t, verify(&AuditVerifier{Key: key}))
+	assert.ErrorContains(t, verify(&AuditVerifier{Key: key, From: 1}), "chain starts at seq 3, expected 1")
+
+	// 截断结尾：只有给出链外记录的链头才能发现
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines[:3], "\n")+"\n"), 0o600))
+	require.NoError(t, verify(&AuditVerifier{Key: key}))
+	assert.ErrorContains(t, verify(&AuditVerifier{Key: key, HeadSeq: seq}), "chain ends at seq 3, head is 5")
+
+	// 链头处的记录被替换
+	assert.ErrorContains(t, verify(&AuditVerifier{Key: key, HeadSeq: 3, HeadHash: hash}), "differs from the recorded head")
+
+	head, h, err := ParseAuditHead("5:" + hash)
+	require.NoError(t, err)
+	assert.Equal(t, seq, head)
+	assert.Equal(t, hash, h)
+	_, _, err = ParseAuditHead("x")
+	assert.Error(t, err)
+}
+
+func TestAuditFileSinkTimeRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(config.Audit{Path: path, RotateInterval: time.Hour, MaxBackups: 2})
+	require.NoError(t, err)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time { return now }
+	sink.created = now
+	a, err := NewAuditor(sink)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		logEvents(t, a, 2)
+		now = now.Add(time.Hour)
+	}
+	require.NoError(t, a.Close())
+
+	backups, err := auditBackups(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path + ".20260101T020000.000000000Z", path + ".20260101T030000.000000000Z"}, backups)
+	v, err := VerifyAuditFiles(append(backups, path)...)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), v.First, "pruned files are gone")
+	assert.Equal(t, uint64(8), v.Last)
+}
+
+func TestAuditFileSinkPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(config.Audit{Path: path})
+	require.NoError(t, err)
+	a, err := NewAuditor(sink)
+	require.NoError(t, err)
+	logEvents(t, a, 2)
+	require.NoError(t, a.Close())
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":3,"ev`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	sink, err = NewFileSink(config.Audit{Path: path})
+	require.NoError(t, err)
+	a, err = NewAuditor(sink)
+	require.NoError(t, err)
+	logEvents(t, a, 1)
+	require.NoError(t, a.Close())
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	require.Len(t, lines, 4)
+	var rec AuditRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[3]), &rec))
+	assert.Equal(t, uint64(3), rec.Seq, "chain resumes after the last complete record")
+	v, err := VerifyAuditFiles(path)
+	require.NoError(t, err, "the torn line is followed by a record that links to seq 2")
+	assert.Equal(t, 3, v.Records)
+
+	// 半行后面的记录接不上链：不是写入中断，而是有记录被替换
+	tampered := strings.Join([]string{lines[0], lines[2], lines[3]}, "\n") + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(tampered), 0o600))
+	_, err = VerifyAuditFiles(path)
+	assert.ErrorContains(t, err, "audit.jsonl:2: malformed record")
+
+	// 结尾的半行没有后续记录确认
+	tampered = strings.Join(lines[:3], "\n") + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(tampered), 0o600))
+	_, err = VerifyAuditFiles(path)
+	assert.ErrorContains(t, err, "audit.jsonl:3: malformed record")
+}
+
+func TestAuditWebhookSink(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		got  []AuditRecord
+		fail atomic.Bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var rec AuditRecord
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&rec))
+		mu.Lock()
+		got = append(got, rec)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	sink, err := NewAuditSink(config.Audit{Sink: "webhook", WebhookURL: srv.URL, WebhookTimeout: time.Second})
+	require.NoError(t, err)
+	a, err := NewAuditor(sink)
+	require.NoError(t, err)
+	logEvents(t, a, 2)
+	fail.Store(true)
+	assert.Error(t, a.Log(context.Background(), &api.AuditEvent{Actor: "bob"}))
+	fail.Store(false)
+	logEvents(t, a, 1)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, got, 3)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range got {
+		require.NoError(t, enc.Encode(&got[i]))
+	}
+	v := &AuditVerifier{}
+	require.NoError(t, v.Verify("webhook", &buf), "a failed write does not break the chain")
+	assert.Equal(t, uint64(3), v.Last)
+
+	sink, err = NewAuditSink(config.Audit{Sink: "none"})
+	assert.NoError(t, err)
+	assert.Nil(t, sink)
+}
+
+func TestAuditorAsync(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		got     []AuditRecord
+		release = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		var rec AuditRecord
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&rec))
+		mu.Lock()
+		got = append(got, rec)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	a, err := NewAuditor(NewWebhookSink(srv.URL, 5*time.Second))
+	require.NoError(t, err)
+	a.StartAsync(16)
+
+	// sink 阻塞时入队立即返回；请求 ctx 取消后事件仍会写出
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 5; i++ {
+		require.NoError(t, a.Enqueue(ctx, &api.AuditEvent{Actor: "alice", Meta: map[string]interface{}{"i": i}}))
+	}
+	cancel()
+	close(release)
+	require.NoError(t, a.Close())
+	assert.Error(t, a.Enqueue(context.Background(), &api.AuditEvent{Actor: "late"}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, got, 5)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range got {
+		var ev api.AuditEvent
+		require.NoError(t, json.Unmarshal(got[i].Event, &ev))
+		assert.Equal(t, float64(i), ev.Meta["i"], "written in enqueue order")
+		require.NoError(t, enc.Encode(&got[i]))
+	}
+	v := &AuditVerifier{}
+	require.NoError(t, v.Verify("webhook", &buf))
+}
+
+func TestInitAuditFileFallback(t *testing.T) {
+	// 父路径是普通文件，目录建不出来，效果同只读根文件系统
+	parent := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(parent, nil, 0o600))
+	var out bytes.Buffer
+	closeAudit, err := InitAuditTo(config.Audit{Sink: "file", Path: filepath.Join(parent, "audit.jsonl")}, &out)
+	require.NoError(t, err, "an unwritable audit path does not block startup")
+	t.Cleanup(func() { SetAuditor(nil) })
+	AuditLog(context.Background(), &api.AuditEvent{Actor: "alice", Resource: "tools/search", Outcome: "success"})
+	require.NoError(t, closeAudit())
+
+	v := &AuditVerifier{}
+	require.NoError(t, v.Verify("stdout", &out))
+	assert.Equal(t, 1, v.Records)
+
+	_, err = InitAuditTo(config.Audit{Sink: "kafka"}, &out)
+	assert.Error(t, err)
+
+	// 配了密钥文件但读不到：显式配置的完整性要求不静默降级
+	_, err = InitAuditTo(config.Audit{Sink: "stdout", KeyFile: filepath.Join(parent, "key")}, &out)
+	assert.Error(t, err)
+}
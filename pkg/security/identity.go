@@ -3,6 +3,9 @@ package security
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
@@ -57,6 +60,17 @@ func (i *identityImpl) Source() credentials.TransportCredentials {
 	return credentials.NewTLS(tlsConfig)
 }
 
+// WithCaller 把已认证的调用方身份写入 ctx
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, ctxKeyCaller, caller)
+}
+
+// CallerFromContext 读取拦截器或网关写入的调用方身份，未认证时为空
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(ctxKeyCaller).(string)
+	return caller
+}
+
 // --------------------------------------------------------------------
 
 // SPIFFEInterceptor gRPC 一元拦截器，附加 caller id
@@ -66,7 +80,7 @@ func SPIFFEInterceptor() grpc.UnaryServerInterceptor {
 		if err != nil {
 			return nil, err
 		}
-		newCtx := context.WithValue(ctx, ctxKeyCaller, id.String())
+		newCtx := WithCaller(ctx, id.String())
 		return handler(newCtx, req)
 	}
 }
@@ -77,7 +91,7 @@ func SPIFFEStreamInterceptor() grpc.StreamServerInterceptor {
 		if err != nil {
 			return err
 		}
-		newCtx := context.WithValue(ss.Context(), ctxKeyCaller, id.String())
+		newCtx := WithCaller(ss.Context(), id.String())
 		return handler(srv, &wrappedServerStream{ss, newCtx})
 	}
 }
@@ -89,16 +103,24 @@ func authorize(ctx context.Context) (spiffeid.ID, error) {
 		return spiffeid.ID{}, status.Error(codes.Unauthenticated, "no peer info")
 	}
 	tlsInfo, ok := peer.AuthInfo.(credentials.TLSInfo)
-	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+	if !ok {
 		return spiffeid.ID{}, status.Error(codes.Unauthenticated, "invalid tls auth")
 	}
-	id, err := x509svid.IDFromCert(tlsInfo.State.PeerCertificates[0])
+	id, err := PeerID(&tlsInfo.State)
 	if err != nil {
 		return spiffeid.ID{}, status.Error(codes.Unauthenticated, err.Error())
 	}
 	return id, nil
 }
 
+// PeerID 从 mTLS 连接的对端证书中取 SPIFFE ID，HTTP 服务可用 r.TLS 调用
+func PeerID(state *tls.ConnectionState) (spiffeid.ID, error) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return spiffeid.ID{}, fmt.Errorf("no peer certificate")
+	}
+	return x509svid.IDFromCert(state.PeerCertificates[0])
+}
+
 // wrappedServerStream is a thin wrapper around grpc.ServerStream that allows modifying the context.
 type wrappedServerStream struct {
 	grpc.ServerStream
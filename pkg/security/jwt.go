@@ -0,0 +1,308 @@
+// pkg/security/jwt.go
+package security
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"go.uber.org/zap"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/types"
+)
+
+const (
+	defaultJWKSRefresh = 5 * time.Minute
+	// minJWKSRefresh 遇到未知 kid 时按需刷新的最小间隔，防止伪造 kid 打爆 JWKS 端点
+	minJWKSRefresh = 30 * time.Second
+	maxJWKSBytes   = 1 << 20
+	defaultRoles   = "roles"
+)
+
+// registeredClaims 不进入 AuthResult.ExtraClaims
+var registeredClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// JWTConfig JWT 校验参数；HMACSecret 与 JWKS 至少配置一项，HMACSecret 不能是空切片
+type JWTConfig struct {
+	Issuer     string   // 非空时校验 iss
+	Audience   []string // 非空时 aud 须与其有交集
+	HMACSecret []byte   // HS256
+	JWKSFile   string   // 本地 JWKS（RS256/ES256），定期重读以支持轮换
+	JWKSURL    string   // 远端 JWKS，与 JWKSFile 同时配置时合并
+	Refresh    time.Duration
+	Leeway     time.Duration // exp/nbf/iat 允许的时钟偏差，默认 1m
+	RolesClaim string        // 角色所在 claim，默认 roles；可为数组或空格分隔的字符串
+	HTTPClient *http.Client
+}
+
+// JWTVerifier 校验 bearer token 的签名与声明
+type JWTVerifier struct {
+	cfg  JWTConfig
+	algs []jose.SignatureAlgorithm
+	now  func() time.Time
+
+	mu   sync.RWMutex
+	keys []jose.JSONWebKey
+
+	fetchMu   sync.Mutex // 串行化 JWKS 拉取
+	attempted time.Time  // 最近一次拉取的时间，无论成败；受 fetchMu 保护
+}
+
+// NewJWTVerifier 配置了 JWKS 时先同步加载一次，之后在 ctx 结束前按 Refresh 周期刷新
+func NewJWTVerifier(ctx context.Context, cfg JWTConfig) (*JWTVerifier, error) {
+	jwks := cfg.JWKSFile != "" || cfg.JWKSURL != ""
+	// 配置了密钥却为空多半是密钥文件为空，不能静默接受以空密钥签名的 token
+	if cfg.HMACSecret != nil && len(cfg.HMACSecret) == 0 {
+		return nil, errors.E(errors.KindValidation, "jwt: HMAC secret is empty")
+	}
+	if len(cfg.HMACSecret) == 0 && !jwks {
+		return nil, errors.E(errors.KindValidation, "jwt: HMAC secret or JWKS required")
+	}
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = defaultJWKSRefresh
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = jwt.DefaultLeeway
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = defaultRoles
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	v := &JWTVerifier{cfg: cfg, now: time.Now}
+	if len(cfg.HMACSecret) > 0 {
+		v.algs = append(v.algs, jose.HS256)
+	}
+	if !jwks {
+		return v, nil
+	}
+	v.algs = append(v.algs, jose.RS256, jose.ES256)
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+	go v.refreshLoop(ctx)
+	return v, nil
+}
+
+// Verify 校验签名、exp/nbf/iat、iss 与 aud；token 必须带 sub 与 exp
+func (v *JWTVerifier) Verify(ctx context.Context, raw string) (*types.AuthResult, error) {
+	tok, err := jwt.ParseSigned(raw, v.algs)
+	if err != nil {
+		return nil, errors.Permission(err, "jwt: malformed token")
+	}
+	var (
+		claims jwt.Claims
+		extra  map[string]interface{}
+	)
+	if err := v.verifySignature(ctx, tok, &claims, &extra); err != nil {
+		return nil, err
+	}
+	if claims.Expiry == nil {
+		return nil, errors.E(errors.KindPermission, "jwt: missing exp")
+	}
+	if claims.Subject == "" {
+		return nil, errors.E(errors.KindPermission, "jwt: missing sub")
+	}
+	expected := jwt.Expected{Issuer: v.cfg.Issuer, AnyAudience: v.cfg.Audience, Time: v.now()}
+	if err := claims.ValidateWithLeeway(expected, v.cfg.Leeway); err != nil {
+		return nil, errors.Permission(err, "jwt: invalid claims")
+	}
+	for _, k := range registeredClaims {
+		delete(extra, k)
+	}
+	return &types.AuthResult{
+		Subject:      claims.Subject,
+		ExtraClaims:  extra,
+		AllowedRoles: rolesOf(extra[v.cfg.RolesClaim]),
+	}, nil
+}
+
+// verifySignature HS256 只用共享密钥，RS256/ES256 只用 JWKS 公钥，避免算法混淆；
+// kid 不在当前 JWKS 中时刷新一次再试，以接住签发方的密钥轮换
+func (v *JWTVerifier) verifySignature(ctx context.Context, tok *jwt.JSONWebToken, dest ...interface{}) error {
+	if len(tok.Headers) != 1 {
+		return errors.E(errors.KindPermission, "jwt: expected exactly one signature")
+	}
+	h := tok.Headers[0]
+	if h.Algorithm == string(jose.HS256) {
+		if err := tok.Claims(v.cfg.HMACSecret, dest...); err != nil {
+			return errors.Permission(err, "jwt: invalid signature")
+		}
+		return nil
+	}
+	keys := v.candidates(h)
+	if len(keys) == 0 {
+		if err := v.refreshStale(ctx); err != nil {
+			logger.Warn(ctx, "jwt: JWKS refresh failed", zap.Error(err))
+		}
+		keys = v.candidates(h)
+	}
+	if len(keys) == 0 {
+		return errors.E(errors.KindPermission, fmt.Sprintf("jwt: no key for kid %q alg %s", h.KeyID, h.Algorithm))
+	}
+	var err error
+	for _, k := range keys {
+		if err = tok.Claims(k, dest...); err == nil {
+			return nil
+		}
+	}
+	return errors.Permission(err, "jwt: invalid signature")
+}
+
+// candidates 与 token 头中 kid、alg 匹配的签名公钥
+func (v *JWTVerifier) candidates(h jose.Header) []interface{} {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	var out []interface{}
+	for _, k := range v.keys {
+		if (h.KeyID != "" && k.KeyID != h.KeyID) || (k.Use != "" && k.Use != "sig") ||
+			(k.Algorithm != "" && k.Algorithm != h.Algorithm) {
+			continue
+		}
+		pub := k.Public().Key
+		switch pub.(type) {
+		case *rsa.PublicKey:
+			if h.Algorithm != string(jose.RS256) {
+				continue
+			}
+		case *ecdsa.PublicKey:
+			if h.Algorithm != string(jose.ES256) {
+				continue
+			}
+		default:
+			continue
+		}
+		out = append(out, pub)
+	}
+	return out
+}
+
+// refreshStale 按需刷新：距上次拉取不足 minJWKSRefresh 时跳过。上次失败也计入，
+// 否则 JWKS 端点故障时每个伪造 kid 的请求都会打到端点上
+func (v *JWTVerifier) refreshStale(ctx context.Context) error {
+	if v.cfg.JWKSFile == "" && v.cfg.JWKSURL == "" {
+		return nil
+	}
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	if v.now().Sub(v.attempted) < minJWKSRefresh {
+		return nil
+	}
+	return v.load(ctx)
+}
+
+func (v *JWTVerifier) refreshLoop(ctx context.Context) {
+	t := time.NewTicker(v.cfg.Refresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := v.refresh(ctx); err != nil {
+				logger.Warn(ctx, "jwt: JWKS refresh failed, keeping previous keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+// refresh 重新加载 JWKS；失败时保留原有密钥
+func (v *JWTVerifier) refresh(ctx context.Context) error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	return v.load(ctx)
+}
+
+// load 调用方持有 fetchMu
+func (v *JWTVerifier) load(ctx context.Context) error {
+	v.attempted = v.now()
+	var keys []jose.JSONWebKey
+	if v.cfg.JWKSFile != "" {
+		raw, err := os.ReadFile(v.cfg.JWKSFile)
+		if err != nil {
+			return errors.Unavailable(err, "jwt: read JWKS file")
+		}
+		set, err := parseJWKS(raw)
+		if err != nil {
+			return errors.Validation(err, "jwt: parse JWKS file "+v.cfg.JWKSFile)
+		}
+		keys = append(keys, set.Keys...)
+	}
+	if v.cfg.JWKSURL != "" {
+		set, err := v.fetchJWKS(ctx)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, set.Keys...)
+	}
+	if len(keys) == 0 {
+		return errors.E(errors.KindValidation, "jwt: JWKS contains no keys")
+	}
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWTVerifier) fetchJWKS(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, errors.Validation(err, "jwt: JWKS url")
+	}
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Unavailable(err, "jwt: fetch JWKS")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.E(errors.KindUnavailable, fmt.Sprintf("jwt: fetch JWKS: status %d", resp.StatusCode))
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	if err != nil {
+		return nil, errors.Unavailable(err, "jwt: read JWKS")
+	}
+	set, err := parseJWKS(raw)
+	if err != nil {
+		return nil, errors.Validation(err, "jwt: parse JWKS from "+v.cfg.JWKSURL)
+	}
+	return set, nil
+}
+
+func parseJWKS(raw []byte) (*jose.JSONWebKeySet, error) {
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func rolesOf(v interface{}) []string {
+	switch r := v.(type) {
+	case string:
+		return strings.Fields(r)
+	case []interface{}:
+		out := make([]string, 0, len(r))
+		for _, x := range r {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,199 @@
+package security
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/utils"
+)
+
+func sign(t *testing.T, alg jose.SignatureAlgorithm, key interface{}, kid string, claims ...interface{}) string {
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if kid != "" {
+		opts = opts.WithHeader(jose.HeaderKey("kid"), kid)
+	}
+	s, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+	require.NoError(t, err)
+	b := jwt.Signed(s)
+	for _, c := range claims {
+		b = b.Claims(c)
+	}
+	raw, err := b.Serialize()
+	require.NoError(t, err)
+	return raw
+}
+
+func stdClaims(now time.Time) jwt.Claims {
+	return jwt.Claims{
+		Subject: "alice", Issuer: "https://idp.example", Audience: jwt.Audience{"tool-gateway"},
+		Expiry: jwt.NewNumericDate(now.Add(time.Hour)), IssuedAt: jwt.NewNumericDate(now),
+	}
+}
+
+func TestJWTVerifierHS256(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	v, err := NewJWTVerifier(context.Background(), JWTConfig{
+		Issuer: "https://idp.example", Audience: []string{"tool-gateway"}, HMACSecret: secret,
+	})
+	require.NoError(t, err)
+	now := time.Now()
+
+	// utils.SignHS256 签出的 token 与标准实现互通
+	raw, err := utils.SignHS256(utils.Claims{
+		"sub": "alice", "iss": "https://idp.example", "aud": "tool-gateway",
+		"exp": now.Add(time.Hour).Unix(), "roles": "reader writer", "team": "infra",
+	}, secret)
+	require.NoError(t, err)
+	res, err := v.Verify(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", res.Subject)
+	assert.Equal(t, []string{"reader", "writer"}, res.AllowedRoles)
+	assert.Equal(t, "infra", res.ExtraClaims["team"])
+	assert.NotContains(t, res.ExtraClaims, "exp")
+
+	cases := map[string]func(c *jwt.Claims){
+		"expired":      func(c *jwt.Claims) { c.Expiry = jwt.NewNumericDate(now.Add(-2 * time.Minute)) },
+		"not yet":      func(c *jwt.Claims) { c.NotBefore = jwt.NewNumericDate(now.Add(2 * time.Minute)) },
+		"wrong issuer": func(c *jwt.Claims) { c.Issuer = "https://evil.example" },
+		"wrong aud":    func(c *jwt.Claims) { c.Audience = jwt.Audience{"other"} },
+		"missing exp":  func(c *jwt.Claims) { c.Expiry = nil },
+		"missing sub":  func(c *jwt.Claims) { c.Subject = "" },
+	}
+	for name, mutate := range cases {
+		c := stdClaims(now)
+		mutate(&c)
+		_, err := v.Verify(context.Background(), sign(t, jose.HS256, secret, "", c))
+		assert.Equal(t, errors.KindPermission, errors.KindOf(err), name)
+	}
+	// 时钟偏差在 leeway 内仍然有效
+	c := stdClaims(now)
+	c.Expiry = jwt.NewNumericDate(now.Add(-30 * time.Second))
+	_, err = v.Verify(context.Background(), sign(t, jose.HS256, secret, "", c))
+	assert.NoError(t, err)
+
+	_, err = v.Verify(context.Background(), sign(t, jose.HS256, []byte("another-secret-another-secret-xx"), "", stdClaims(now)))
+	assert.Error(t, err)
+	// 未配置 JWKS 时不接受非对称算法
+	rk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), sign(t, jose.RS256, rk, "k1", stdClaims(now)))
+	assert.Error(t, err)
+}
+
+// jwksServer 可替换密钥集的 JWKS 端点
+type jwksServer struct {
+	mu    sync.Mutex
+	set   jose.JSONWebKeySet
+	calls int
+	fail  bool
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(s.set)
+}
+
+func (s *jwksServer) setKeys(keys ...jose.JSONWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set = jose.JSONWebKeySet{Keys: keys}
+}
+
+func TestJWTVerifierJWKSRotation(t *testing.T) {
+	k1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	js := &jwksServer{}
+	js.setKeys(jose.JSONWebKey{Key: &k1.PublicKey, KeyID: "k1", Algorithm: string(jose.RS256), Use: "sig"})
+	srv := httptest.NewServer(js)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v, err := NewJWTVerifier(ctx, JWTConfig{JWKSURL: srv.URL, Refresh: time.Hour})
+	require.NoError(t, err)
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	res, err := v.Verify(ctx, sign(t, jose.RS256, k1, "k1", stdClaims(now)))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", res.Subject)
+
+	// 签发方轮换到 k2：距上次拉取不足最小间隔时不刷新
+	js.setKeys(jose.JSONWebKey{Key: &k2.PublicKey, KeyID: "k2", Algorithm: string(jose.ES256)})
+	rotated := sign(t, jose.ES256, k2, "k2", stdClaims(now))
+	_, err = v.Verify(ctx, rotated)
+	assert.Error(t, err)
+	assert.Equal(t, 1, js.calls)
+
+	now = now.Add(minJWKSRefresh)
+	_, err = v.Verify(ctx, rotated)
+	require.NoError(t, err)
+	assert.Equal(t, 2, js.calls)
+	_, err = v.Verify(ctx, sign(t, jose.RS256, k1, "k1", stdClaims(now)))
+	assert.Error(t, err, "retired key must be rejected")
+	// HS256 不能拿 JWKS 中的公钥当密钥
+	_, err = v.Verify(ctx, sign(t, jose.HS256, []byte("0123456789abcdef0123456789abcdef"), "k2", stdClaims(now)))
+	assert.Error(t, err)
+
+	// 端点故障时失败的拉取同样限频，伪造的 kid 不能反复打到端点上
+	js.mu.Lock()
+	js.fail = true
+	js.mu.Unlock()
+	now = now.Add(minJWKSRefresh)
+	for _, kid := range []string{"forged-1", "forged-2", "forged-3"} {
+		_, err = v.Verify(ctx, sign(t, jose.ES256, k2, kid, stdClaims(now)))
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 3, js.calls)
+	_, err = v.Verify(ctx, rotated)
+	require.NoError(t, err, "keys from the last successful fetch are kept")
+}
+
+func TestJWTVerifierJWKSFile(t *testing.T) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	raw, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &k.PublicKey, KeyID: "file"}}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	v, err := NewJWTVerifier(context.Background(), JWTConfig{JWKSFile: path, RolesClaim: "groups"})
+	require.NoError(t, err)
+	c := stdClaims(time.Now())
+	res, err := v.Verify(context.Background(), sign(t, jose.ES256, k, "", c, map[string]interface{}{"groups": []string{"ops"}}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ops"}, res.AllowedRoles)
+
+	_, err = NewJWTVerifier(context.Background(), JWTConfig{JWKSFile: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+	_, err = NewJWTVerifier(context.Background(), JWTConfig{JWKSFile: path, HMACSecret: []byte{}})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err), "an empty HMAC secret is rejected even with JWKS")
+	_, err = NewJWTVerifier(context.Background(), JWTConfig{})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+}
@@ -0,0 +1,106 @@
+// pkg/security/peercred.go
+package security
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// PeerCredAuthType unix socket 对端凭据的 AuthType
+const PeerCredAuthType = "peercred"
+
+// PeerCredAuthInfo 经 SO_PEERCRED 取得的对端进程身份
+type PeerCredAuthInfo struct {
+	credentials.CommonAuthInfo
+	UID, GID uint32
+	PID      int32
+}
+
+func (PeerCredAuthInfo) AuthType() string { return PeerCredAuthType }
+
+// Caller 以 uid 作为调用方身份，pid 可复用，不参与鉴权
+func (a PeerCredAuthInfo) Caller() string { return fmt.Sprintf("uid:%d", a.UID) }
+
+// peerCredentials 本机 unix socket 的传输凭据：不加密，握手时读取对端 uid/pid
+type peerCredentials struct {
+	allowed []uint32
+}
+
+// PeerCredentials 只接受 allowedUIDs 中的对端，为空时接受任意 uid；仅 linux 可用
+func PeerCredentials(allowedUIDs ...uint32) credentials.TransportCredentials {
+	return &peerCredentials{allowed: allowedUIDs}
+}
+
+func (c *peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, fmt.Errorf("peercred: %T is not a unix socket", conn)
+	}
+	info, err := peerCred(uc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("peercred: %w", err)
+	}
+	if len(c.allowed) > 0 && !slices.Contains(c.allowed, info.UID) {
+		return nil, nil, fmt.Errorf("peercred: uid %d not allowed", info.UID)
+	}
+	info.SecurityLevel = credentials.NoSecurity
+	return conn, info, nil
+}
+
+// ClientHandshake 客户端无需出示凭据，内核已替其作证
+func (c *peerCredentials) ClientHandshake(_ context.Context, _ string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return conn, PeerCredAuthInfo{CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity}}, nil
+}
+
+func (c *peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: PeerCredAuthType}
+}
+
+func (c *peerCredentials) Clone() credentials.TransportCredentials {
+	return &peerCredentials{allowed: slices.Clone(c.allowed)}
+}
+
+func (c *peerCredentials) OverrideServerName(string) error { return nil }
+
+// PeerCredInterceptor 配合 PeerCredentials 使用，附加 caller id（uid:<n>）
+func PeerCredInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		caller, err := peerCredCaller(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(WithCaller(ctx, caller), req)
+	}
+}
+
+func PeerCredStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		caller, err := peerCredCaller(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedServerStream{ss, WithCaller(ss.Context(), caller)})
+	}
+}
+
+func peerCredCaller(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no peer info")
+	}
+	info, ok := p.AuthInfo.(PeerCredAuthInfo)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no peer credentials")
+	}
+	return info.Caller(), nil
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,31 @@
+// pkg/security/peercred_linux.go
+//go:build linux
+
+package security
+
+import (
+	"net"
+	"syscall"
+)
+
+func peerCred(conn *net.UnixConn) (PeerCredAuthInfo, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return PeerCredAuthInfo{}, err
+	}
+	var (
+		cred    *syscall.Ucred
+		credErr error
+	)
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return PeerCredAuthInfo{}, err
+	}
+	if credErr != nil {
+		return PeerCredAuthInfo{}, credErr
+	}
+	return PeerCredAuthInfo{UID: cred.Uid, GID: cred.Gid, PID: cred.Pid}, nil
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,15 @@
+// pkg/security/peercred_other.go
+//go:build !linux
+
+package security
+
+import (
+	"errors"
+	"net"
+)
+
+func peerCred(*net.UnixConn) (PeerCredAuthInfo, error) {
+	return PeerCredAuthInfo{}, errors.New("SO_PEERCRED is only supported on linux")
+}
+
+//Personal.AI order the ending
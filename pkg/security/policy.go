@@ -0,0 +1,191 @@
+// pkg/security/policy.go
+package security
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// PolicyTarget 待匹配 SecurityPolicy 的工作负载
+type PolicyTarget struct {
+	Kind      string // "Agent" | "Task"
+	Namespace string
+	Labels    map[string]string
+}
+
+// ValidatePolicy 检查 Match、系统调用名与 RBAC 部分能否编译
+func ValidatePolicy(pol *apis.SecurityPolicy) error {
+	if _, err := compileMatch(pol); err != nil {
+		return err
+	}
+	if err := ValidateSyscalls(pol.Spec.Constraints.SysCallRestriction); err != nil {
+		return errors.Validation(err, "constraints.sysCallRestriction")
+	}
+	_, err := compilePolicy(pol)
+	return err
+}
+
+// MatchPolicy Kind、Namespaces、Labels 与 Expressions 全部满足时匹配。
+// 表达式非法时只要 Kind 与命名空间命中就返回错误，调用方应按拒绝处理
+func MatchPolicy(pol *apis.SecurityPolicy, t PolicyTarget) (bool, error) {
+	m := pol.Spec.Match
+	if m.Kind != "" && m.Kind != t.Kind {
+		return false, nil
+	}
+	namespaces := m.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{pol.Namespace}
+	}
+	nsRe, err := CompileGlobs(namespaces)
+	if err != nil {
+		return true, errors.Validation(err, "match.namespaces")
+	}
+	if !nsRe.MatchString(t.Namespace) {
+		return false, nil
+	}
+	sel, err := compileMatch(pol)
+	if err != nil {
+		return true, err
+	}
+	return sel.Matches(labels.Set(t.Labels)), nil
+}
+
+// compileMatch 把 Labels 与 Expressions 合成一个标签选择器
+func compileMatch(pol *apis.SecurityPolicy) (labels.Selector, error) {
+	sel := labels.SelectorFromSet(pol.Spec.Match.Labels)
+	for i, expr := range pol.Spec.Match.Expressions {
+		s, err := labels.Parse(expr)
+		if err != nil {
+			return nil, errors.Validation(err, fmt.Sprintf("match.expressions[%d]", i))
+		}
+		reqs, _ := s.Requirements()
+		sel = sel.Add(reqs...)
+	}
+	return sel, nil
+}
+
+// MergeConstraints 多份策略同时作用时取最严格的组合
+func MergeConstraints(pols []*apis.SecurityPolicy) apis.PolicyConstraints {
+	if len(pols) == 0 {
+		return apis.PolicyConstraints{AllowPrivileged: true}
+	}
+	out := apis.PolicyConstraints{AllowPrivileged: true}
+	seen := make(map[string]bool)
+	for _, p := range pols {
+		c := p.Spec.Constraints
+		out.ReadOnlyRootFS = out.ReadOnlyRootFS || c.ReadOnlyRootFS
+		out.AllowPrivileged = out.AllowPrivileged && c.AllowPrivileged
+		for _, sc := range c.SysCallRestriction {
+			if !seen[sc] {
+				seen[sc] = true
+				out.SysCallRestriction = append(out.SysCallRestriction, sc)
+			}
+		}
+	}
+	sort.Strings(out.SysCallRestriction)
+	return out
+}
+
+// EnforcePodConstraints 按约束改写 Pod 模板中未显式设置的字段；
+// 与约束冲突的显式设置不做覆盖，作为违规返回。
+// 有 SysCallRestriction 时 Pod 使用按禁止集合生成的 Localhost profile，见 SeccompProfileName
+func EnforcePodConstraints(tpl *corev1.PodTemplateSpec, c apis.PolicyConstraints) []string {
+	var violations []string
+	spec := &tpl.Spec
+	var seccomp *corev1.SeccompProfile
+	if len(c.SysCallRestriction) > 0 {
+		path := LocalhostProfile(SeccompProfileName(c.SysCallRestriction))
+		seccomp = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: &path}
+	}
+	containers := make([]*corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	for i := range spec.InitContainers {
+		containers = append(containers, &spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		containers = append(containers, &spec.Containers[i])
+	}
+	for _, ctr := range containers {
+		if ctr.SecurityContext == nil {
+			ctr.SecurityContext = &corev1.SecurityContext{}
+		}
+		sc := ctr.SecurityContext
+		if !c.AllowPrivileged {
+			switch {
+			case sc.Privileged != nil && *sc.Privileged:
+				violations = append(violations, fmt.Sprintf("container %s: privileged is not allowed", ctr.Name))
+			case sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation:
+				violations = append(violations, fmt.Sprintf("container %s: privilege escalation is not allowed", ctr.Name))
+			default:
+				sc.Privileged, sc.AllowPrivilegeEscalation = boolPtr(false), boolPtr(false)
+			}
+		}
+		if c.ReadOnlyRootFS {
+			if sc.ReadOnlyRootFilesystem != nil && !*sc.ReadOnlyRootFilesystem {
+				violations = append(violations, fmt.Sprintf("container %s: root filesystem must be read-only", ctr.Name))
+			} else {
+				sc.ReadOnlyRootFilesystem = boolPtr(true)
+				ensureTmpMount(spec, ctr)
+			}
+		}
+		// 容器级 profile 覆盖 Pod 级，只能与生成的一致
+		if seccomp != nil && sc.SeccompProfile != nil && !equality.Semantic.DeepEqual(sc.SeccompProfile, seccomp) {
+			violations = append(violations, fmt.Sprintf("container %s: seccomp profile must be %s", ctr.Name, *seccomp.LocalhostProfile))
+		}
+	}
+	if seccomp != nil {
+		if spec.SecurityContext == nil {
+			spec.SecurityContext = &corev1.PodSecurityContext{}
+		}
+		switch sp := spec.SecurityContext.SeccompProfile; {
+		case sp == nil:
+			spec.SecurityContext.SeccompProfile = seccomp
+		case !equality.Semantic.DeepEqual(sp, seccomp):
+			violations = append(violations, "pod: seccomp profile must be "+*seccomp.LocalhostProfile)
+		}
+	}
+	return violations
+}
+
+// ensureTmpMount 只读根文件系统下为 /tmp 挂一个 emptyDir
+func ensureTmpMount(spec *corev1.PodSpec, ctr *corev1.Container) {
+	for _, m := range ctr.VolumeMounts {
+		if m.MountPath == "/tmp" {
+			return
+		}
+	}
+	const name = "policy-tmp"
+	found := false
+	for _, v := range spec.Volumes {
+		if v.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: name, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+	}
+	ctr.VolumeMounts = append(ctr.VolumeMounts, corev1.VolumeMount{Name: name, MountPath: "/tmp"})
+}
+
+// PolicyNames 策略的 namespace/name 列表，用于注解与状态信息
+func PolicyNames(pols []*apis.SecurityPolicy) string {
+	names := make([]string, len(pols))
+	for i, p := range pols {
+		names[i] = policyKey(p.Namespace, p.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+//Personal.AI order the ending
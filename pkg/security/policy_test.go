@@ -0,0 +1,114 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+func TestMatchPolicy(t *testing.T) {
+	pol := &apis.SecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "agents", Name: "strict"},
+		Spec: apis.PolicySpec{Match: apis.PolicyMatch{
+			Kind:        "Agent",
+			Labels:      map[string]string{"team": "infra"},
+			Expressions: []string{"tier in (prod,staging)"},
+		}},
+	}
+	target := PolicyTarget{Kind: "Agent", Namespace: "agents", Labels: map[string]string{"team": "infra", "tier": "prod"}}
+	ok, err := MatchPolicy(pol, target)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	for name, mutate := range map[string]func(t *PolicyTarget){
+		"kind":       func(t *PolicyTarget) { t.Kind = "Task" },
+		"namespace":  func(t *PolicyTarget) { t.Namespace = "other" },
+		"label":      func(t *PolicyTarget) { t.Labels = map[string]string{"team": "web", "tier": "prod"} },
+		"expression": func(t *PolicyTarget) { t.Labels = map[string]string{"team": "infra", "tier": "dev"} },
+	} {
+		tt := target
+		mutate(&tt)
+		ok, err := MatchPolicy(pol, tt)
+		assert.NoError(t, err, name)
+		assert.False(t, ok, name)
+	}
+
+	// 命名空间支持 glob，Kind 为空时匹配全部
+	pol.Spec.Match = apis.PolicyMatch{Namespaces: []string{"team-*"}}
+	ok, err = MatchPolicy(pol, PolicyTarget{Kind: "Task", Namespace: "team-a"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 表达式非法：范围外不报错，范围内报错
+	pol.Spec.Match = apis.PolicyMatch{Kind: "Agent", Expressions: []string{"tier in (("}}
+	ok, err = MatchPolicy(pol, PolicyTarget{Kind: "Task", Namespace: "agents"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, err = MatchPolicy(pol, target)
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	assert.Error(t, ValidatePolicy(pol))
+}
+
+func TestMergeConstraints(t *testing.T) {
+	c := MergeConstraints([]*apis.SecurityPolicy{
+		{Spec: apis.PolicySpec{Constraints: apis.PolicyConstraints{AllowPrivileged: true, SysCallRestriction: []string{"ptrace", "mount"}}}},
+		{Spec: apis.PolicySpec{Constraints: apis.PolicyConstraints{ReadOnlyRootFS: true, SysCallRestriction: []string{"mount"}}}},
+	})
+	assert.True(t, c.ReadOnlyRootFS)
+	assert.False(t, c.AllowPrivileged)
+	assert.Equal(t, []string{"mount", "ptrace"}, c.SysCallRestriction)
+	assert.True(t, MergeConstraints(nil).AllowPrivileged)
+}
+
+func TestEnforcePodConstraints(t *testing.T) {
+	strict := apis.PolicyConstraints{ReadOnlyRootFS: true, SysCallRestriction: []string{"ptrace"}}
+	tpl := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}}
+	require.Empty(t, EnforcePodConstraints(tpl, strict))
+
+	sc := tpl.Spec.Containers[0].SecurityContext
+	assert.False(t, *sc.Privileged)
+	assert.False(t, *sc.AllowPrivilegeEscalation)
+	assert.True(t, *sc.ReadOnlyRootFilesystem)
+	assert.Equal(t, corev1.SeccompProfileTypeLocalhost, tpl.Spec.SecurityContext.SeccompProfile.Type)
+	assert.Equal(t, "agenticai/agent-default.json", *tpl.Spec.SecurityContext.SeccompProfile.LocalhostProfile)
+	assert.Equal(t, "/tmp", tpl.Spec.Containers[0].VolumeMounts[0].MountPath)
+	require.Len(t, tpl.Spec.Volumes, 1)
+	// 重复执行结果不变
+	require.Empty(t, EnforcePodConstraints(tpl, strict))
+	assert.Len(t, tpl.Spec.Volumes, 1)
+	assert.Len(t, tpl.Spec.Containers[0].VolumeMounts, 1)
+
+	yes, no := true, false
+	tpl = &corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}},
+		Containers: []corev1.Container{
+			{Name: "priv", SecurityContext: &corev1.SecurityContext{Privileged: &yes}},
+			{Name: "rw", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: &no}},
+		},
+	}}
+	v := EnforcePodConstraints(tpl, strict)
+	assert.Len(t, v, 3)
+
+	// 显式设置不被覆盖
+	assert.True(t, *tpl.Spec.Containers[0].SecurityContext.Privileged)
+	assert.False(t, *tpl.Spec.Containers[1].SecurityContext.ReadOnlyRootFilesystem)
+
+	// 容器级 profile 只能与生成的一致
+	tpl = &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+		{Name: "rd", SecurityContext: &corev1.SecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}}},
+	}}}
+	assert.Equal(t, []string{"container rd: seccomp profile must be agenticai/agent-default.json"}, EnforcePodConstraints(tpl, strict))
+
+	// 允许特权时不改写
+	tpl = &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+		{Name: "priv", SecurityContext: &corev1.SecurityContext{Privileged: &yes}},
+	}}}
+	assert.Empty(t, EnforcePodConstraints(tpl, apis.PolicyConstraints{AllowPrivileged: true}))
+	assert.Nil(t, tpl.Spec.SecurityContext)
+}
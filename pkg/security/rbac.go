@@ -3,12 +3,16 @@ package security
 
 import (
 	"context"
+	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/turtacn/agenticai/internal/errors"
 	"github.com/turtacn/agenticai/pkg/apis"
 )
 
@@ -16,54 +20,230 @@ const ctxKeyCaller = callerKey("caller")
 
 type callerKey string
 
-// RBAC 管理器
+// RBAC 管理器；可同时加载多份策略，按 namespace/name 区分
 type RBAC interface {
-	UpdatePolicy(pol *apis.SecurityPolicy)
+	// UpdatePolicy 新增或替换同名策略；任一规则非法时整份策略不生效，保留旧版本
+	UpdatePolicy(pol *apis.SecurityPolicy) error
+	RemovePolicy(namespace, name string)
+	Decide(ctx context.Context, subject, action, resource string) Decision
+	// Authorize 未被允许时返回 PermissionDenied
 	Authorize(ctx context.Context, subject, action, resource string) error
 }
 
+// Decision 一次授权判定及命中的规则
+type Decision struct {
+	Allowed bool
+	Policy  string // 命中规则所属策略 namespace/name，未命中为空
+	Rule    int    // 命中规则在 Spec.Rules 中的下标，未命中为 -1
+	Role    string // 调用方借以命中规则的角色
+	Reason  string
+}
+
+// rule 编译后的 RBACRule
 type rule struct {
-	sub, act, res *regexp.Regexp
+	index            int
+	deny             bool
+	roleRe, act, res *regexp.Regexp
+}
+
+type binding struct {
+	role     string
+	subjects *regexp.Regexp
+}
+
+type policy struct {
+	key      string
+	bindings []binding
+	rules    []rule
 }
 
 type rbac struct {
-	mu    sync.RWMutex
-	rules []rule
+	mu       sync.RWMutex
+	policies map[string]*policy
+	ordered  []*policy // 按 key 排序，保证判定结果稳定
 }
 
-func NewRBAC() RBAC { return &rbac{} }
+func NewRBAC() RBAC { return &rbac{policies: make(map[string]*policy)} }
+
+func policyKey(namespace, name string) string { return namespace + "/" + name }
 
-func (r *rbac) UpdatePolicy(pol *apis.SecurityPolicy) {
+func (r *rbac) UpdatePolicy(pol *apis.SecurityPolicy) error {
+	p, err := compilePolicy(pol)
+	if err != nil {
+		return err
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	rules := make([]rule, 0, len(pol.Spec.Rules))
-	for _, ro := range pol.Spec.Rules {
-		// TODO: This is a temporary simplification to allow compilation.
-		// The RBAC authorizer needs to be updated to properly handle multiple verbs and resources per rule.
-		if len(ro.Verbs) > 0 && len(ro.Resources) > 0 {
-			rules = append(rules, rule{
-				sub: regexp.MustCompile(wild2regex(ro.Role)),
-				act: regexp.MustCompile(wild2regex(ro.Verbs[0])),
-				res: regexp.MustCompile(wild2regex(ro.Resources[0])),
-			})
-		}
+	r.policies[p.key] = p
+	r.reorderLocked()
+	return nil
+}
+
+func (r *rbac) RemovePolicy(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.policies, policyKey(namespace, name))
+	r.reorderLocked()
+}
+
+func (r *rbac) reorderLocked() {
+	r.ordered = r.ordered[:0]
+	for _, p := range r.policies {
+		r.ordered = append(r.ordered, p)
 	}
-	r.rules = rules
+	sort.Slice(r.ordered, func(i, j int) bool { return r.ordered[i].key < r.ordered[j].key })
 }
 
-func (r *rbac) Authorize(ctx context.Context, subject, action, resource string) error {
+// Decide 调用方的角色只来自同一策略内的 Bindings；任一 Deny 命中即拒绝，
+// 否则取第一条命中的 Allow，全部未命中时默认拒绝
+func (r *rbac) Decide(_ context.Context, subject, action, resource string) Decision {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for _, ru := range r.rules {
-		if ru.sub.MatchString(subject) && ru.act.MatchString(action) && ru.res.MatchString(resource) {
-			return nil
+	var allow *Decision
+	for _, p := range r.ordered {
+		roles := p.rolesOf(subject)
+		if len(roles) == 0 {
+			continue
+		}
+		for _, ru := range p.rules {
+			role, ok := ru.matches(roles, action, resource)
+			if !ok {
+				continue
+			}
+			d := Decision{Policy: p.key, Rule: ru.index, Role: role}
+			if ru.deny {
+				d.Reason = fmt.Sprintf("denied by rule %d of policy %s", ru.index, p.key)
+				return d
+			}
+			if allow == nil {
+				d.Allowed = true
+				d.Reason = fmt.Sprintf("allowed by rule %d of policy %s", ru.index, p.key)
+				allow = &d
+			}
+		}
+	}
+	if allow != nil {
+		return *allow
+	}
+	return Decision{Rule: -1, Reason: "no rule allows " + action + " on " + resource}
+}
+
+func (r *rbac) Authorize(ctx context.Context, subject, action, resource string) error {
+	if d := r.Decide(ctx, subject, action, resource); !d.Allowed {
+		return status.Errorf(codes.PermissionDenied, "%s may not %s %s: %s", subject, action, resource, d.Reason)
+	}
+	return nil
+}
+
+func (p *policy) rolesOf(subject string) []string {
+	var roles []string
+	for _, b := range p.bindings {
+		if b.subjects.MatchString(subject) {
+			roles = append(roles, b.role)
 		}
 	}
-	return status.Error(codes.PermissionDenied, "unauthorized")
+	return roles
 }
 
-func wild2regex(s string) string {
-	return "^" + s + "$"
+func (ru *rule) matches(roles []string, action, resource string) (string, bool) {
+	if !ru.act.MatchString(action) || !ru.res.MatchString(resource) {
+		return "", false
+	}
+	for _, role := range roles {
+		if ru.roleRe.MatchString(role) {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+func compilePolicy(pol *apis.SecurityPolicy) (*policy, error) {
+	p := &policy{key: policyKey(pol.Namespace, pol.Name)}
+	for i, b := range pol.Spec.Bindings {
+		if b.Role == "" || len(b.Subjects) == 0 {
+			return nil, errors.E(errors.KindValidation, fmt.Sprintf("binding %d: role and subjects are required", i))
+		}
+		re, err := CompileGlobs(b.Subjects)
+		if err != nil {
+			return nil, errors.Validation(err, fmt.Sprintf("binding %d", i))
+		}
+		p.bindings = append(p.bindings, binding{role: b.Role, subjects: re})
+	}
+	for i, ro := range pol.Spec.Rules {
+		ru, err := compileRule(i, ro)
+		if err != nil {
+			return nil, errors.Validation(err, fmt.Sprintf("rule %d", i))
+		}
+		p.rules = append(p.rules, ru)
+	}
+	return p, nil
+}
+
+func compileRule(i int, ro apis.RBACRule) (rule, error) {
+	ru := rule{index: i}
+	switch ro.Effect {
+	case "", apis.EffectAllow:
+	case apis.EffectDeny:
+		ru.deny = true
+	default:
+		return ru, fmt.Errorf("unknown effect %q", ro.Effect)
+	}
+	if ro.Role == "" || len(ro.Verbs) == 0 || len(ro.Resources) == 0 {
+		return ru, fmt.Errorf("role, verbs and resources are required")
+	}
+	var err error
+	if ru.roleRe, err = CompileGlobs([]string{ro.Role}); err != nil {
+		return ru, err
+	}
+	if ru.act, err = CompileGlobs(ro.Verbs); err != nil {
+		return ru, err
+	}
+	ru.res, err = CompileGlobs(ro.Resources)
+	return ru, err
+}
+
+// CompileGlobs 把一组 glob 编译为整体锚定的正则，任一匹配即可；镜像白名单等配置共用同一语法
+func CompileGlobs(globs []string) (*regexp.Regexp, error) {
+	alts := make([]string, 0, len(globs))
+	for _, g := range globs {
+		re, err := glob2regex(g)
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, re)
+	}
+	return regexp.Compile("^(?:" + strings.Join(alts, "|") + ")$")
 }
+
+// glob2regex * 匹配任意字符序列，? 匹配单个字符，{a,b} 匹配其一（不可嵌套），其余字符按字面匹配
+func glob2regex(g string) (string, error) {
+	var b strings.Builder
+	inAlt := false
+	for _, c := range g {
+		switch {
+		case c == '*':
+			b.WriteString(".*")
+		case c == '?':
+			b.WriteString(".")
+		case c == '{' && !inAlt:
+			inAlt = true
+			b.WriteString("(?:")
+		case c == '{':
+			return "", fmt.Errorf("glob %q: nested {", g)
+		case c == '}' && inAlt:
+			inAlt = false
+			b.WriteString(")")
+		case c == ',' && inAlt:
+			b.WriteString("|")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if inAlt {
+		return "", fmt.Errorf("glob %q: unterminated {", g)
+	}
+	return b.String(), nil
+}
+
 //Personal.AI order the ending
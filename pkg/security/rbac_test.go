@@ -0,0 +1,102 @@
+package security
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+func testPolicy(name string, rules []apis.RBACRule, bindings ...apis.RoleBinding) *apis.SecurityPolicy {
+	return &apis.SecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       apis.PolicySpec{Rules: rules, Bindings: bindings},
+	}
+}
+
+func TestRBACDecide(t *testing.T) {
+	ctx := context.Background()
+	r := NewRBAC()
+	require.NoError(t, r.UpdatePolicy(testPolicy("agents", []apis.RBACRule{
+		{Role: "operator", Verbs: []string{"get", "list", "create"}, Resources: []string{"agent-*", "tasks/{batch,stream}"}},
+		{Role: "operator", Verbs: []string{"*"}, Resources: []string{"agent-prod"}, Effect: apis.EffectDeny},
+		{Role: "ad?in", Verbs: []string{"*"}, Resources: []string{"*"}},
+	},
+		apis.RoleBinding{Role: "operator", Subjects: []string{"spiffe://agenticai.io/ns/default/sa/*", "alice"}},
+		apis.RoleBinding{Role: "admin", Subjects: []string{"root"}},
+	)))
+
+	cases := []struct {
+		subject, action, resource string
+		allowed                   bool
+		rule                      int
+	}{
+		// 所有 verb 与 resource 都参与匹配
+		{"alice", "create", "agent-dev", true, 0},
+		{"alice", "list", "tasks/stream", true, 0},
+		{"spiffe://agenticai.io/ns/default/sa/runner", "get", "agent-a/b", true, 0},
+		// * 是 glob 而非正则："agent-*" 不匹配 "agent"
+		{"alice", "get", "agent", false, -1},
+		{"alice", "get", "tasks/batchX", false, -1},
+		{"alice", "delete", "agent-dev", false, -1},
+		// Deny 优先于先出现的 Allow
+		{"alice", "get", "agent-prod", false, 1},
+		{"root", "delete", "agent-prod", true, 2},
+		// 未绑定的调用方没有任何角色；规则中的 Role 不直接匹配调用方
+		{"bob", "get", "agent-dev", false, -1},
+		{"operator", "get", "agent-dev", false, -1},
+		{"spiffe://agenticai.io/ns/other/sa/runner", "get", "agent-dev", false, -1},
+	}
+	for _, c := range cases {
+		d := r.Decide(ctx, c.subject, c.action, c.resource)
+		assert.Equal(t, c.allowed, d.Allowed, "%+v: %s", c, d.Reason)
+		assert.Equal(t, c.rule, d.Rule, "%+v", c)
+		if c.rule >= 0 {
+			assert.Equal(t, "default/agents", d.Policy)
+		}
+	}
+	d := r.Decide(ctx, "root", "get", "x")
+	assert.Equal(t, "admin", d.Role)
+
+	err := r.Authorize(ctx, "alice", "get", "agent-prod")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, err.Error(), "rule 1 of policy default/agents")
+	assert.NoError(t, r.Authorize(ctx, "alice", "get", "agent-dev"))
+}
+
+func TestRBACPolicies(t *testing.T) {
+	ctx := context.Background()
+	r := NewRBAC()
+	allow := testPolicy("allow", []apis.RBACRule{{Role: "dev", Verbs: []string{"invoke"}, Resources: []string{"tools/*"}}},
+		apis.RoleBinding{Role: "dev", Subjects: []string{"alice"}})
+	require.NoError(t, r.UpdatePolicy(allow))
+	assert.True(t, r.Decide(ctx, "alice", "invoke", "tools/shell").Allowed)
+
+	// 另一份策略的 Deny 同样生效；绑定只作用于所在策略
+	deny := testPolicy("deny", []apis.RBACRule{{Role: "*", Verbs: []string{"invoke"}, Resources: []string{"tools/shell"}, Effect: apis.EffectDeny}},
+		apis.RoleBinding{Role: "everyone", Subjects: []string{"*"}})
+	require.NoError(t, r.UpdatePolicy(deny))
+	d := r.Decide(ctx, "alice", "invoke", "tools/shell")
+	assert.False(t, d.Allowed)
+	assert.Equal(t, "default/deny", d.Policy)
+	assert.True(t, r.Decide(ctx, "alice", "invoke", "tools/search").Allowed)
+
+	// 非法的更新被拒绝，保留旧版本
+	bad := deny.DeepCopy()
+	bad.Spec.Rules[0].Resources = []string{"tools/{shell"}
+	assert.Error(t, r.UpdatePolicy(bad))
+	bad.Spec.Rules[0] = apis.RBACRule{Role: "x", Verbs: []string{"get"}, Resources: []string{"y"}, Effect: "Maybe"}
+	assert.Error(t, r.UpdatePolicy(bad))
+	assert.False(t, r.Decide(ctx, "alice", "invoke", "tools/shell").Allowed)
+
+	r.RemovePolicy("default", "deny")
+	assert.True(t, r.Decide(ctx, "alice", "invoke", "tools/shell").Allowed)
+	r.RemovePolicy("default", "allow")
+	assert.False(t, r.Decide(ctx, "alice", "invoke", "tools/shell").Allowed)
+}
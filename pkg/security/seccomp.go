@@ -0,0 +1,109 @@
+// pkg/security/seccomp.go
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// AgentDefaultProfile 内置 profile 名，未匹配任何策略的沙箱也使用它
+	AgentDefaultProfile = "agent-default"
+	// SeccompProfileDir 生成的 profile 在 kubelet seccomp 目录下的子目录
+	SeccompProfileDir = "agenticai"
+)
+
+// SeccompAction OCI seccomp 动作
+type SeccompAction string
+
+const (
+	ActAllow SeccompAction = "SCMP_ACT_ALLOW"
+	ActErrno SeccompAction = "SCMP_ACT_ERRNO"
+)
+
+// SeccompProfile OCI runtime-spec 中 linux.seccomp 的结构，kubelet 的 Localhost profile 使用同一格式
+type SeccompProfile struct {
+	DefaultAction SeccompAction    `json:"defaultAction"`
+	Architectures []string         `json:"architectures,omitempty"`
+	Syscalls      []SeccompSyscall `json:"syscalls,omitempty"`
+}
+
+type SeccompSyscall struct {
+	Names    []string      `json:"names"`
+	Action   SeccompAction `json:"action"`
+	ErrnoRet *uint         `json:"errnoRet,omitempty"`
+}
+
+// agentDefaultSyscalls 内核模块、挂载、调试他进程、换内核等沙箱内不应出现的系统调用
+var agentDefaultSyscalls = []string{
+	"acct", "add_key", "bpf", "clock_adjtime", "clock_settime", "delete_module", "finit_module",
+	"init_module", "kexec_file_load", "kexec_load", "keyctl", "mount", "move_mount", "open_by_handle_at",
+	"perf_event_open", "pivot_root", "process_vm_readv", "process_vm_writev", "ptrace", "reboot",
+	"request_key", "settimeofday", "swapoff", "swapon", "umount", "umount2", "userfaultfd",
+}
+
+var seccompArchitectures = []string{
+	"SCMP_ARCH_X86_64", "SCMP_ARCH_X86", "SCMP_ARCH_X32", "SCMP_ARCH_AARCH64", "SCMP_ARCH_ARM",
+}
+
+var syscallName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// ValidateSyscalls 系统调用名只含小写字母、数字与下划线
+func ValidateSyscalls(names []string) error {
+	for _, n := range names {
+		if !syscallName.MatchString(n) {
+			return fmt.Errorf("invalid syscall name %q", n)
+		}
+	}
+	return nil
+}
+
+// blockedSyscalls agent-default 与 extra 的并集，排序去重
+func blockedSyscalls(extra []string) []string {
+	set := make(map[string]bool, len(agentDefaultSyscalls)+len(extra))
+	for _, n := range agentDefaultSyscalls {
+		set[n] = true
+	}
+	for _, n := range extra {
+		set[n] = true
+	}
+	out := make([]string, 0, len(set))
+	for n := range set {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SeccompProfileFor 在 agent-default 的基础上额外禁止 extra，被禁止的调用返回 EPERM
+func SeccompProfileFor(extra []string) *SeccompProfile {
+	eperm := uint(1)
+	return &SeccompProfile{
+		DefaultAction: ActAllow,
+		Architectures: append([]string(nil), seccompArchitectures...),
+		Syscalls: []SeccompSyscall{
+			{Names: blockedSyscalls(extra), Action: ActErrno, ErrnoRet: &eperm},
+		},
+	}
+}
+
+// SeccompProfileName 禁止集合相同则名字相同；不超出 agent-default 时即为 agent-default
+func SeccompProfileName(extra []string) string {
+	blocked := blockedSyscalls(extra)
+	if len(blocked) == len(agentDefaultSyscalls) {
+		return AgentDefaultProfile
+	}
+	sum := sha256.Sum256([]byte(strings.Join(blocked, ",")))
+	return "agent-" + hex.EncodeToString(sum[:6])
+}
+
+// LocalhostProfile Pod SeccompProfile.LocalhostProfile 引用的相对路径
+func LocalhostProfile(name string) string {
+	return SeccompProfileDir + "/" + name + ".json"
+}
+
+//Personal.AI order the ending
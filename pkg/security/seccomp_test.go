@@ -0,0 +1,43 @@
+package security
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeccompProfileFor(t *testing.T) {
+	prof := SeccompProfileFor([]string{"unshare", "ptrace"})
+	assert.Equal(t, ActAllow, prof.DefaultAction)
+	require.Len(t, prof.Syscalls, 1)
+	blocked := prof.Syscalls[0]
+	assert.Equal(t, ActErrno, blocked.Action)
+	assert.Equal(t, uint(1), *blocked.ErrnoRet)
+	for _, n := range []string{"ptrace", "mount", "kexec_load", "unshare"} {
+		assert.Contains(t, blocked.Names, n)
+	}
+	assert.Len(t, blocked.Names, len(agentDefaultSyscalls)+1, "duplicates are removed")
+	assert.IsIncreasing(t, blocked.Names)
+
+	assert.Equal(t, AgentDefaultProfile, SeccompProfileName(nil))
+	assert.Equal(t, AgentDefaultProfile, SeccompProfileName([]string{"mount"}))
+	name := SeccompProfileName([]string{"unshare", "ptrace"})
+	assert.Equal(t, name, SeccompProfileName([]string{"ptrace", "unshare", "unshare"}))
+	assert.NotEqual(t, name, SeccompProfileName([]string{"chroot"}))
+	assert.Equal(t, "agenticai/"+name+".json", LocalhostProfile(name))
+
+	assert.NoError(t, ValidateSyscalls([]string{"ptrace", "clone3"}))
+	assert.Error(t, ValidateSyscalls([]string{"../etc"}))
+}
+
+// config/seccomp/agent-default.json 与代码中的内置 profile 保持一致
+func TestAgentDefaultProfileFile(t *testing.T) {
+	raw, err := os.ReadFile("../../config/seccomp/agent-default.json")
+	require.NoError(t, err)
+	want, err := json.Marshal(SeccompProfileFor(nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(raw))
+}
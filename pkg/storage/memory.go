@@ -0,0 +1,120 @@
+// pkg/storage/memory.go
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/turtacn/agenticai/internal/errors"
+)
+
+// memStore 进程内 Store，用于单副本部署与测试
+type memStore struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+	closed  bool
+}
+
+// NewMemoryStore Writer 在 Close 时整体提交，读方不会看到写了一半的对象
+func NewMemoryStore() Store {
+	return &memStore{objects: make(map[string][]byte)}
+}
+
+func (m *memStore) Reader(_ context.Context, key string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return nil, errStoreClosed()
+	}
+	b, ok := m.objects[key]
+	if !ok {
+		return nil, errors.E(errors.KindNotFound, fmt.Sprintf("object %q not found", key))
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m *memStore) Writer(_ context.Context, key string) (io.WriteCloser, error) {
+	if key == "" {
+		return nil, errors.E(errors.KindValidation, "empty object key")
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return nil, errStoreClosed()
+	}
+	return &memWriter{store: m, key: key}, nil
+}
+
+func (m *memStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return errStoreClosed()
+	}
+	delete(m.objects, key)
+	return nil
+}
+
+// List 返回以 prefix 开头的 key，按字典序
+func (m *memStore) List(_ context.Context, prefix string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return nil, errStoreClosed()
+	}
+	var keys []string
+	for k := range m.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *memStore) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	m.objects = nil
+	return nil
+}
+
+type memWriter struct {
+	store *memStore
+	key   string
+	buf   bytes.Buffer
+	done  bool
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, errors.E(errors.KindValidation, "write after close")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *memWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	if w.store.closed {
+		return errStoreClosed()
+	}
+	w.store.objects[w.key] = w.buf.Bytes()
+	return nil
+}
+
+func errStoreClosed() error {
+	return errors.E(errors.KindUnavailable, "store closed")
+}
+
+//Personal.AI order the ending
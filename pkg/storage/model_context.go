@@ -0,0 +1,417 @@
+// pkg/storage/model_context.go
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	mcpv1 "github.com/turtacn/agenticai/pkg/gen/api/proto/mcp/v1"
+)
+
+const (
+	// DefaultContextVersions 每个上下文默认保留的历史版本数
+	DefaultContextVersions = 100
+
+	contextKeyPrefix = "contexts/"
+	// contextTombstone 删除后记录最后版本号，重建时版本继续递增
+	contextTombstone  = "deleted"
+	contextWatchQueue = 64
+)
+
+var contextIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,252}$`)
+
+// ContextService 基于 Store 实现 ModelContextService，每个版本一个对象：
+// contexts/<id>/v<20 位版本号>。版本号在进程内串行分配，
+// 多副本共享同一 Store 时需要存储层提供条件写
+type ContextService struct {
+	mcpv1.UnimplementedModelContextServiceServer
+
+	store       Store
+	maxVersions int
+	now         func() time.Time
+
+	mu       sync.Mutex // 串行化写入与事件发布
+	watchers map[string]map[*contextWatcher]struct{}
+}
+
+// ContextOption 配置 ContextService
+type ContextOption func(*ContextService)
+
+// WithMaxContextVersions 每个上下文保留的版本数，超出时删除最旧的；<=0 表示全部保留
+func WithMaxContextVersions(n int) ContextOption {
+	return func(s *ContextService) { s.maxVersions = n }
+}
+
+func NewContextService(store Store, opts ...ContextOption) *ContextService {
+	s := &ContextService{
+		store:       store,
+		maxVersions: DefaultContextVersions,
+		now:         time.Now,
+		watchers:    make(map[string]map[*contextWatcher]struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Register 把服务挂到 grpc.Server
+func (s *ContextService) Register(srv grpc.ServiceRegistrar) {
+	mcpv1.RegisterModelContextServiceServer(srv, s)
+}
+
+type contextEntry struct {
+	Timestamp int64  `json:"timestamp"`
+	Data      string `json:"data"`
+}
+
+func (s *ContextService) GetContext(ctx context.Context, req *mcpv1.GetContextRequest) (*mcpv1.GetContextResponse, error) {
+	id := req.GetContextId()
+	if err := validateContextID(id); err != nil {
+		return nil, err
+	}
+	versions, _, err := s.versions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, errContextNotFound(id)
+	}
+	v := req.GetVersion()
+	if v == 0 {
+		v = versions[len(versions)-1]
+	}
+	e, err := s.read(ctx, id, v)
+	if err != nil {
+		return nil, err
+	}
+	return &mcpv1.GetContextResponse{Data: e.Data, Timestamp: e.Timestamp, Version: v}, nil
+}
+
+func (s *ContextService) UpdateContext(ctx context.Context, req *mcpv1.UpdateRequest) (*mcpv1.UpdateResponse, error) {
+	id := req.GetContextId()
+	if err := validateContextID(id); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions, deletedAt, err := s.versions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cur := latest(versions)
+	if err := checkExpected(id, req.ExpectedVersion, cur); err != nil {
+		return nil, err
+	}
+	next := max(cur, deletedAt) + 1
+	e := contextEntry{Timestamp: s.now().UnixMilli(), Data: req.GetData()}
+	if err := s.write(ctx, versionKey(id, next), &e); err != nil {
+		return nil, err
+	}
+	if deletedAt > 0 {
+		if err := s.store.Delete(ctx, contextPrefix(id)+contextTombstone); err != nil {
+			return nil, errors.Unavailable(err, "clear context tombstone")
+		}
+	}
+	// 清理失败只会多留旧版本，下次写入时再删
+	if s.maxVersions > 0 {
+		for _, old := range append(versions, next)[:max(0, len(versions)+1-s.maxVersions)] {
+			_ = s.store.Delete(ctx, versionKey(id, old))
+		}
+	}
+
+	s.publish(&mcpv1.ContextEvent{
+		Type: mcpv1.ContextEventType_CONTEXT_EVENT_TYPE_UPDATED, ContextId: id,
+		Version: next, Data: e.Data, Timestamp: e.Timestamp,
+	})
+	return &mcpv1.UpdateResponse{Success: true, Version: next, Timestamp: e.Timestamp}, nil
+}
+
+func (s *ContextService) ListVersions(ctx context.Context, req *mcpv1.ListVersionsRequest) (*mcpv1.ListVersionsResponse, error) {
+	id := req.GetContextId()
+	if err := validateContextID(id); err != nil {
+		return nil, err
+	}
+	versions, _, err := s.versions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, errContextNotFound(id)
+	}
+	resp := &mcpv1.ListVersionsResponse{}
+	for _, v := range versions {
+		e, err := s.read(ctx, id, v)
+		if errors.KindOf(err) == errors.KindNotFound {
+			continue // 并发写入时被清理
+		}
+		if err != nil {
+			return nil, err
+		}
+		resp.Versions = append(resp.Versions, &mcpv1.ContextVersion{
+			Version: v, Timestamp: e.Timestamp, Size: int64(len(e.Data)),
+		})
+	}
+	return resp, nil
+}
+
+func (s *ContextService) DeleteContext(ctx context.Context, req *mcpv1.DeleteContextRequest) (*mcpv1.DeleteContextResponse, error) {
+	id := req.GetContextId()
+	if err := validateContextID(id); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions, _, err := s.versions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, errContextNotFound(id)
+	}
+	cur := latest(versions)
+	if err := checkExpected(id, req.ExpectedVersion, cur); err != nil {
+		return nil, err
+	}
+	// 先写墓碑：中途失败时重建也不会复用版本号
+	w, err := s.store.Writer(ctx, contextPrefix(id)+contextTombstone)
+	if err != nil {
+		return nil, errors.Unavailable(err, "write context tombstone")
+	}
+	_, err = io.WriteString(w, strconv.FormatInt(cur, 10))
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, errors.Unavailable(err, "write context tombstone")
+	}
+	for _, v := range versions {
+		if err := s.store.Delete(ctx, versionKey(id, v)); err != nil {
+			return nil, errors.Unavailable(err, fmt.Sprintf("delete context %s version %d", id, v))
+		}
+	}
+
+	s.publish(&mcpv1.ContextEvent{
+		Type: mcpv1.ContextEventType_CONTEXT_EVENT_TYPE_DELETED, ContextId: id,
+		Version: cur, Timestamp: s.now().UnixMilli(),
+	})
+	return &mcpv1.DeleteContextResponse{DeletedVersions: int64(len(versions))}, nil
+}
+
+// contextWatcher 队列写满说明消费太慢，断开后由客户端带 since_version 重连补齐
+type contextWatcher struct {
+	events  chan *mcpv1.ContextEvent
+	dropped chan struct{}
+}
+
+func (s *ContextService) WatchContext(req *mcpv1.WatchContextRequest, stream mcpv1.ModelContextService_WatchContextServer) error {
+	id := req.GetContextId()
+	if err := validateContextID(id); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+
+	// 先登记再补发历史，补发与实时事件的重叠按版本号去重
+	w := &contextWatcher{
+		events:  make(chan *mcpv1.ContextEvent, contextWatchQueue),
+		dropped: make(chan struct{}),
+	}
+	s.mu.Lock()
+	if s.watchers[id] == nil {
+		s.watchers[id] = make(map[*contextWatcher]struct{})
+	}
+	s.watchers[id][w] = struct{}{}
+	s.mu.Unlock()
+	defer s.unwatch(id, w)
+
+	versions, _, err := s.versions(ctx, id)
+	if err != nil {
+		return err
+	}
+	since := req.GetSinceVersion()
+	if since == 0 && len(versions) > 0 {
+		versions = versions[len(versions)-1:]
+	}
+	sent := since
+	for _, v := range versions {
+		if v <= since {
+			continue
+		}
+		e, err := s.read(ctx, id, v)
+		if errors.KindOf(err) == errors.KindNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&mcpv1.ContextEvent{
+			Type: mcpv1.ContextEventType_CONTEXT_EVENT_TYPE_UPDATED, ContextId: id,
+			Version: v, Data: e.Data, Timestamp: e.Timestamp,
+		}); err != nil {
+			return err
+		}
+		sent = v
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.dropped:
+			return errors.E(errors.KindUnavailable,
+				fmt.Sprintf("watch on context %s fell behind, resume from version %d", id, sent))
+		case ev := <-w.events:
+			if ev.Type == mcpv1.ContextEventType_CONTEXT_EVENT_TYPE_UPDATED {
+				if ev.Version <= sent {
+					continue
+				}
+				sent = ev.Version
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publish 调用方持有 s.mu
+func (s *ContextService) publish(ev *mcpv1.ContextEvent) {
+	for w := range s.watchers[ev.ContextId] {
+		select {
+		case w.events <- ev:
+		default:
+			close(w.dropped)
+			delete(s.watchers[ev.ContextId], w)
+		}
+	}
+}
+
+func (s *ContextService) unwatch(id string, w *contextWatcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.watchers[id], w)
+	if len(s.watchers[id]) == 0 {
+		delete(s.watchers, id)
+	}
+}
+
+// versions 返回现存版本（升序）与墓碑记录的删除前版本
+func (s *ContextService) versions(ctx context.Context, id string) ([]int64, int64, error) {
+	prefix := contextPrefix(id)
+	keys, err := s.store.List(ctx, prefix)
+	if err != nil {
+		return nil, 0, errors.Unavailable(err, fmt.Sprintf("list context %s", id))
+	}
+	var (
+		out       []int64
+		deletedAt int64
+	)
+	for _, k := range keys {
+		name := strings.TrimPrefix(k, prefix)
+		if name == contextTombstone {
+			if deletedAt, err = s.readTombstone(ctx, k); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		if v, err := strconv.ParseInt(strings.TrimPrefix(name, "v"), 10, 64); err == nil && strings.HasPrefix(name, "v") {
+			out = append(out, v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, deletedAt, nil
+}
+
+func (s *ContextService) readTombstone(ctx context.Context, key string) (int64, error) {
+	r, err := s.store.Reader(ctx, key)
+	if err != nil {
+		return 0, errors.Unavailable(err, "read context tombstone")
+	}
+	defer r.Close()
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return 0, errors.Unavailable(err, "read context tombstone")
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil {
+		return 0, errors.Internal(err, fmt.Sprintf("corrupt context tombstone %s", key))
+	}
+	return v, nil
+}
+
+func (s *ContextService) read(ctx context.Context, id string, v int64) (*contextEntry, error) {
+	r, err := s.store.Reader(ctx, versionKey(id, v))
+	if err != nil {
+		if errors.KindOf(err) == errors.KindNotFound {
+			return nil, errors.E(errors.KindNotFound, fmt.Sprintf("context %s version %d not found", id, v))
+		}
+		return nil, errors.Unavailable(err, fmt.Sprintf("read context %s", id))
+	}
+	defer r.Close()
+	var e contextEntry
+	if err := json.NewDecoder(r).Decode(&e); err != nil {
+		return nil, errors.Internal(err, fmt.Sprintf("decode context %s version %d", id, v))
+	}
+	return &e, nil
+}
+
+func (s *ContextService) write(ctx context.Context, key string, e *contextEntry) error {
+	w, err := s.store.Writer(ctx, key)
+	if err != nil {
+		return errors.Unavailable(err, "open context writer")
+	}
+	err = json.NewEncoder(w).Encode(e)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Unavailable(err, "write context")
+	}
+	return nil
+}
+
+// checkExpected expected 为 nil 时不做检查；0 表示要求上下文不存在
+func checkExpected(id string, expected *int64, cur int64) error {
+	if expected == nil || *expected == cur {
+		return nil
+	}
+	return errors.E(errors.KindConflict,
+		fmt.Sprintf("context %s is at version %d, expected %d", id, cur, *expected))
+}
+
+func validateContextID(id string) error {
+	if !contextIDPattern.MatchString(id) {
+		return errors.E(errors.KindValidation, fmt.Sprintf("invalid context id %q", id))
+	}
+	return nil
+}
+
+func errContextNotFound(id string) error {
+	return errors.E(errors.KindNotFound, fmt.Sprintf("context %s not found", id))
+}
+
+func latest(versions []int64) int64 {
+	if len(versions) == 0 {
+		return 0
+	}
+	return versions[len(versions)-1]
+}
+
+func contextPrefix(id string) string { return contextKeyPrefix + id + "/" }
+
+func versionKey(id string, v int64) string { return fmt.Sprintf("%sv%020d", contextPrefix(id), v) }
+
+//Personal.AI order the ending
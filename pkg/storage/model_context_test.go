@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	mcpv1 "github.com/turtacn/agenticai/pkg/gen/api/proto/mcp/v1"
+)
+
+// contextClient 经 bufconn 连到 ContextService
+func contextClient(t *testing.T, opts ...ContextOption) mcpv1.ModelContextServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	NewContextService(NewMemoryStore(), opts...).Register(srv)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return mcpv1.NewModelContextServiceClient(conn)
+}
+
+func codeOf(err error) codes.Code { return status.Code(err) }
+
+func TestContextServiceVersions(t *testing.T) {
+	c := contextClient(t, WithMaxContextVersions(3))
+	ctx := context.Background()
+
+	_, err := c.GetContext(ctx, &mcpv1.GetContextRequest{ContextId: "task-1"})
+	assert.Equal(t, codes.NotFound, codeOf(err))
+	_, err = c.GetContext(ctx, &mcpv1.GetContextRequest{ContextId: "../etc"})
+	assert.Equal(t, codes.InvalidArgument, codeOf(err))
+
+	// expected_version=0 只允许创建
+	up, err := c.UpdateContext(ctx, &mcpv1.UpdateRequest{ContextId: "task-1", Data: "a", ExpectedVersion: proto.Int64(0)})
+	require.NoError(t, err)
+	assert.True(t, up.Success)
+	assert.Equal(t, int64(1), up.Version)
+	_, err = c.UpdateContext(ctx, &mcpv1.UpdateRequest{ContextId: "task-1", Data: "x", ExpectedVersion: proto.Int64(0)})
+	assert.Equal(t, codes.Aborted, codeOf(err))
+
+	up, err = c.UpdateContext(ctx, &mcpv1.UpdateRequest{ContextId: "task-1", Data: "bb", ExpectedVersion: proto.Int64(1)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), up.Version)
+	// 基于旧版本的写入被拒绝
+	_, err = c.UpdateContext(ctx, &mcpv1.UpdateRequest{ContextId: "task-1", Data: "x", ExpectedVersion: proto.Int64(1)})
+	assert.Equal(t, codes.Aborted, codeOf(err))
+	// 不带期望版本时无条件写入
+	for _, d := range []string{"ccc", "dddd"} {
+		_, err = c.UpdateContext(ctx, &mcpv1.UpdateRequest{ContextId: "task-1", Data: d})
+		require.NoError(t, err)
+	}
+
+	got, err := c.GetContext(ctx, &mcpv1.GetContextRequest{ContextId: "task-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "dddd", got.Data)
+	assert.Equal(t, int64(4), got.Version)
+	assert.NotZero(t, got.Timestamp)
+	got, err = c.GetContext(ctx, &mcpv1.GetContextRequest{ContextId: "task-1", Version: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "bb", got.Data)
+
+	// 只保留最近 3 个版本
+	list, err := c.ListVersions(ctx, &mcpv1.ListVersionsRequest{ContextId: "task-1"})
+	require.NoError(t, err)
+	require.Len(t, list.Versions, 3)
+	assert.Equal(t, int64(2), list.Versions[0].Version)
+	assert.Equal(t, int64(4), list.Versions[2].Version)
+	assert.Equal(t, int64(4), list.Versions[2].Size)
+	_, err = c.GetContext(ctx, &mcpv1.GetContextRequest{ContextId: "task-1", Version: 1})
+	assert.Equal(t, codes.NotFound, codeOf(err))
+
+	_, err = c.DeleteContext(ctx, &mcpv1.DeleteContextRequest{ContextId: "task-1", ExpectedVersion: proto.Int64(3)})
+	assert.Equal(t, codes.Aborted, codeOf(err))
+	del, err := c.DeleteContext(ctx, &mcpv1.DeleteContextRequest{ContextId: "task-1"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), del.DeletedVersions)
+	_, err = c.ListVersions(ctx, &mcpv1.ListVersionsRequest{ContextId: "task-1"})
+	assert.Equal(t, codes.NotFound, codeOf(err))
+
+	// 删除后重建，版本号继续递增
+	up, err = c.UpdateContext(ctx, &mcpv1.UpdateRequest{ContextId: "task-1", Data: "again", ExpectedVersion: proto.Int64(0)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), up.Version)
+}
+
+func TestContextServiceWatch(t *testing.T) {
+	c := contextClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, d := range []string{"v1", "v2", "v3"} {
+		_, err := c.UpdateContext(ctx, &mcpv1.UpdateRequest{ContextId: "chat", Data: d})
+		require.NoError(t, err)
+	}
+
+	// since_version 之后的历史先补发
+	replay, err := c.WatchContext(ctx, &mcpv1.WatchContextRequest{ContextId: "chat", SinceVersion: 1})
+	require.NoError(t, err)
+	// since_version=0 从当前最新版本开始
+	live, err := c.WatchContext(ctx, &mcpv1.WatchContextRequest{ContextId: "chat"})
+	require.NoError(t, err)
+
+	for _, want := range []int64{2, 3} {
+		ev, err := replay.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, want, ev.Version)
+	}
+	ev, err := live.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), ev.Version)
+	assert.Equal(t, "v3", ev.Data)
+
+	_, err = c.UpdateContext(ctx, &mcpv1.UpdateRequest{ContextId: "chat", Data: "v4"})
+	require.NoError(t, err)
+	_, err = c.DeleteContext(ctx, &mcpv1.DeleteContextRequest{ContextId: "chat"})
+	require.NoError(t, err)
+
+	for _, s := range []mcpv1.ModelContextService_WatchContextClient{replay, live} {
+		ev, err := s.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, mcpv1.ContextEventType_CONTEXT_EVENT_TYPE_UPDATED, ev.Type)
+		assert.Equal(t, int64(4), ev.Version)
+		assert.Equal(t, "v4", ev.Data)
+		ev, err = s.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, mcpv1.ContextEventType_CONTEXT_EVENT_TYPE_DELETED, ev.Type)
+		assert.Equal(t, int64(4), ev.Version)
+	}
+}
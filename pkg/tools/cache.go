@@ -0,0 +1,219 @@
+// pkg/tools/cache.go
+package tools
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+const (
+	// defaultCacheTTL CachePolicy.TTL 为空时的有效期
+	defaultCacheTTL = time.Minute
+	// defaultCacheEntries 内存缓存的条目上限
+	defaultCacheEntries = 4096
+	// defaultIdempotencyWindow 幂等键的去重窗口
+	defaultIdempotencyWindow = 10 * time.Minute
+)
+
+// ResultCache 工具结果缓存；实现需并发安全，Get 返回的结果调用方可以修改
+type ResultCache interface {
+	Get(key string) (*apis.ToolResult, bool)
+	Set(key string, res *apis.ToolResult, ttl time.Duration)
+}
+
+var (
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "agenticai_tool_cache_requests_total",
+		Help: "result cache lookups for cacheable tools; result is hit or miss",
+	}, []string{"tool", "result"})
+	idempotentReplays = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "agenticai_tool_idempotent_replays_total",
+		Help: "tool calls answered from an earlier call with the same idempotency key",
+	}, []string{"tool"})
+)
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey 同一调用方在窗口期内以相同键重试时只执行一次，后续返回首次结果
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKeyFromContext 读取 WithIdempotencyKey 写入的键
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// callKey 工具 ID + 解析出的版本与摘要 + 规范化入参；入参须已经过 ValidateArgs
+func callKey(spec *apis.ToolSpec, args map[string]interface{}) string {
+	// encoding/json 对 map 键排序，同一入参得到同一串
+	raw, _ := json.Marshal(args)
+	h := sha256.New()
+	for _, part := range []string{spec.ID, spec.Version, spec.Digest} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(raw)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func cacheTTL(p *apis.CachePolicy) time.Duration {
+	if p.TTL.Duration > 0 {
+		return p.TTL.Duration
+	}
+	return defaultCacheTTL
+}
+
+// ------------------ 内存 LRU ------------------
+
+type cacheEntry struct {
+	key       string
+	res       *apis.ToolResult
+	expiresAt time.Time
+}
+
+type memoryCache struct {
+	mu    sync.Mutex
+	max   int
+	ll    *list.List
+	items map[string]*list.Element
+	now   func() time.Time
+}
+
+// NewMemoryCache 进程内 LRU，maxEntries<=0 时取默认上限
+func NewMemoryCache(maxEntries int) ResultCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	return &memoryCache{max: maxEntries, ll: list.New(), items: make(map[string]*list.Element), now: time.Now}
+}
+
+func (c *memoryCache) Get(key string) (*apis.ToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !c.now().Before(e.expiresAt) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.res.DeepCopy(), true
+}
+
+func (c *memoryCache) Set(key string, res *apis.ToolResult, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &cacheEntry{key: key, res: res.DeepCopy(), expiresAt: c.now().Add(ttl)}
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(e)
+	for c.ll.Len() > c.max {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// ------------------ 幂等键 ------------------
+
+type idemEntry struct {
+	call      string // callKey，同键不同入参视为冲突
+	done      chan struct{}
+	res       *apis.ToolResult
+	err       error
+	expiresAt time.Time // 完成后才设置
+}
+
+// idempotencyStore 记录窗口期内的调用；失败（返回 error）的调用不保留，允许重试
+type idempotencyStore struct {
+	mu        sync.Mutex
+	window    time.Duration
+	now       func() time.Time
+	entries   map[string]*idemEntry
+	lastSweep time.Time
+}
+
+func newIdempotencyStore(window time.Duration) *idempotencyStore {
+	return &idempotencyStore{window: window, now: time.Now, entries: make(map[string]*idemEntry)}
+}
+
+// do 键按调用方隔离；首个调用执行 fn，同键的并发或后续调用等待并复用其结果，replayed 表示结果来自先前的调用
+func (s *idempotencyStore) do(ctx context.Context, caller, key, call string, fn func() (*apis.ToolResult, error)) (res *apis.ToolResult, replayed bool, err error) {
+	scoped := caller + "\x00" + key
+	s.mu.Lock()
+	now := s.now()
+	s.sweepLocked(now)
+	if e, ok := s.entries[scoped]; ok && !e.expired(now) {
+		s.mu.Unlock()
+		if e.call != call {
+			return nil, false, errors.E(errors.KindConflict,
+				fmt.Sprintf("idempotency key %q was already used with a different tool or arguments", key))
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, false, errors.Timeout(ctx.Err(), fmt.Sprintf("waiting for idempotent call %q", key))
+		}
+		if e.err != nil {
+			return nil, false, e.err
+		}
+		return e.res.DeepCopy(), true, nil
+	}
+	e := &idemEntry{call: call, done: make(chan struct{})}
+	s.entries[scoped] = e
+	s.mu.Unlock()
+
+	e.res, e.err = fn()
+	s.mu.Lock()
+	if e.err != nil {
+		delete(s.entries, scoped)
+	} else {
+		e.expiresAt = s.now().Add(s.window)
+	}
+	s.mu.Unlock()
+	close(e.done)
+	if e.err != nil {
+		return nil, false, e.err
+	}
+	return e.res.DeepCopy(), false, nil
+}
+
+// sweepLocked 每个窗口最多全量扫描一次，其间过期条目由查找时跳过
+func (s *idempotencyStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < s.window {
+		return
+	}
+	s.lastSweep = now
+	for k, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+func (e *idemEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+//Personal.AI order the ending
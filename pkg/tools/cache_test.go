@@ -0,0 +1,228 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+	"github.com/turtacn/agenticai/pkg/security"
+)
+
+func TestMemoryCache(t *testing.T) {
+	clock := newFakeClock()
+	c := NewMemoryCache(2).(*memoryCache)
+	c.now = clock.Now
+
+	c.Set("a", &apis.ToolResult{Output: "A"}, time.Minute)
+	c.Set("b", &apis.ToolResult{Output: "B"}, time.Minute)
+	got, ok := c.Get("a")
+	require.True(t, ok)
+	got.Output = "mutated"
+
+	// b 最久未用，被淘汰
+	c.Set("c", &apis.ToolResult{Output: "C"}, time.Second)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	got, ok = c.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "A", got.Output)
+
+	clock.Advance(2 * time.Second)
+	_, ok = c.Get("c")
+	assert.False(t, ok)
+	_, ok = c.Get("a")
+	assert.True(t, ok)
+}
+
+// countingSandbox 输出调用次数，便于区分真实执行与缓存
+func countingSandbox() *fakeSandboxes {
+	var n int
+	var mu sync.Mutex
+	return &fakeSandboxes{run: func(spec *sandbox.SandboxSpec) error {
+		mu.Lock()
+		n++
+		fmt.Fprintf(spec.Stdout, "run %d", n)
+		mu.Unlock()
+		return nil
+	}}
+}
+
+func TestInvokerResultCache(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	sb := countingSandbox()
+	reg := NewInMemRegistry()
+	lookup := &apis.ToolSpec{
+		Name: "lookup", Version: "1.0.0",
+		ArgsSchema: apis.AnyMap{"type": "object", "properties": map[string]interface{}{
+			"q":     map[string]interface{}{"type": "string"},
+			"limit": map[string]interface{}{"type": "integer", "default": float64(10)},
+		}},
+		CustomExec: &apis.CustomBinding{Image: "lookup"},
+		Cache:      &apis.CachePolicy{Cacheable: true, TTL: metav1.Duration{Duration: time.Minute}},
+	}
+	require.NoError(t, reg.Register(ctx, lookup))
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "write", CustomExec: &apis.CustomBinding{Image: "write"}}))
+	inv := NewInvoker(reg, sb)
+	inv.(*invoker).cache.(*memoryCache).now = clock.Now
+
+	res, err := inv.Invoke(ctx, "lookup", map[string]interface{}{"q": "go", "limit": float64(10)})
+	require.NoError(t, err)
+	assert.Equal(t, "run 1", res.Output)
+	assert.False(t, res.Cached)
+
+	// 规范化后相同：缺省值补齐、字符串强制转换
+	res, err = inv.Invoke(ctx, "lookup@^1", map[string]interface{}{"q": "go"})
+	require.NoError(t, err)
+	assert.Equal(t, "run 1", res.Output)
+	assert.True(t, res.Cached)
+	res, err = inv.Invoke(ctx, "lookup", map[string]interface{}{"limit": "10", "q": "go"})
+	require.NoError(t, err)
+	assert.True(t, res.Cached)
+	assert.Equal(t, 1, sb.starts())
+
+	res, err = inv.Invoke(ctx, "lookup", map[string]interface{}{"q": "rust"})
+	require.NoError(t, err)
+	assert.Equal(t, "run 2", res.Output)
+
+	// 新版本不复用旧结果
+	v2 := lookup.DeepCopy()
+	v2.Version = "1.1.0"
+	require.NoError(t, reg.Register(ctx, v2))
+	res, err = inv.Invoke(ctx, "lookup", map[string]interface{}{"q": "go"})
+	require.NoError(t, err)
+	assert.Equal(t, "run 3", res.Output)
+
+	clock.Advance(2 * time.Minute)
+	res, err = inv.Invoke(ctx, "lookup", map[string]interface{}{"q": "go"})
+	require.NoError(t, err)
+	assert.False(t, res.Cached)
+	assert.Equal(t, "run 4", res.Output)
+
+	// 未声明 cacheable 的工具每次都执行
+	for i := 0; i < 2; i++ {
+		res, err = inv.Invoke(ctx, "write", nil)
+		require.NoError(t, err)
+		assert.False(t, res.Cached)
+	}
+	assert.Equal(t, 6, sb.starts())
+}
+
+func TestInvokerResultCacheSkipsFailures(t *testing.T) {
+	ctx := context.Background()
+	sb := &fakeSandboxes{run: func(spec *sandbox.SandboxSpec) error { return fmt.Errorf("exit status 1") }}
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{
+		Name: "flaky", CustomExec: &apis.CustomBinding{Image: "flaky"}, Cache: &apis.CachePolicy{Cacheable: true},
+	}))
+	inv := NewInvoker(reg, sb)
+	for i := 0; i < 2; i++ {
+		res, err := inv.Invoke(ctx, "flaky", nil)
+		require.NoError(t, err)
+		assert.NotEmpty(t, res.Error)
+		assert.False(t, res.Cached)
+	}
+	assert.Equal(t, 2, sb.starts())
+}
+
+func TestInvokerIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	sb := countingSandbox()
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "charge", CustomExec: &apis.CustomBinding{Image: "charge"}}))
+	inv := NewInvoker(reg, sb)
+	store := inv.(*invoker).idempotency
+	clock := newFakeClock()
+	store.now = clock.Now
+
+	alice := WithIdempotencyKey(security.WithCaller(ctx, "alice"), "order-1")
+	args := map[string]interface{}{"amount": float64(5)}
+	res, err := inv.Invoke(alice, "charge", args)
+	require.NoError(t, err)
+	assert.Equal(t, "run 1", res.Output)
+	assert.False(t, res.Cached)
+
+	res, err = inv.Invoke(alice, "charge", args)
+	require.NoError(t, err)
+	assert.Equal(t, "run 1", res.Output)
+	assert.True(t, res.Cached)
+
+	// 同一个键换了入参
+	_, err = inv.Invoke(alice, "charge", map[string]interface{}{"amount": float64(6)})
+	assert.Equal(t, errors.KindConflict, errors.KindOf(err))
+
+	// 键按调用方隔离
+	bob := WithIdempotencyKey(security.WithCaller(ctx, "bob"), "order-1")
+	res, err = inv.Invoke(bob, "charge", args)
+	require.NoError(t, err)
+	assert.Equal(t, "run 2", res.Output)
+
+	clock.Advance(defaultIdempotencyWindow + time.Second)
+	res, err = inv.Invoke(alice, "charge", args)
+	require.NoError(t, err)
+	assert.Equal(t, "run 3", res.Output)
+
+	// 调用本身失败时不占用键，可以重试
+	sb.fail = fmt.Errorf("no capacity")
+	retry := WithIdempotencyKey(ctx, "order-2")
+	_, err = inv.Invoke(retry, "charge", args)
+	assert.Equal(t, errors.KindUnavailable, errors.KindOf(err))
+	sb.fail = nil
+	res, err = inv.Invoke(retry, "charge", args)
+	require.NoError(t, err)
+	assert.False(t, res.Cached)
+}
+
+func TestInvokerIdempotencyConcurrent(t *testing.T) {
+	ctx := context.Background()
+	gate := make(chan struct{})
+	var runs int
+	var mu sync.Mutex
+	sb := &fakeSandboxes{run: func(spec *sandbox.SandboxSpec) error {
+		<-gate
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		fmt.Fprint(spec.Stdout, "done")
+		return nil
+	}}
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "slow", CustomExec: &apis.CustomBinding{Image: "slow"}}))
+	inv := NewInvoker(reg, sb)
+
+	kctx := WithIdempotencyKey(ctx, "k")
+	var wg sync.WaitGroup
+	results := make([]*apis.ToolResult, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := inv.Invoke(kctx, "slow", nil)
+			assert.NoError(t, err)
+			results[i] = res
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+
+	assert.Equal(t, 1, runs)
+	cached := 0
+	for _, r := range results {
+		require.NotNil(t, r)
+		assert.Equal(t, "done", r.Output)
+		if r.Cached {
+			cached++
+		}
+	}
+	assert.Equal(t, 2, cached)
+}
@@ -0,0 +1,458 @@
+// pkg/tools/invoker.go
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+	"github.com/turtacn/agenticai/pkg/security"
+)
+
+// 绑定类型，用作指标标签
+const (
+	bindingMCP     = "mcp"
+	bindingOpenAPI = "openapi"
+	bindingCustom  = "custom"
+)
+
+// Invoker 统一调用入口：解析引用 → 校验入参 → 按绑定分发
+type Invoker interface {
+	// Invoke ref 语法见 ParseToolRef；工具自身的失败体现在 ToolResult.Error，
+	// 返回 error 仅表示调用未能完成（找不到工具、入参非法、后端不可达、超时）
+	Invoke(ctx context.Context, ref string, args map[string]interface{}) (*apis.ToolResult, error)
+	// InvokeStream 同 Invoke，执行过程中的进度与增量输出经 onEvent 推送；
+	// 命中缓存或幂等重放时没有中间事件。ctx 取消会中止后端调用
+	InvokeStream(ctx context.Context, ref string, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error)
+	// Close 断开缓存的 MCP 连接
+	Close() error
+}
+
+// InvokerOption 调用器可选项
+type InvokerOption func(*invoker)
+
+// WithInvokerHTTPClient 替换拉取 OpenAPI 文档、调用上游及 MCP streamable HTTP 使用的 http.Client
+func WithInvokerHTTPClient(hc *http.Client) InvokerOption {
+	return func(i *invoker) { i.http = hc }
+}
+
+// WithLimiter 替换按 工具×调用方 计算的限流器
+func WithLimiter(l Limiter) InvokerOption {
+	return func(i *invoker) { i.limiter = l }
+}
+
+// WithResultCache 替换结果缓存；只有 Cache.Cacheable 的工具会用到
+func WithResultCache(c ResultCache) InvokerOption {
+	return func(i *invoker) { i.cache = c }
+}
+
+// WithIdempotencyWindow 幂等键的去重窗口，默认 10m
+func WithIdempotencyWindow(d time.Duration) InvokerOption {
+	return func(i *invoker) { i.idempotency = newIdempotencyStore(d) }
+}
+
+// WithSandboxType CustomExec 使用的沙箱类型，默认 gvisor
+func WithSandboxType(t sandbox.Type) InvokerOption {
+	return func(i *invoker) { i.sandboxType = t }
+}
+
+var (
+	invokeLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "agenticai_tool_invoke_duration_seconds",
+		Help:    "tool invocation latency",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"tool", "binding"})
+	invokeErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "agenticai_tool_invoke_errors_total",
+		Help: "failed tool invocations; kind=tool means the tool itself reported an error",
+	}, []string{"tool", "binding", "kind"})
+)
+
+type invoker struct {
+	reg         Registry
+	limiter     Limiter
+	cache       ResultCache
+	idempotency *idempotencyStore
+	sandbox     sandbox.Manager
+	sandboxType sandbox.Type
+	http        *http.Client
+	trace       trace.Tracer
+
+	mu      sync.Mutex
+	mcp     map[string]MCPClient       // ServerURL+Headers -> 已握手的客户端
+	openapi map[string]*openAPIAdapter // 绑定 -> 已加载文档的适配器
+}
+
+// NewInvoker sb 可为 nil，此时 CustomExec 工具不可用
+func NewInvoker(reg Registry, sb sandbox.Manager, opts ...InvokerOption) Invoker {
+	i := &invoker{
+		reg:         reg,
+		limiter:     NewLimiter(),
+		cache:       NewMemoryCache(0),
+		idempotency: newIdempotencyStore(defaultIdempotencyWindow),
+		sandbox:     sb,
+		sandboxType: sandbox.TypeGvisor,
+		trace:       otel.Tracer("tools"),
+		mcp:         make(map[string]MCPClient),
+		openapi:     make(map[string]*openAPIAdapter),
+	}
+	for _, o := range opts {
+		o(i)
+	}
+	return i
+}
+
+func (i *invoker) Invoke(ctx context.Context, ref string, args map[string]interface{}) (*apis.ToolResult, error) {
+	return i.InvokeStream(ctx, ref, args, nil)
+}
+
+func (i *invoker) InvokeStream(ctx context.Context, ref string, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	ctx, span := i.trace.Start(ctx, "Invoker.Invoke")
+	defer span.End()
+	onEvent, stop := guardHandler(onEvent)
+	defer stop()
+
+	spec, err := i.reg.Resolve(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	binding := bindingOf(spec)
+	span.SetAttributes(
+		attribute.String("tool.id", spec.ID),
+		attribute.String("tool.binding", binding),
+	)
+
+	res, err := i.dispatch(ctx, spec, binding, args, onEvent)
+	switch {
+	case err != nil:
+		invokeErrors.WithLabelValues(spec.Name, binding, string(errors.KindOf(err))).Inc()
+		logger.Warn(ctx, "tool invoke failed", zap.String("tool", spec.ID), zap.Error(err))
+		return nil, err
+	case res.Error != "":
+		invokeErrors.WithLabelValues(spec.Name, binding, "tool").Inc()
+	}
+	return res, nil
+}
+
+// dispatch 校验入参 → 幂等键去重 → 结果缓存 → 限流 → 后端
+func (i *invoker) dispatch(ctx context.Context, spec *apis.ToolSpec, binding string, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	args, err := ValidateArgs(spec.ArgsSchema, args)
+	if err != nil {
+		return nil, err
+	}
+	call := callKey(spec, args)
+	key := IdempotencyKeyFromContext(ctx)
+	if key == "" {
+		return i.cached(ctx, spec, binding, args, call, onEvent)
+	}
+	res, replayed, err := i.idempotency.do(ctx, security.CallerFromContext(ctx), key, call, func() (*apis.ToolResult, error) {
+		return i.cached(ctx, spec, binding, args, call, onEvent)
+	})
+	if replayed {
+		idempotentReplays.WithLabelValues(spec.Name).Inc()
+		res.Cached = true
+	}
+	return res, err
+}
+
+// cached 仅缓存成功的结果，命中时不占用限流配额
+func (i *invoker) cached(ctx context.Context, spec *apis.ToolSpec, binding string, args map[string]interface{}, call string, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	if spec.Cache == nil || !spec.Cache.Cacheable || i.cache == nil {
+		return i.limited(ctx, spec, binding, args, onEvent)
+	}
+	if res, ok := i.cache.Get(call); ok {
+		cacheRequests.WithLabelValues(spec.Name, "hit").Inc()
+		res.Cached = true
+		return res, nil
+	}
+	cacheRequests.WithLabelValues(spec.Name, "miss").Inc()
+	res, err := i.limited(ctx, spec, binding, args, onEvent)
+	if err == nil && res.Error == "" {
+		i.cache.Set(call, res, cacheTTL(spec.Cache))
+	}
+	return res, err
+}
+
+// limited 限流排队不计入调用耗时
+func (i *invoker) limited(ctx context.Context, spec *apis.ToolSpec, binding string, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	release, err := i.limiter.Acquire(ctx, spec, security.CallerFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	start := time.Now()
+	defer func() { invokeLatency.WithLabelValues(spec.Name, binding).Observe(time.Since(start).Seconds()) }()
+	return i.invoke(ctx, spec, binding, args, onEvent)
+}
+
+func (i *invoker) invoke(ctx context.Context, spec *apis.ToolSpec, binding string, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	timeout := spec.DefaultTimeout.Duration
+	if timeout <= 0 {
+		timeout = constants.DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch binding {
+	case bindingMCP:
+		return i.invokeMCP(ctx, spec, args, onEvent)
+	case bindingOpenAPI:
+		return i.invokeOpenAPI(ctx, spec, args, onEvent)
+	case bindingCustom:
+		return i.invokeCustom(ctx, spec, args, onEvent)
+	}
+	return nil, errors.E(errors.KindValidation, fmt.Sprintf("tool %s has no binding", spec.ID))
+}
+
+func (i *invoker) Close() error {
+	i.mu.Lock()
+	clients := i.mcp
+	i.mcp = make(map[string]MCPClient)
+	i.mu.Unlock()
+	var first error
+	for _, c := range clients {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// bindingOf 多个绑定并存时按 MCP > OpenAPI > CustomExec 取第一个
+func bindingOf(spec *apis.ToolSpec) string {
+	switch {
+	case spec.MCP != nil:
+		return bindingMCP
+	case spec.OpenAPI != nil:
+		return bindingOpenAPI
+	case spec.CustomExec != nil:
+		return bindingCustom
+	}
+	return "none"
+}
+
+// ------------------ MCP ------------------
+
+func (i *invoker) invokeMCP(ctx context.Context, spec *apis.ToolSpec, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	key := mcpKey(spec.MCP)
+	c, err := i.mcpClient(ctx, key, spec.MCP)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.CallToolStream(ctx, spec.Name, args, onEvent)
+	if errors.KindOf(err) == errors.KindUnavailable {
+		// 连接已断开，丢弃以便下次重连
+		i.dropMCP(key, c)
+	}
+	return res, err
+}
+
+func (i *invoker) mcpClient(ctx context.Context, key string, b *apis.MCPBinding) (MCPClient, error) {
+	i.mu.Lock()
+	c, ok := i.mcp[key]
+	i.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	opts := []MCPOption{WithMCPHeaders(b.Headers)}
+	if i.http != nil {
+		opts = append(opts, WithMCPHTTPClient(i.http))
+	}
+	c = NewMCPClient(opts...)
+	if err := c.Connect(ctx, b.ServerURL); err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if prev, ok := i.mcp[key]; ok {
+		// 并发建连时保留先到者
+		_ = c.Close()
+		return prev, nil
+	}
+	i.mcp[key] = c
+	return c, nil
+}
+
+func (i *invoker) dropMCP(key string, c MCPClient) {
+	i.mu.Lock()
+	if i.mcp[key] == c {
+		delete(i.mcp, key)
+	}
+	i.mu.Unlock()
+	_ = c.Close()
+}
+
+// mcpKey 同一服务器、同一组请求头共享连接
+func mcpKey(b *apis.MCPBinding) string {
+	keys := make([]string, 0, len(b.Headers))
+	for k := range b.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString(b.ServerURL)
+	for _, k := range keys {
+		sb.WriteString("\n" + k + ":" + b.Headers[k])
+	}
+	return sb.String()
+}
+
+// ------------------ OpenAPI ------------------
+
+func (i *invoker) invokeOpenAPI(ctx context.Context, spec *apis.ToolSpec, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	a, err := i.openAPIAdapter(ctx, spec.OpenAPI)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{spec.OpenAPI.Operation}
+	if spec.OpenAPI.Operation == "" {
+		candidates = []string{spec.ID, spec.Name}
+	}
+	for _, name := range candidates {
+		if _, ok := a.lookup(name); ok {
+			return a.InvokeStream(ctx, name, args, onEvent)
+		}
+	}
+	return nil, errors.E(errors.KindNotFound,
+		fmt.Sprintf("tool %s: operation not found in %s", spec.ID, spec.OpenAPI.SpecURL))
+}
+
+// openAPIAdapter 按绑定缓存已解析的文档；文档只在首次调用时拉取
+func (i *invoker) openAPIAdapter(ctx context.Context, b *apis.OpenAPIBinding) (*openAPIAdapter, error) {
+	raw, _ := json.Marshal(b)
+	key := string(raw)
+	i.mu.Lock()
+	a, ok := i.openapi[key]
+	i.mu.Unlock()
+	if ok {
+		return a, nil
+	}
+
+	doc, err := fetchOpenAPISpec(ctx, i.http, b.SpecURL)
+	if err != nil {
+		return nil, err
+	}
+	opts := []OpenAPIOption{WithOpenAPIBinding(b)}
+	if i.http != nil {
+		opts = append(opts, WithOpenAPIHTTPClient(i.http))
+	}
+	a = NewOpenAPIAdapter(opts...).(*openAPIAdapter)
+	if err := a.LoadSpec(ctx, doc); err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if prev, ok := i.openapi[key]; ok {
+		return prev, nil
+	}
+	i.openapi[key] = a
+	return a, nil
+}
+
+// ------------------ CustomExec ------------------
+
+// invokeCustom 入参以 JSON 写入容器 stdin，stdout 边写边推送并作为 Output；非零退出时 stderr 作为 Error
+func (i *invoker) invokeCustom(ctx context.Context, spec *apis.ToolSpec, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	if i.sandbox == nil {
+		return nil, errors.E(errors.KindUnavailable, fmt.Sprintf("tool %s: no sandbox manager configured", spec.ID))
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	stdin, err := json.Marshal(args)
+	if err != nil {
+		return nil, errors.Validation(err, "encode tool arguments")
+	}
+	stdout := &limitedBuffer{max: maxResponseBytes}
+	stderr := &limitedBuffer{max: maxResponseBytes}
+	cmd := append(append([]string{}, spec.CustomExec.Cmd...), spec.CustomExec.Args...)
+	sbSpec := &sandbox.SandboxSpec{
+		Type:     i.sandboxType,
+		ImageRef: spec.CustomExec.Image,
+		Cmd:      cmd,
+		Resource: sandbox.ResourceLimit{CPU: constants.DefaultSandboxCPU, Mem: constants.DefaultSandboxMemory},
+		Network:  spec.NetworkPolicy != nil && len(spec.NetworkPolicy.AllowOutbound) > 0,
+		Stdin:    bytes.NewReader(stdin),
+		Stdout:   &outputWriter{buf: stdout, emit: onEvent},
+		Stderr:   stderr,
+	}
+
+	sb, err := i.sandbox.Start(ctx, sbSpec)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Timeout(err, fmt.Sprintf("invoke %s", spec.ID))
+		}
+		return nil, errors.Unavailable(err, fmt.Sprintf("start sandbox for %s", spec.ID))
+	}
+	defer func() {
+		if err := sb.Delete(context.Background()); err != nil {
+			logger.Warn(ctx, "delete sandbox", zap.String("tool", spec.ID), zap.Error(err))
+		}
+	}()
+	done := make(chan error, 1)
+	go func() { done <- sb.Wait(ctx) }()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		_ = sb.Kill(context.Background())
+		return nil, errors.Timeout(ctx.Err(), fmt.Sprintf("invoke %s", spec.ID))
+	}
+
+	out := &apis.ToolResult{Output: stdout.String(), Status: http.StatusOK}
+	if err != nil {
+		out.Status = http.StatusInternalServerError
+		out.Error = strings.TrimSpace(stderr.String())
+		if out.Error == "" {
+			out.Error = err.Error()
+		}
+	}
+	return out, nil
+}
+
+// limitedBuffer 超过上限的输出被丢弃，避免失控的工具占满内存
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.max - b.buf.Len(); room < len(p) {
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+//Personal.AI order the ending
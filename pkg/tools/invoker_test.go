@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+)
+
+// fakeSandboxes 以进程内函数代替容器，run 读 stdin 写 stdout/stderr
+type fakeSandboxes struct {
+	mu      sync.Mutex
+	specs   []*sandbox.SandboxSpec
+	run     func(spec *sandbox.SandboxSpec) error
+	fail    error // 非空时 Start 失败
+	kills   int
+	deletes int
+}
+
+func (m *fakeSandboxes) Start(_ context.Context, spec *sandbox.SandboxSpec) (sandbox.Sandbox, error) {
+	m.mu.Lock()
+	m.specs = append(m.specs, spec)
+	m.mu.Unlock()
+	if m.fail != nil {
+		return nil, m.fail
+	}
+	return &fakeSandbox{m: m, spec: spec, killed: make(chan struct{})}, nil
+}
+
+func (m *fakeSandboxes) starts() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.specs)
+}
+
+func (m *fakeSandboxes) Stop(context.Context, string) error            { return nil }
+func (m *fakeSandboxes) List(context.Context) ([]*sandbox.Info, error) { return nil, nil }
+func (m *fakeSandboxes) Close() error                                  { return nil }
+
+type fakeSandbox struct {
+	m      *fakeSandboxes
+	spec   *sandbox.SandboxSpec
+	killed chan struct{}
+}
+
+func (s *fakeSandbox) Start(context.Context) error { return nil }
+
+func (s *fakeSandbox) Kill(context.Context) error {
+	s.m.mu.Lock()
+	s.m.kills++
+	s.m.mu.Unlock()
+	close(s.killed)
+	return nil
+}
+
+func (s *fakeSandbox) Wait(context.Context) error {
+	if s.m.run == nil {
+		<-s.killed
+		return fmt.Errorf("killed")
+	}
+	return s.m.run(s.spec)
+}
+
+func (s *fakeSandbox) Delete(context.Context) error {
+	s.m.mu.Lock()
+	s.m.deletes++
+	s.m.mu.Unlock()
+	return nil
+}
+
+func (s *fakeSandbox) Info(context.Context) (*sandbox.Info, error) {
+	return &sandbox.Info{ID: s.spec.ImageRef}, nil
+}
+
+// counterDelta 返回 c 自此刻起的增量；包级计数器在 -count>1 时跨轮累积
+func counterDelta(c prometheus.Counter) func() float64 {
+	before := testutil.ToFloat64(c)
+	return func() float64 { return testutil.ToFloat64(c) - before }
+}
+
+func TestInvokerMCP(t *testing.T) {
+	srv := newFakeMCPServer()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	ctx := context.Background()
+
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{
+		Name: "echo", Version: "1.0.0",
+		ArgsSchema: apis.AnyMap{"type": "object", "required": []interface{}{"msg"}},
+		MCP:        &apis.MCPBinding{ServerURL: ts.URL},
+	}))
+	inv := NewInvoker(reg, nil)
+	defer inv.Close()
+
+	for _, msg := range []string{"one", "two"} {
+		res, err := inv.Invoke(ctx, "echo@^1", map[string]interface{}{"msg": msg})
+		require.NoError(t, err)
+		assert.Equal(t, msg, res.Output)
+	}
+	// 连接被复用
+	assert.Len(t, inv.(*invoker).mcp, 1)
+	require.Len(t, srv.calls, 2)
+	assert.Equal(t, "echo", srv.calls[1].Name)
+
+	// 入参先按注册的 ArgsSchema 校验
+	invalid := counterDelta(invokeErrors.WithLabelValues("echo", bindingMCP, string(errors.KindValidation)))
+	_, err := inv.Invoke(ctx, "echo", map[string]interface{}{})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	assert.Len(t, srv.calls, 2)
+	assert.Equal(t, 1.0, invalid())
+
+	_, err = inv.Invoke(ctx, "missing", nil)
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestInvokerOpenAPI(t *testing.T) {
+	var rec recordedRequest
+	upstream := petstoreServer(t, &rec)
+	var fetches int
+	specSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_, _ = io.WriteString(w, petstoreSpec)
+	}))
+	defer specSrv.Close()
+	ctx := context.Background()
+
+	binding := &apis.OpenAPIBinding{SpecURL: specSrv.URL + "/openapi.yaml", BaseURL: upstream.URL + "/v1"}
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{ID: "PUT /pets/{id}", Name: "updatePet", Version: "1.0.0", OpenAPI: binding}))
+	aliased := *binding
+	aliased.Operation = "GET /pets"
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "list-pets", Version: "1.0.0", OpenAPI: &aliased}))
+	inv := NewInvoker(reg, nil)
+
+	res, err := inv.Invoke(ctx, "updatePet", map[string]interface{}{"id": float64(7), "body": map[string]interface{}{"name": "rex"}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(http.StatusOK), res.Status)
+	assert.Equal(t, "/v1/pets/7", rec.path)
+
+	toolErrs := counterDelta(invokeErrors.WithLabelValues("updatePet", bindingOpenAPI, "tool"))
+	res, err = inv.Invoke(ctx, "updatePet", map[string]interface{}{"id": float64(404), "body": map[string]interface{}{"name": "ghost"}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(http.StatusNotFound), res.Status)
+	assert.Equal(t, 1.0, toolErrs())
+	assert.Equal(t, 1, fetches)
+
+	_, err = inv.Invoke(ctx, "list-pets", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, rec.method)
+	assert.Equal(t, "/v1/pets", rec.path)
+	assert.Equal(t, 2, fetches)
+
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{ID: "ghost", Name: "ghost", OpenAPI: binding}))
+	_, err = inv.Invoke(ctx, "ghost", nil)
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestInvokerCustom(t *testing.T) {
+	ctx := context.Background()
+	sb := &fakeSandboxes{run: func(spec *sandbox.SandboxSpec) error {
+		var args map[string]interface{}
+		if err := json.NewDecoder(spec.Stdin).Decode(&args); err != nil {
+			return err
+		}
+		if args["fail"] == true {
+			fmt.Fprintln(spec.Stderr, "bad input")
+			return fmt.Errorf("exit status 2")
+		}
+		return json.NewEncoder(spec.Stdout).Encode(map[string]interface{}{"sum": args["a"].(float64) + args["b"].(float64)})
+	}}
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{
+		Name: "add",
+		ArgsSchema: apis.AnyMap{"type": "object", "properties": map[string]interface{}{
+			"a": map[string]interface{}{"type": "number"},
+			"b": map[string]interface{}{"type": "number", "default": float64(1)},
+		}},
+		CustomExec: &apis.CustomBinding{Image: "registry.local/add:1", Cmd: []string{"/add"}, Args: []string{"--json"}},
+	}))
+	inv := NewInvoker(reg, sb, WithSandboxType(sandbox.TypeKata))
+
+	// 字符串被强制转换，缺省值被补齐后才写入 stdin
+	res, err := inv.Invoke(ctx, "add", map[string]interface{}{"a": "2"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sum":3}`, res.Output)
+	assert.Equal(t, int32(http.StatusOK), res.Status)
+	require.Len(t, sb.specs, 1)
+	assert.Equal(t, sandbox.Type(sandbox.TypeKata), sb.specs[0].Type)
+	assert.Equal(t, "registry.local/add:1", sb.specs[0].ImageRef)
+	assert.Equal(t, []string{"/add", "--json"}, sb.specs[0].Cmd)
+
+	res, err = inv.Invoke(ctx, "add", map[string]interface{}{"fail": true})
+	require.NoError(t, err)
+	assert.Equal(t, "bad input", res.Error)
+	assert.Equal(t, int32(http.StatusInternalServerError), res.Status)
+	// 成功与失败的调用都删除沙箱
+	assert.Equal(t, 2, sb.deletes)
+
+	_, err = NewInvoker(reg, nil).Invoke(ctx, "add", nil)
+	assert.Equal(t, errors.KindUnavailable, errors.KindOf(err))
+}
+
+func TestInvokerTimeout(t *testing.T) {
+	ctx := context.Background()
+	sb := &fakeSandboxes{}
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{
+		Name:           "sleep",
+		CustomExec:     &apis.CustomBinding{Image: "sleep"},
+		DefaultTimeout: metav1.Duration{Duration: 20 * time.Millisecond},
+	}))
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "unbound"}))
+	inv := NewInvoker(reg, sb)
+
+	timeouts := counterDelta(invokeErrors.WithLabelValues("sleep", bindingCustom, string(errors.KindTimeout)))
+	_, err := inv.Invoke(ctx, "sleep", nil)
+	assert.Equal(t, errors.KindTimeout, errors.KindOf(err))
+	assert.Equal(t, 1, sb.kills)
+	assert.Equal(t, 1, sb.deletes)
+	assert.Equal(t, 1.0, timeouts())
+
+	_, err = inv.Invoke(ctx, "unbound", nil)
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+}
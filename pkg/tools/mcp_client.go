@@ -3,113 +3,447 @@ package tools
 
 import (
 	"context"
-	"errors"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
 	"github.com/turtacn/agenticai/internal/logger"
 	"github.com/turtacn/agenticai/pkg/apis"
-	// pb "githu" // 省略自动生成pb前缀包
 )
 
+// mcpProtocolVersion 客户端声明的 MCP 协议版本
+const mcpProtocolVersion = "2025-06-18"
+
+// maxListPages 防止服务端 nextCursor 死循环
+const maxListPages = 100
+
+// cancelNotifyTimeout 发送 notifications/cancelled 的超时
+const cancelNotifyTimeout = 2 * time.Second
+
 // MCPClient 协议客户端
 type MCPClient interface {
 	Connect(ctx context.Context, addr string) error
 	Close() error
-	ListTools(ctx context.Context) ([]*apis.Metadata, error)
+	ListTools(ctx context.Context) ([]*apis.ToolSpec, error)
 	CallTool(ctx context.Context, name string, args map[string]interface{}) (*apis.ToolResult, error)
+	// CallToolStream 附带 progressToken 调用，服务端的 notifications/progress 转为 progress 事件；
+	// ctx 取消时向服务端发送 notifications/cancelled
+	CallToolStream(ctx context.Context, name string, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error)
 	ServerInfo(ctx context.Context) (*apis.MCPServerInfo, error)
 }
 
+// MCPOption 客户端可选项
+type MCPOption func(*mcpClient)
+
+// WithMCPHeaders 附加 HTTP 头（stdio 下作为子进程环境变量）
+func WithMCPHeaders(h map[string]string) MCPOption {
+	return func(c *mcpClient) { c.headers = h }
+}
+
+// WithMCPHTTPClient 替换 streamable HTTP 使用的 http.Client
+func WithMCPHTTPClient(hc *http.Client) MCPOption {
+	return func(c *mcpClient) { c.http = hc }
+}
+
 type mcpClient struct {
-	conn *grpc.ClientConn
-	// client   pb.MCPServerClient
-	trace trace.Tracer
+	headers map[string]string
+	http    *http.Client
+	trace   trace.Tracer
+
+	nextID atomic.Int64
+
+	mu        sync.RWMutex
+	transport mcpTransport // 在途调用取快照，Close/重连时替换
+	info      *apis.MCPServerInfo
+	schemas   map[string]apis.AnyMap      // ListTools 缓存的 inputSchema，CallTool 前校验
+	progress  map[string]ToolEventHandler // progressToken -> 在途调用的事件回调
 }
 
-func NewMCPClient() MCPClient {
-	return &mcpClient{
-		trace: otel.Tracer("mcp"),
+func NewMCPClient(opts ...MCPOption) MCPClient {
+	c := &mcpClient{
+		trace:    otel.Tracer("mcp"),
+		progress: make(map[string]ToolEventHandler),
 	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
 }
 
+// Connect 按地址 scheme 选择传输：stdio:// 或 http(s)://，随后完成 initialize 握手
 func (c *mcpClient) Connect(ctx context.Context, addr string) error {
 	ctx, span := c.trace.Start(ctx, "MCPClient.Connect")
 	defer span.End()
 
-	dial, err := grpc.DialContext(ctx, addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-		grpc.WithTimeout(5*time.Second))
+	u, err := url.Parse(addr)
+	if err != nil {
+		return errors.Validation(err, fmt.Sprintf("invalid mcp address %q", addr))
+	}
+	var t mcpTransport
+	switch u.Scheme {
+	case "stdio":
+		t, err = dialStdio(ctx, u, c.headers, c.onNotify)
+	case "http", "https":
+		t = newHTTPTransport(addr, c.http, c.headers, c.onNotify)
+	default:
+		return errors.E(errors.KindValidation, fmt.Sprintf("unsupported mcp scheme %q", u.Scheme))
+	}
 	if err != nil {
-		return fmt.Errorf("mcp connect fail %w", err)
+		return errors.Unavailable(err, "mcp connect fail")
+	}
+	if err := c.connectTransport(ctx, t); err != nil {
+		_ = t.Close()
+		return err
 	}
-	c.conn = dial
-	// c.client = pb.NewMCPServerClient(dial)
-	logger.Info(ctx, "mcp connected", zap.String("addr", addr))
+	_, info := c.conn()
+	logger.Info(ctx, "mcp connected", zap.String("addr", addr),
+		zap.String("server", info.Name), zap.String("protocol", info.ProtocolVersion))
 	return nil
 }
 
-func (c *mcpClient) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
+// connectTransport 在已建立的传输上执行 initialize 握手，成功后替换并关闭原有传输
+func (c *mcpClient) connectTransport(ctx context.Context, t mcpTransport) error {
+	var res initializeResult
+	err := c.callOn(ctx, t, c.nextID.Add(1), "initialize", initializeParams{
+		ProtocolVersion: mcpProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      implementation{Name: constants.ProjectName, Version: constants.Version},
+	}, &res)
+	if err != nil {
+		return err
+	}
+	if ht, ok := t.(*httpTransport); ok {
+		ht.setProtocol(res.ProtocolVersion)
+	}
+	note, err := newNotification("notifications/initialized", nil)
+	if err != nil {
+		return err
+	}
+	if err := t.Notify(ctx, note); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	old := c.transport
+	c.transport, c.info = t, res.serverInfo()
+	c.mu.Unlock()
+	if old != nil {
+		_ = old.Close()
 	}
 	return nil
 }
 
-func (c *mcpClient) ListTools(ctx context.Context) ([]*apis.Metadata, error) {
-	// ctx, span := c.trace.Start(ctx, "MCPClient.ListTools")
-	// defer span.End()
-	// resp, err := c.client.ListTools(ctx, &pb.ListToolsRequest{})
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// out := make([]*types.Metadata, 0, len(resp.Tools))
-	// for _, t := range resp.Tools {
-	// 	out = append(out, &types.Metadata{
-	// 		ID:      t.Id,
-	// 		Name:    t.Name,
-	// 		Version: t.Version,
-	// 		Digest:  t.Digest,
-	// 	})
-	// }
-	// return out, nil
-	return nil, errors.New("not implemented")
+// conn 当前传输与服务端信息的快照
+func (c *mcpClient) conn() (mcpTransport, *apis.MCPServerInfo) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.transport, c.info
 }
 
+func (c *mcpClient) Close() error {
+	c.mu.Lock()
+	t := c.transport
+	c.transport = nil
+	c.mu.Unlock()
+	if t == nil {
+		return nil
+	}
+	return t.Close()
+}
+
+// ListTools 调用 tools/list 并跟随 nextCursor 翻页
+func (c *mcpClient) ListTools(ctx context.Context) ([]*apis.ToolSpec, error) {
+	ctx, span := c.trace.Start(ctx, "MCPClient.ListTools")
+	defer span.End()
+
+	_, info := c.conn()
+	var out []*apis.ToolSpec
+	schemas := make(map[string]apis.AnyMap)
+	cursor := ""
+	for page := 0; page < maxListPages; page++ {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		var res listToolsResult
+		if err := c.call(ctx, "tools/list", params, &res); err != nil {
+			return nil, err
+		}
+		for _, t := range res.Tools {
+			out = append(out, t.toSpec(info))
+			schemas[t.Name] = t.InputSchema
+		}
+		if res.NextCursor == "" {
+			span.SetAttributes(attribute.Int("mcp.tools", len(out)))
+			c.mu.Lock()
+			c.schemas = schemas
+			c.mu.Unlock()
+			return out, nil
+		}
+		cursor = res.NextCursor
+	}
+	return nil, errors.E(errors.KindInternal, fmt.Sprintf("tools/list exceeded %d pages", maxListPages))
+}
+
+// CallTool 若此前 ListTools 见过该工具，先按其 inputSchema 校验并补默认值
 func (c *mcpClient) CallTool(ctx context.Context, name string, args map[string]interface{}) (*apis.ToolResult, error) {
-	// ctx, span := c.trace.Start(ctx, "MCPClient.CallTool")
-	// defer span.End()
-	// resp, err := c.client.CallTool(ctx, &pb.CallToolRequest{
-	// 	ToolId:    name,
-	// 	Arguments: args,
-	// })
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// return &types.ToolResult{
-	// 	Output: resp.Output,
-	// 	Error:  resp.Error,
-	// 	Status: resp.Status,
-	// }, nil
-	return nil, errors.New("not implemented")
+	return c.CallToolStream(ctx, name, args, nil)
+}
+
+func (c *mcpClient) CallToolStream(ctx context.Context, name string, args map[string]interface{}, onEvent ToolEventHandler) (*apis.ToolResult, error) {
+	ctx, span := c.trace.Start(ctx, "MCPClient.CallTool")
+	defer span.End()
+	span.SetAttributes(attribute.String("mcp.tool", name))
+
+	c.mu.RLock()
+	schema := c.schemas[name]
+	c.mu.RUnlock()
+	args, err := ValidateArgs(schema, args)
+	if err != nil {
+		return nil, err
+	}
+	id := c.nextID.Add(1)
+	params := callToolParams{Name: name, Arguments: args}
+	if onEvent != nil {
+		token := strconv.FormatInt(id, 10)
+		params.Meta = &requestMeta{ProgressToken: token}
+		c.mu.Lock()
+		c.progress[token] = onEvent
+		c.mu.Unlock()
+		defer func() {
+			c.mu.Lock()
+			delete(c.progress, token)
+			c.mu.Unlock()
+		}()
+	}
+	var res callToolResult
+	if err := c.callID(ctx, id, "tools/call", params, &res); err != nil {
+		return nil, err
+	}
+	return res.toResult(), nil
 }
 
 func (c *mcpClient) ServerInfo(ctx context.Context) (*apis.MCPServerInfo, error) {
-	// resp, err := c.client.GetServerInfo(ctx, &pb.GetServerInfoRequest{})
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// return &types.MCPServerInfo{
-	// 	Name:    resp.Name,
-	// 	Version: resp.Version,
-	// }, nil
-	return nil, errors.New("not implemented")
+	_, info := c.conn()
+	if info == nil {
+		return nil, errors.E(errors.KindUnavailable, "mcp client not connected")
+	}
+	cp := *info
+	return &cp, nil
+}
+
+// call 发送请求并把 result 解到 out
+func (c *mcpClient) call(ctx context.Context, method string, params, out interface{}) error {
+	return c.callID(ctx, c.nextID.Add(1), method, params, out)
+}
+
+func (c *mcpClient) callID(ctx context.Context, id int64, method string, params, out interface{}) error {
+	t, _ := c.conn()
+	if t == nil {
+		return errors.E(errors.KindUnavailable, "mcp client not connected")
+	}
+	return c.callOn(ctx, t, id, method, params, out)
 }
+
+// callOn 在指定传输上调用，调用期间传输被替换不影响本次请求
+func (c *mcpClient) callOn(ctx context.Context, t mcpTransport, id int64, method string, params, out interface{}) error {
+	req, err := newRequest(id, method, params)
+	if err != nil {
+		return errors.Internal(err, "build mcp request")
+	}
+	resp, err := t.Call(ctx, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			if method != "initialize" {
+				c.cancel(t, id, ctx.Err())
+			}
+			return errors.Timeout(err, fmt.Sprintf("mcp %s", method))
+		}
+		return errors.Unavailable(err, fmt.Sprintf("mcp %s", method))
+	}
+	if resp.Error != nil {
+		kind := errors.KindInternal
+		switch resp.Error.Code {
+		case rpcMethodNotFound:
+			kind = errors.KindNotFound
+		case rpcInvalidParams:
+			kind = errors.KindValidation
+		}
+		return errors.E(kind, fmt.Sprintf("mcp %s", method), error(resp.Error))
+	}
+	if out == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return errors.Internal(err, fmt.Sprintf("decode mcp %s result", method))
+	}
+	return nil
+}
+
+// cancel 通知服务端放弃仍在执行的请求；尽力而为，失败只记录
+func (c *mcpClient) cancel(t mcpTransport, id int64, reason error) {
+	msg, err := newNotification("notifications/cancelled", cancelledParams{RequestID: id, Reason: reason.Error()})
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cancelNotifyTimeout)
+	defer cancel()
+	if err := t.Notify(ctx, msg); err != nil {
+		logger.Debug(ctx, "mcp cancel notification failed", zap.Int64("id", id), zap.Error(err))
+	}
+}
+
+// onNotify 处理服务端通知：progress 转交给对应调用，其余仅记录
+func (c *mcpClient) onNotify(msg *rpcMessage) {
+	if msg.Method != "notifications/progress" {
+		logger.Debug(context.Background(), "mcp notification", zap.String("method", msg.Method))
+		return
+	}
+	var p progressParams
+	if err := json.Unmarshal(msg.Params, &p); err != nil {
+		logger.Warn(context.Background(), "mcp: drop malformed progress", zap.Error(err))
+		return
+	}
+	// token 可能被服务端以数字或字符串回传
+	token := strings.Trim(string(p.ProgressToken), `"`)
+	c.mu.RLock()
+	h := c.progress[token]
+	c.mu.RUnlock()
+	h.emit(apis.ToolEvent{Type: apis.ToolEventProgress, Progress: p.Progress, Total: p.Total, Message: p.Message})
+}
+
+// ------------------ MCP 消息体 ------------------
+
+type implementation struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ClientInfo      implementation         `json:"clientInfo"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string                     `json:"protocolVersion"`
+	Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	ServerInfo      implementation             `json:"serverInfo"`
+	Instructions    string                     `json:"instructions,omitempty"`
+}
+
+func (r *initializeResult) serverInfo() *apis.MCPServerInfo {
+	caps := make([]string, 0, len(r.Capabilities))
+	for k := range r.Capabilities {
+		caps = append(caps, k)
+	}
+	sort.Strings(caps)
+	return &apis.MCPServerInfo{
+		Name:            r.ServerInfo.Name,
+		Version:         r.ServerInfo.Version,
+		ProtocolVersion: r.ProtocolVersion,
+		Instructions:    r.Instructions,
+		Capabilities:    caps,
+	}
+}
+
+type mcpTool struct {
+	Name         string      `json:"name"`
+	Title        string      `json:"title,omitempty"`
+	Description  string      `json:"description,omitempty"`
+	InputSchema  apis.AnyMap `json:"inputSchema"`
+	OutputSchema apis.AnyMap `json:"outputSchema,omitempty"`
+}
+
+func (t *mcpTool) toSpec(info *apis.MCPServerInfo) *apis.ToolSpec {
+	spec := &apis.ToolSpec{
+		ID:          t.Name,
+		Name:        t.Name,
+		DisplayName: t.Title,
+		Description: t.Description,
+		Category:    "mcp",
+		ArgsSchema:  t.InputSchema,
+	}
+	if info != nil {
+		spec.Version = info.Version
+		spec.Author = info.Name
+	}
+	return spec
+}
+
+type listToolsResult struct {
+	Tools      []mcpTool `json:"tools"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+	Meta      *requestMeta           `json:"_meta,omitempty"`
+}
+
+type requestMeta struct {
+	ProgressToken string `json:"progressToken,omitempty"`
+}
+
+type progressParams struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      float64         `json:"progress"`
+	Total         float64         `json:"total,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
+
+type cancelledParams struct {
+	RequestID int64  `json:"requestId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type callToolResult struct {
+	Content           []apis.ToolContent `json:"content"`
+	StructuredContent apis.AnyMap        `json:"structuredContent,omitempty"`
+	IsError           bool               `json:"isError,omitempty"`
+}
+
+// toResult 文本块拼接为 Output；isError 时写入 Error 并置 500
+func (r *callToolResult) toResult() *apis.ToolResult {
+	var texts []string
+	for _, c := range r.Content {
+		if c.Type == "text" {
+			texts = append(texts, c.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	if text == "" && r.StructuredContent != nil {
+		raw, _ := json.Marshal(r.StructuredContent)
+		text = string(raw)
+	}
+	out := &apis.ToolResult{
+		Status:            http.StatusOK,
+		Content:           r.Content,
+		StructuredContent: r.StructuredContent,
+	}
+	if r.IsError {
+		out.Status = http.StatusInternalServerError
+		out.Error = text
+	} else {
+		out.Output = text
+	}
+	return out
+}
+
 //Personal.AI order the ending
@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+)
+
+// fakeMCPServer is a minimal in-process MCP server used to exercise the client.
+type fakeMCPServer struct {
+	pageSize  int
+	tools     []mcpTool
+	calls     []callToolParams
+	cancelled chan cancelledParams
+}
+
+func newFakeMCPServer() *fakeMCPServer {
+	return &fakeMCPServer{
+		pageSize:  1,
+		cancelled: make(chan cancelledParams, 8),
+		tools: []mcpTool{
+			{Name: "echo", Description: "echo input", InputSchema: map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"msg"},
+				"properties": map[string]interface{}{
+					"msg":    map[string]interface{}{"type": "string"},
+					"repeat": map[string]interface{}{"type": "integer", "default": float64(1)},
+				},
+			}},
+			{Name: "fail", Description: "always fails", InputSchema: map[string]interface{}{"type": "object"}},
+		},
+	}
+}
+
+func (s *fakeMCPServer) handle(msg *rpcMessage) *rpcMessage {
+	switch msg.Method {
+	case "initialize":
+		return newResponse(msg.ID, map[string]interface{}{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{"listChanged": true}, "logging": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "fake", "version": "1.2.3"},
+			"instructions":    "be nice",
+		})
+	case "tools/list":
+		var p struct {
+			Cursor string `json:"cursor"`
+		}
+		_ = json.Unmarshal(msg.Params, &p)
+		start := 0
+		fmt.Sscanf(p.Cursor, "%d", &start)
+		end := start + s.pageSize
+		res := listToolsResult{Tools: s.tools[start:end]}
+		if end < len(s.tools) {
+			res.NextCursor = fmt.Sprintf("%d", end)
+		}
+		return newResponse(msg.ID, res)
+	case "tools/call":
+		var p callToolParams
+		_ = json.Unmarshal(msg.Params, &p)
+		s.calls = append(s.calls, p)
+		if p.Name == "hang" {
+			return nil
+		}
+		if p.Name == "fail" {
+			return newResponse(msg.ID, map[string]interface{}{
+				"content": []map[string]string{{"type": "text", "text": "boom"}},
+				"isError": true,
+			})
+		}
+		return newResponse(msg.ID, map[string]interface{}{
+			"content":           []map[string]string{{"type": "text", "text": fmt.Sprintf("%v", p.Arguments["msg"])}},
+			"structuredContent": map[string]interface{}{"msg": p.Arguments["msg"]},
+		})
+	}
+	return newErrorResponse(msg.ID, rpcMethodNotFound, "method not found")
+}
+
+// progress emits two progress notifications when the request carries a progressToken.
+func (s *fakeMCPServer) progress(msg *rpcMessage) []*rpcMessage {
+	var p callToolParams
+	if msg.Method != "tools/call" || json.Unmarshal(msg.Params, &p) != nil || p.Meta == nil {
+		return nil
+	}
+	var out []*rpcMessage
+	for i := 1; i <= 2; i++ {
+		n, _ := newNotification("notifications/progress", map[string]interface{}{
+			"progressToken": p.Meta.ProgressToken, "progress": i, "total": 2, "message": fmt.Sprintf("step %d", i),
+		})
+		out = append(out, n)
+	}
+	return out
+}
+
+// serveStdio answers newline-delimited messages until the reader is closed.
+func (s *fakeMCPServer) serveStdio(r io.Reader, w io.Writer) {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	for {
+		var msg rpcMessage
+		if err := dec.Decode(&msg); err != nil {
+			return
+		}
+		if msg.isNotification() {
+			if msg.Method == "notifications/cancelled" {
+				var p cancelledParams
+				_ = json.Unmarshal(msg.Params, &p)
+				s.cancelled <- p
+			}
+			continue
+		}
+		for _, n := range s.progress(&msg) {
+			_ = enc.Encode(n)
+		}
+		if resp := s.handle(&msg); resp != nil {
+			_ = enc.Encode(resp)
+		}
+	}
+}
+
+// ServeHTTP answers requests with SSE so that the stream path is covered.
+func (s *fakeMCPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var msg rpcMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg.isNotification() {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if msg.Method == "initialize" {
+		w.Header().Set(mcpSessionHeader, "session-1")
+	} else if r.Header.Get(mcpSessionHeader) != "session-1" {
+		http.Error(w, "missing session", http.StatusBadRequest)
+		return
+	}
+	raw, _ := json.Marshal(s.handle(&msg))
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\"}\n\n")
+	for _, n := range s.progress(&msg) {
+		nraw, _ := json.Marshal(n)
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", nraw)
+	}
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", raw)
+}
+
+func pipeClient(t *testing.T, srv *fakeMCPServer) *mcpClient {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go srv.serveStdio(serverR, serverW)
+
+	c := NewMCPClient().(*mcpClient)
+	require.NoError(t, c.connectTransport(context.Background(), newStdioTransport(clientR, clientW, c.onNotify)))
+	t.Cleanup(func() {
+		serverW.Close()
+		c.Close()
+	})
+	return c
+}
+
+func TestMCPClientStdio(t *testing.T) {
+	srv := newFakeMCPServer()
+	c := pipeClient(t, srv)
+	ctx := context.Background()
+
+	info, err := c.ServerInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "fake", info.Name)
+	assert.Equal(t, "1.2.3", info.Version)
+	assert.Equal(t, mcpProtocolVersion, info.ProtocolVersion)
+	assert.Equal(t, []string{"logging", "tools"}, info.Capabilities)
+
+	// pageSize=1 forces the client to follow nextCursor
+	tools, err := c.ListTools(ctx)
+	require.NoError(t, err)
+	require.Len(t, tools, 2)
+	assert.Equal(t, "echo", tools[0].Name)
+	assert.Equal(t, "fail", tools[1].Name)
+	assert.Equal(t, "object", tools[0].ArgsSchema["type"])
+
+	res, err := c.CallTool(ctx, "echo", map[string]interface{}{"msg": "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "hi", res.Output)
+	assert.Equal(t, int32(http.StatusOK), res.Status)
+	assert.Equal(t, "hi", res.StructuredContent["msg"])
+
+	res, err = c.CallTool(ctx, "fail", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "boom", res.Error)
+	assert.Equal(t, int32(http.StatusInternalServerError), res.Status)
+
+	err = c.call(ctx, "resources/list", nil, nil)
+	assert.Error(t, err)
+}
+
+func TestMCPClientHTTP(t *testing.T) {
+	srv := newFakeMCPServer()
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c := NewMCPClient()
+	ctx := context.Background()
+	require.NoError(t, c.Connect(ctx, ts.URL))
+	defer c.Close()
+
+	tools, err := c.ListTools(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tools, 2)
+
+	res, err := c.CallTool(ctx, "echo", map[string]interface{}{"msg": "over http"})
+	require.NoError(t, err)
+	assert.Equal(t, "over http", res.Output)
+	require.Len(t, srv.calls, 1)
+	assert.Equal(t, "echo", srv.calls[0].Name)
+}
+
+// 在途调用与 Close/重连并发时只会得到错误，不会读到被替换一半的连接（配合 -race）
+func TestMCPClientConcurrentReconnect(t *testing.T) {
+	ts := httptest.NewServer(newFakeMCPServer())
+	defer ts.Close()
+	c := NewMCPClient()
+	ctx := context.Background()
+	require.NoError(t, c.Connect(ctx, ts.URL))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, _ = c.ListTools(ctx)
+				_, _ = c.ServerInfo(ctx)
+			}
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c.Close())
+		require.NoError(t, c.Connect(ctx, ts.URL))
+	}
+	close(stop)
+	wg.Wait()
+	_, err := c.ListTools(ctx)
+	assert.NoError(t, err)
+	require.NoError(t, c.Close())
+	_, err = c.ListTools(ctx)
+	assert.Equal(t, errors.KindUnavailable, errors.KindOf(err))
+}
+
+func TestMCPClientValidatesArgs(t *testing.T) {
+	srv := newFakeMCPServer()
+	c := pipeClient(t, srv)
+	ctx := context.Background()
+
+	_, err := c.ListTools(ctx)
+	require.NoError(t, err)
+
+	// 不合法入参不会发到服务端
+	_, err = c.CallTool(ctx, "echo", map[string]interface{}{"msg": map[string]interface{}{}})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "/msg")
+	assert.Empty(t, srv.calls)
+
+	_, err = c.CallTool(ctx, "echo", map[string]interface{}{"msg": 5})
+	require.NoError(t, err)
+	require.Len(t, srv.calls, 1)
+	assert.Equal(t, "5", srv.calls[0].Arguments["msg"])
+	assert.Equal(t, float64(1), srv.calls[0].Arguments["repeat"])
+}
+
+func TestMCPClientUnsupportedScheme(t *testing.T) {
+	err := NewMCPClient().Connect(context.Background(), "grpc://localhost:1234")
+	assert.Error(t, err)
+}
@@ -0,0 +1,609 @@
+// pkg/tools/mcp_server.go
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/types"
+)
+
+// ToolVerbInvoke RBAC 中调用工具的动作，资源见 ToolResource
+const ToolVerbInvoke = "invoke"
+
+// ToolResource 工具在 RBAC 中的资源名
+func ToolResource(name string) string { return "tools/" + name }
+
+const (
+	// mcpServerName initialize 返回的 serverInfo.name
+	mcpServerName = constants.ProjectName + "-tool-gateway"
+	// mcpListPageSize tools/list 每页条数
+	mcpListPageSize = 100
+	// mcpSessionIdleTTL HTTP 会话空闲超过该时长即失效
+	mcpSessionIdleTTL = 30 * time.Minute
+)
+
+// mcpSupportedVersions 服务端接受的协议版本，客户端请求其一时原样返回，否则返回最新版
+var mcpSupportedVersions = map[string]bool{
+	mcpProtocolVersion: true,
+	"2025-03-26":       true,
+}
+
+// MCPServerOption 服务端可选项
+type MCPServerOption func(*MCPServer)
+
+// WithMCPAuthorizer 按调用方过滤 tools/list 并拒绝越权的 tools/call；未设置时不做授权
+func WithMCPAuthorizer(r security.RBAC) MCPServerOption {
+	return func(s *MCPServer) { s.rbac = r }
+}
+
+// MCPServer 把 Registry 中的工具以 MCP 协议暴露，tools/call 经 Invoker 执行，
+// 与内部 Agent 走同一条调用链（校验、限流、缓存、幂等）。
+// ServeStdio 服务单个客户端；作为 http.Handler 时实现 streamable HTTP
+type MCPServer struct {
+	reg   Registry
+	inv   Invoker
+	rbac  security.RBAC
+	trace trace.Tracer
+	now   func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*mcpSession
+}
+
+// mcpSession 一个已 initialize 的客户端；HTTP 会话绑定发起 initialize 的调用方
+type mcpSession struct {
+	id     string
+	caller string
+
+	mu       sync.Mutex
+	lastSeen time.Time
+	inflight map[string]context.CancelFunc // 请求 id -> 取消函数，供 notifications/cancelled 使用
+}
+
+func NewMCPServer(reg Registry, inv Invoker, opts ...MCPServerOption) *MCPServer {
+	s := &MCPServer{
+		reg:      reg,
+		inv:      inv,
+		trace:    otel.Tracer("mcp"),
+		now:      time.Now,
+		sessions: make(map[string]*mcpSession),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// ------------------ stdio ------------------
+
+// ServeStdio 以换行分隔的 JSON 消息读写，直到 r 结束或 ctx 取消；
+// 调用方身份取自 ctx（security.WithCaller）。请求并发处理，以便处理 notifications/cancelled
+func (s *MCPServer) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sess := newMCPSession("", security.CallerFromContext(ctx), s.now())
+
+	var writeMu sync.Mutex
+	write := func(msg *rpcMessage) {
+		raw, err := json.Marshal(msg)
+		if err != nil {
+			logger.Error(ctx, "mcp stdio: marshal message", zap.Error(err))
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if _, err := w.Write(append(raw, '\n')); err != nil {
+			logger.Warn(ctx, "mcp stdio: write failed", zap.Error(err))
+		}
+	}
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for sc.Scan() {
+			line := append([]byte(nil), bytes.TrimSpace(sc.Bytes())...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- sc.Err()
+	}()
+
+	// 输入结束时等在途请求写完响应；ctx 取消时它们随之中止
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		var line []byte
+		select {
+		case line = <-lines:
+		case err := <-readErr:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+		if len(line) == 0 {
+			continue
+		}
+		var msg rpcMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			write(newErrorResponse(json.RawMessage("null"), rpcParseError, "parse error: "+err.Error()))
+			continue
+		}
+		if !msg.isRequest() {
+			s.handleNotification(ctx, sess, &msg)
+			continue
+		}
+		reqCtx, done := sess.begin(ctx, msg.ID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer done()
+			if resp := s.handle(reqCtx, sess, &msg, write); resp != nil {
+				write(resp)
+			}
+		}()
+	}
+}
+
+// ------------------ streamable HTTP ------------------
+
+// ServeHTTP POST 承载单条 JSON-RPC 消息；带 progressToken 且客户端接受 text/event-stream 的
+// tools/call 以 SSE 推送进度并以响应收尾，其余请求返回单个 JSON。DELETE 结束会话。
+// 不提供服务端主动推送的 GET 流。调用方身份取自请求 ctx，由外层认证中间件写入
+func (s *MCPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.servePost(w, r)
+	case http.MethodDelete:
+		sess, status := s.session(r)
+		if sess == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		s.mu.Lock()
+		delete(s.sessions, sess.id)
+		s.mu.Unlock()
+		sess.cancelAll()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *MCPServer) servePost(w http.ResponseWriter, r *http.Request) {
+	if v := r.Header.Get(mcpProtocolHeader); v != "" && !mcpSupportedVersions[v] {
+		http.Error(w, fmt.Sprintf("unsupported %s %q", mcpProtocolHeader, v), http.StatusBadRequest)
+		return
+	}
+	var msg rpcMessage
+	if err := json.NewDecoder(io.LimitReader(r.Body, 16*1024*1024)).Decode(&msg); err != nil {
+		writeRPC(w, newErrorResponse(json.RawMessage("null"), rpcParseError, "parse error: "+err.Error()))
+		return
+	}
+	ctx := r.Context()
+
+	if msg.Method == "initialize" && msg.isRequest() {
+		sess := newMCPSession(newSessionID(), security.CallerFromContext(ctx), s.now())
+		resp := s.handle(ctx, sess, &msg, nil)
+		if resp.Error == nil {
+			s.mu.Lock()
+			s.sweepSessionsLocked(s.now())
+			s.sessions[sess.id] = sess
+			s.mu.Unlock()
+			w.Header().Set(mcpSessionHeader, sess.id)
+		}
+		writeRPC(w, resp)
+		return
+	}
+
+	sess, status := s.session(r)
+	if sess == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if !msg.isRequest() {
+		s.handleNotification(ctx, sess, &msg)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	reqCtx, done := sess.begin(ctx, msg.ID)
+	defer done()
+	flusher, canFlush := w.(http.Flusher)
+	if !canFlush || !acceptsSSE(r) || !wantsProgress(&msg) {
+		writeRPC(w, s.handle(reqCtx, sess, &msg, nil))
+		return
+	}
+
+	// 进度通知与最终响应来自同一个请求协程之外的回调，串行写出
+	var mu sync.Mutex
+	started := false
+	send := func(m *rpcMessage) {
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", raw)
+		flusher.Flush()
+	}
+	send(s.handle(reqCtx, sess, &msg, send))
+}
+
+// session 按 Mcp-Session-Id 查找会话并校验调用方；失败时返回 HTTP 状态码
+func (s *MCPServer) session(r *http.Request) (*mcpSession, int) {
+	id := r.Header.Get(mcpSessionHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+	now := s.now()
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	if ok && sess.idle(now) {
+		delete(s.sessions, id)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, http.StatusNotFound
+	}
+	// 会话 id 不是凭证，换了身份的请求不能沿用他人的会话
+	if sess.caller != security.CallerFromContext(r.Context()) {
+		return nil, http.StatusForbidden
+	}
+	sess.touch(now)
+	return sess, 0
+}
+
+func (s *MCPServer) sweepSessionsLocked(now time.Time) {
+	for id, sess := range s.sessions {
+		if sess.idle(now) {
+			delete(s.sessions, id)
+			sess.cancelAll()
+		}
+	}
+}
+
+func writeRPC(w http.ResponseWriter, msg *rpcMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(msg)
+}
+
+func acceptsSSE(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mt == "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
+func wantsProgress(msg *rpcMessage) bool {
+	var p serverCallParams
+	return msg.Method == "tools/call" && json.Unmarshal(msg.Params, &p) == nil && p.Meta != nil && len(p.Meta.ProgressToken) > 0
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ------------------ 协议处理 ------------------
+
+// handleNotification 客户端通知：initialized 无需处理，cancelled 中止对应请求
+func (s *MCPServer) handleNotification(ctx context.Context, sess *mcpSession, msg *rpcMessage) {
+	if msg.Method != "notifications/cancelled" {
+		logger.Debug(ctx, "mcp server notification", zap.String("method", msg.Method))
+		return
+	}
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+		Reason    string          `json:"reason,omitempty"`
+	}
+	if err := json.Unmarshal(msg.Params, &p); err != nil {
+		return
+	}
+	if sess.cancel(p.RequestID) {
+		logger.Debug(ctx, "mcp request cancelled by client",
+			zap.String("id", string(p.RequestID)), zap.String("reason", p.Reason))
+	}
+}
+
+// handle 处理一个请求并返回响应；notify 非 nil 时用于推送该请求的进度通知
+func (s *MCPServer) handle(ctx context.Context, sess *mcpSession, msg *rpcMessage, notify func(*rpcMessage)) *rpcMessage {
+	switch msg.Method {
+	case "initialize":
+		var p initializeParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return newErrorResponse(msg.ID, rpcInvalidParams, err.Error())
+		}
+		version := mcpProtocolVersion
+		if mcpSupportedVersions[p.ProtocolVersion] {
+			version = p.ProtocolVersion
+		}
+		logger.Info(ctx, "mcp client initialized", zap.String("client", p.ClientInfo.Name),
+			zap.String("caller", sess.caller), zap.String("protocol", version))
+		return newResponse(msg.ID, map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{"listChanged": false}},
+			"serverInfo":      implementation{Name: mcpServerName, Version: constants.Version},
+		})
+	case "ping":
+		return newResponse(msg.ID, struct{}{})
+	case "tools/list":
+		return s.listTools(ctx, sess, msg)
+	case "tools/call":
+		return s.callTool(ctx, sess, msg, notify)
+	}
+	return newErrorResponse(msg.ID, rpcMethodNotFound, "method not found: "+msg.Method)
+}
+
+// listTools 每个工具名只列出最新版本，且只列出调用方有权调用的工具；cursor 为偏移量
+func (s *MCPServer) listTools(ctx context.Context, sess *mcpSession, msg *rpcMessage) *rpcMessage {
+	ctx, span := s.trace.Start(ctx, "MCPServer.ListTools")
+	defer span.End()
+
+	var p struct {
+		Cursor string `json:"cursor"`
+	}
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return newErrorResponse(msg.ID, rpcInvalidParams, err.Error())
+		}
+	}
+	start := 0
+	if p.Cursor != "" {
+		n, err := strconv.Atoi(p.Cursor)
+		if err != nil || n < 0 {
+			return newErrorResponse(msg.ID, rpcInvalidParams, fmt.Sprintf("invalid cursor %q", p.Cursor))
+		}
+		start = n
+	}
+
+	metas, err := s.reg.List(ctx, nil)
+	if err != nil {
+		return newErrorResponse(msg.ID, rpcInternalError, err.Error())
+	}
+	names := make(map[string]bool, len(metas))
+	for _, m := range metas {
+		names[m.Name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+
+	// 与 tools/call 一致，按解析后的工具名授权
+	visible := make([]*apis.ToolSpec, 0, len(sorted))
+	for _, n := range sorted {
+		spec, err := s.reg.Resolve(ctx, n)
+		if err != nil {
+			// 列举与解析之间被下线
+			continue
+		}
+		if s.allowed(ctx, sess.caller, spec.Name) {
+			visible = append(visible, spec)
+		}
+	}
+	res := listToolsResult{Tools: []mcpTool{}}
+	for i := start; i < len(visible) && len(res.Tools) < mcpListPageSize; i++ {
+		res.Tools = append(res.Tools, toolOf(visible[i]))
+		if len(res.Tools) == mcpListPageSize && i+1 < len(visible) {
+			res.NextCursor = strconv.Itoa(i + 1)
+		}
+	}
+	span.SetAttributes(attribute.Int("mcp.tools", len(res.Tools)))
+	return newResponse(msg.ID, res)
+}
+
+// callTool 先解析引用再按工具名授权，并以 name@digest 钉死调用的版本，
+// 带版本、digest 或 ID 的引用无法绕过对工具名的拒绝规则。
+// 找不到与无权调用返回同样的错误，不泄露工具是否存在；
+// 工具执行失败（含超时、限流）以 isError 结果返回，便于模型看到原因
+func (s *MCPServer) callTool(ctx context.Context, sess *mcpSession, msg *rpcMessage, notify func(*rpcMessage)) *rpcMessage {
+	ctx, span := s.trace.Start(ctx, "MCPServer.CallTool")
+	defer span.End()
+
+	var p serverCallParams
+	if err := json.Unmarshal(msg.Params, &p); err != nil || p.Name == "" {
+		return newErrorResponse(msg.ID, rpcInvalidParams, "tools/call requires a tool name")
+	}
+	span.SetAttributes(attribute.String("mcp.tool", p.Name))
+	spec, err := s.reg.Resolve(ctx, p.Name)
+	switch {
+	case err == nil:
+	case errors.KindOf(err) == errors.KindNotFound:
+		return newErrorResponse(msg.ID, rpcInvalidParams, "unknown tool: "+p.Name)
+	case errors.KindOf(err) == errors.KindValidation:
+		return newErrorResponse(msg.ID, rpcInvalidParams, err.Error())
+	default:
+		return newErrorResponse(msg.ID, rpcInternalError, err.Error())
+	}
+	if !s.allowed(ctx, sess.caller, spec.Name) {
+		logger.Info(ctx, "mcp tools/call denied", zap.String("tool", spec.Name), zap.String("caller", sess.caller))
+		auditCall(ctx, sess, spec, "denied")
+		return newErrorResponse(msg.ID, rpcInvalidParams, "unknown tool: "+p.Name)
+	}
+	auditCall(ctx, sess, spec, "success")
+
+	var onEvent ToolEventHandler
+	if notify != nil && p.Meta != nil && len(p.Meta.ProgressToken) > 0 {
+		token := p.Meta.ProgressToken
+		// MCP 只有进度通知；增量输出随最终结果返回
+		onEvent = func(ev apis.ToolEvent) {
+			if ev.Type != apis.ToolEventProgress {
+				return
+			}
+			n, err := newNotification("notifications/progress", serverProgressParams{
+				ProgressToken: token, Progress: ev.Progress, Total: ev.Total, Message: ev.Message,
+			})
+			if err == nil {
+				notify(n)
+			}
+		}
+	}
+
+	res, err := s.inv.InvokeStream(security.WithCaller(ctx, sess.caller), PinRef(spec.ID, spec.Name, spec.Digest), p.Arguments, onEvent)
+	if err != nil {
+		switch errors.KindOf(err) {
+		case errors.KindNotFound:
+			return newErrorResponse(msg.ID, rpcInvalidParams, "unknown tool: "+p.Name)
+		case errors.KindValidation:
+			return newErrorResponse(msg.ID, rpcInvalidParams, err.Error())
+		}
+		logger.Warn(ctx, "mcp tools/call failed", zap.String("tool", p.Name), zap.Error(err))
+		return newResponse(msg.ID, callToolResult{
+			Content: []apis.ToolContent{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		})
+	}
+	return newResponse(msg.ID, resultOf(res))
+}
+
+func (s *MCPServer) allowed(ctx context.Context, caller, tool string) bool {
+	if s.rbac == nil {
+		return true
+	}
+	return s.rbac.Authorize(ctx, caller, ToolVerbInvoke, ToolResource(tool)) == nil
+}
+
+// auditCall 记录 tools/call 的授权结果
+func auditCall(ctx context.Context, sess *mcpSession, spec *apis.ToolSpec, outcome string) {
+	security.AuditLog(ctx, &types.AuditEvent{
+		Actor: sess.caller, Resource: ToolResource(spec.Name), Action: strings.ToUpper(ToolVerbInvoke),
+		Outcome: outcome, SessionID: sess.id,
+		Meta: map[string]interface{}{"via": "mcp", "version": spec.Version, "digest": spec.Digest},
+	})
+}
+
+// toolOf 没有入参 schema 的工具按空对象声明，MCP 要求 inputSchema 必填
+func toolOf(spec *apis.ToolSpec) mcpTool {
+	schema := spec.ArgsSchema
+	if len(schema) == 0 {
+		schema = apis.AnyMap{"type": "object"}
+	}
+	return mcpTool{Name: spec.Name, Title: spec.DisplayName, Description: spec.Description, InputSchema: schema}
+}
+
+// resultOf callToolResult.toResult 的逆过程；没有富内容时把 Output/Error 作为单个文本块
+func resultOf(res *apis.ToolResult) callToolResult {
+	out := callToolResult{Content: res.Content, StructuredContent: res.StructuredContent, IsError: res.Error != ""}
+	if len(out.Content) == 0 {
+		text := res.Output
+		if out.IsError {
+			text = res.Error
+		}
+		out.Content = []apis.ToolContent{{Type: "text", Text: text}}
+	}
+	return out
+}
+
+// ------------------ 会话 ------------------
+
+func newMCPSession(id, caller string, now time.Time) *mcpSession {
+	return &mcpSession{id: id, caller: caller, lastSeen: now, inflight: make(map[string]context.CancelFunc)}
+}
+
+// begin 登记在途请求，返回的 done 必须调用
+func (s *mcpSession) begin(ctx context.Context, id json.RawMessage) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := string(id)
+	s.mu.Lock()
+	s.inflight[key] = cancel
+	s.mu.Unlock()
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+		cancel()
+	}
+}
+
+func (s *mcpSession) cancel(id json.RawMessage) bool {
+	s.mu.Lock()
+	cancel, ok := s.inflight[string(id)]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+func (s *mcpSession) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cancel := range s.inflight {
+		cancel()
+	}
+}
+
+func (s *mcpSession) touch(now time.Time) {
+	s.mu.Lock()
+	s.lastSeen = now
+	s.mu.Unlock()
+}
+
+// idle 有在途请求的会话不算空闲
+func (s *mcpSession) idle(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.inflight) == 0 && now.Sub(s.lastSeen) > mcpSessionIdleTTL
+}
+
+// ------------------ 服务端消息体 ------------------
+
+// serverCallParams 与 callToolParams 相同，但保留 progressToken 的原始类型（数字或字符串）
+type serverCallParams struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+	Meta      *struct {
+		ProgressToken json.RawMessage `json:"progressToken,omitempty"`
+	} `json:"_meta,omitempty"`
+}
+
+type serverProgressParams struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      float64         `json:"progress"`
+	Total         float64         `json:"total,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
+
+//Personal.AI order the ending
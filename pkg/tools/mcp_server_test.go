@@ -0,0 +1,267 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/types"
+)
+
+// gatewayFixture 注册 echo（MCP 后端，带进度）、slow（阻塞到被杀）与 secret 三个工具
+func gatewayFixture(t *testing.T) (*MCPServer, *fakeSandboxes) {
+	ctx := context.Background()
+	upstream := httptest.NewServer(newFakeMCPServer())
+	t.Cleanup(upstream.Close)
+
+	reg := NewInMemRegistry()
+	for _, spec := range []*apis.ToolSpec{
+		{Name: "echo", Version: "1.0.0", Description: "old", MCP: &apis.MCPBinding{ServerURL: upstream.URL}},
+		{Name: "echo", Version: "1.1.0", Description: "echo input", MCP: &apis.MCPBinding{ServerURL: upstream.URL},
+			ArgsSchema: apis.AnyMap{"type": "object", "required": []interface{}{"msg"}}},
+		{Name: "slow", Version: "1.0.0", CustomExec: &apis.CustomBinding{Image: "slow"}},
+		{Name: "secret", Version: "1.0.0", CustomExec: &apis.CustomBinding{Image: "secret"}},
+	} {
+		require.NoError(t, reg.Register(ctx, spec))
+	}
+	sb := &fakeSandboxes{}
+	inv := NewInvoker(reg, sb)
+	t.Cleanup(func() { inv.Close() })
+
+	rbac := security.NewRBAC()
+	require.NoError(t, rbac.UpdatePolicy(&apis.SecurityPolicy{Spec: apis.PolicySpec{
+		Rules:    []apis.RBACRule{{Role: "caller", Verbs: []string{ToolVerbInvoke}, Resources: []string{"tools/{echo,slow}"}}},
+		Bindings: []apis.RoleBinding{{Role: "caller", Subjects: []string{"alice"}}},
+	}}))
+	return NewMCPServer(reg, inv, WithMCPAuthorizer(rbac)), sb
+}
+
+// stdioGateway 经管道把 MCPClient 接到 ServeStdio
+func stdioGateway(t *testing.T, srv *MCPServer, caller string) *mcpClient {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- srv.ServeStdio(security.WithCaller(context.Background(), caller), serverR, serverW) }()
+
+	c := NewMCPClient().(*mcpClient)
+	require.NoError(t, c.connectTransport(context.Background(), newStdioTransport(clientR, clientW, c.onNotify)))
+	t.Cleanup(func() {
+		c.Close()
+		assert.NoError(t, <-done)
+		serverW.Close()
+	})
+	return c
+}
+
+func TestMCPServerStdio(t *testing.T) {
+	srv, _ := gatewayFixture(t)
+	c := stdioGateway(t, srv, "alice")
+	ctx := context.Background()
+
+	info, err := c.ServerInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, mcpServerName, info.Name)
+	assert.Equal(t, []string{"tools"}, info.Capabilities)
+
+	// 只列出有权调用的工具，每个名字一个最新版本
+	tools, err := c.ListTools(ctx)
+	require.NoError(t, err)
+	require.Len(t, tools, 2)
+	assert.Equal(t, "echo", tools[0].Name)
+	assert.Equal(t, "echo input", tools[0].Description)
+	assert.Equal(t, "slow", tools[1].Name)
+	assert.Equal(t, "object", tools[1].ArgsSchema["type"])
+
+	var log eventLog
+	res, err := c.CallToolStream(ctx, "echo", map[string]interface{}{"msg": "hi"}, log.handle)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", res.Output)
+	assert.Equal(t, "hi", res.StructuredContent["msg"])
+	assert.Len(t, log.of(apis.ToolEventProgress), 2)
+
+	// 无权调用与不存在不可区分
+	_, err = c.CallTool(ctx, "secret", nil)
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "unknown tool")
+	_, err = c.CallTool(ctx, "missing", nil)
+	assert.Contains(t, err.Error(), "unknown tool")
+
+	// 服务端按注册的 schema 校验
+	_, err = c.CallTool(ctx, "echo", map[string]interface{}{})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+
+	err = c.call(ctx, "resources/list", nil, nil)
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+// 带版本、digest 或 ID 的引用按解析后的工具名授权，绕不过对名字的拒绝规则
+func TestMCPServerDenyByResolvedName(t *testing.T) {
+	ctx := context.Background()
+	reg := NewInMemRegistry()
+	for _, spec := range []*apis.ToolSpec{
+		{Name: "public", Version: "1.0.0", CustomExec: &apis.CustomBinding{Image: "public"}},
+		{Name: "secret", Version: "1.0.0", CustomExec: &apis.CustomBinding{Image: "secret"}},
+	} {
+		require.NoError(t, reg.Register(ctx, spec))
+	}
+	sb := &fakeSandboxes{run: func(*sandbox.SandboxSpec) error { return nil }}
+	rbac := security.NewRBAC()
+	require.NoError(t, rbac.UpdatePolicy(&apis.SecurityPolicy{Spec: apis.PolicySpec{
+		Rules: []apis.RBACRule{
+			{Role: "caller", Verbs: []string{ToolVerbInvoke}, Resources: []string{"tools/*"}},
+			{Role: "caller", Verbs: []string{ToolVerbInvoke}, Resources: []string{"tools/secret"}, Effect: apis.EffectDeny},
+		},
+		Bindings: []apis.RoleBinding{{Role: "caller", Subjects: []string{"alice"}}},
+	}}))
+	var audit bytes.Buffer
+	auditor, err := security.NewAuditor(security.NewWriterSink(&audit))
+	require.NoError(t, err)
+	security.SetAuditor(auditor)
+	defer security.SetAuditor(nil)
+	srv := NewMCPServer(reg, NewInvoker(reg, sb), WithMCPAuthorizer(rbac))
+	c := stdioGateway(t, srv, "alice")
+
+	secret, err := reg.Resolve(ctx, "secret")
+	require.NoError(t, err)
+	for _, ref := range []string{"secret", "secret@1.0.0", "secret@^1", "secret@" + secret.Digest, secret.ID} {
+		_, err := c.CallTool(ctx, ref, nil)
+		assert.ErrorContains(t, err, "unknown tool", ref)
+	}
+	assert.Zero(t, sb.starts(), "denied tools never reach the sandbox")
+
+	_, err = c.CallTool(ctx, "public@^1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sb.starts())
+	tools, err := c.ListTools(ctx)
+	require.NoError(t, err)
+	require.Len(t, tools, 1)
+	assert.Equal(t, "public", tools[0].Name)
+
+	// 每次 tools/call 的授权结果都进审计日志
+	outcomes := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(audit.String()), "\n") {
+		var rec security.AuditRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		var ev types.AuditEvent
+		require.NoError(t, json.Unmarshal(rec.Event, &ev))
+		assert.Equal(t, "alice", ev.Actor)
+		outcomes[ev.Resource+" "+ev.Outcome]++
+	}
+	assert.Equal(t, map[string]int{"tools/secret denied": 5, "tools/public success": 1}, outcomes)
+}
+
+func TestMCPServerCancel(t *testing.T) {
+	srv, sb := gatewayFixture(t)
+	c := stdioGateway(t, srv, "alice")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.CallTool(ctx, "slow", nil)
+	assert.Equal(t, errors.KindTimeout, errors.KindOf(err))
+
+	// notifications/cancelled 中止沙箱
+	require.Eventually(t, func() bool {
+		sb.mu.Lock()
+		defer sb.mu.Unlock()
+		return sb.kills == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+// callerHeader 测试中以请求头模拟认证中间件
+func callerHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(security.WithCaller(r.Context(), r.Header.Get("X-Caller"))))
+	})
+}
+
+func TestMCPServerHTTP(t *testing.T) {
+	srv, _ := gatewayFixture(t)
+	ts := httptest.NewServer(callerHeader(srv))
+	defer ts.Close()
+	ctx := context.Background()
+
+	c := NewMCPClient(WithMCPHeaders(map[string]string{"X-Caller": "alice"}))
+	require.NoError(t, c.Connect(ctx, ts.URL))
+	tools, err := c.ListTools(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tools, 2)
+
+	// 带 progressToken 时以 SSE 推送进度
+	var log eventLog
+	res, err := c.CallToolStream(ctx, "echo", map[string]interface{}{"msg": "over http"}, log.handle)
+	require.NoError(t, err)
+	assert.Equal(t, "over http", res.Output)
+	assert.Len(t, log.of(apis.ToolEventProgress), 2)
+
+	tr, _ := c.(*mcpClient).conn()
+	sid := tr.(*httpTransport).session
+	require.NotEmpty(t, sid)
+	post := func(caller, session string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"jsonrpc":"2.0","id":9,"method":"ping"}`))
+		req.Header.Set("X-Caller", caller)
+		if session != "" {
+			req.Header.Set(mcpSessionHeader, session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+	assert.Equal(t, http.StatusOK, post("alice", sid).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, post("alice", "").StatusCode)
+	assert.Equal(t, http.StatusNotFound, post("alice", "nope").StatusCode)
+	// 会话绑定发起 initialize 的调用方
+	assert.Equal(t, http.StatusForbidden, post("mallory", sid).StatusCode)
+
+	// 其他调用方看不到 alice 的工具
+	m := NewMCPClient(WithMCPHeaders(map[string]string{"X-Caller": "mallory"}))
+	require.NoError(t, m.Connect(ctx, ts.URL))
+	defer m.Close()
+	tools, err = m.ListTools(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tools)
+
+	require.NoError(t, c.Close())
+	assert.Equal(t, http.StatusNotFound, post("alice", sid).StatusCode)
+
+	resp, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestMCPServerListPaging(t *testing.T) {
+	ctx := context.Background()
+	reg := NewInMemRegistry()
+	for i := 0; i < mcpListPageSize+5; i++ {
+		require.NoError(t, reg.Register(ctx, &apis.ToolSpec{
+			Name: "t" + strings.Repeat("x", i), Version: "1.0.0", CustomExec: &apis.CustomBinding{Image: "x"},
+		}))
+	}
+	srv := NewMCPServer(reg, NewInvoker(reg, &fakeSandboxes{run: func(*sandbox.SandboxSpec) error { return nil }}))
+	var out bytes.Buffer
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list","params":{"cursor":"100"}}` + "\n")
+	require.NoError(t, srv.ServeStdio(ctx, in, &out))
+	assert.Contains(t, out.String(), `"nextCursor":"100"`)
+	assert.Equal(t, 1, strings.Count(out.String(), "nextCursor"))
+
+	// 客户端翻页拿到全部工具
+	c := stdioGateway(t, srv, "")
+	tools, err := c.ListTools(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tools, mcpListPageSize+5)
+}
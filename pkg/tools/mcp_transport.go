@@ -0,0 +1,474 @@
+// pkg/tools/mcp_transport.go
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/turtacn/agenticai/internal/logger"
+)
+
+// ------------------ JSON-RPC 2.0 ------------------
+
+const jsonrpcVersion = "2.0"
+
+// JSON-RPC 标准错误码
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// rpcMessage 统一承载 request / notification / response
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+func (m *rpcMessage) isNotification() bool { return m.Method != "" && len(m.ID) == 0 }
+func (m *rpcMessage) isRequest() bool      { return m.Method != "" && len(m.ID) > 0 }
+func (m *rpcMessage) isResponse() bool     { return m.Method == "" && len(m.ID) > 0 }
+
+func newRequest(id int64, method string, params interface{}) (*rpcMessage, error) {
+	msg, err := newNotification(method, params)
+	if err != nil {
+		return nil, err
+	}
+	msg.ID = json.RawMessage(fmt.Sprintf("%d", id))
+	return msg, nil
+}
+
+func newNotification(method string, params interface{}) (*rpcMessage, error) {
+	msg := &rpcMessage{JSONRPC: jsonrpcVersion, Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s params: %w", method, err)
+		}
+		msg.Params = raw
+	}
+	return msg, nil
+}
+
+func newResponse(id json.RawMessage, result interface{}) *rpcMessage {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return newErrorResponse(id, rpcInternalError, err.Error())
+	}
+	return &rpcMessage{JSONRPC: jsonrpcVersion, ID: id, Result: raw}
+}
+
+func newErrorResponse(id json.RawMessage, code int, msg string) *rpcMessage {
+	return &rpcMessage{JSONRPC: jsonrpcVersion, ID: id, Error: &rpcError{Code: code, Message: msg}}
+}
+
+// ------------------ 传输层 ------------------
+
+// mcpTransport 屏蔽 stdio / streamable HTTP 差异
+type mcpTransport interface {
+	// Call 发送请求并阻塞等待同 id 响应
+	Call(ctx context.Context, req *rpcMessage) (*rpcMessage, error)
+	// Notify 单向通知，无响应
+	Notify(ctx context.Context, msg *rpcMessage) error
+	Close() error
+}
+
+// notifyFunc 处理服务端推送的通知
+type notifyFunc func(msg *rpcMessage)
+
+// ------------------ stdio ------------------
+
+// stdioTransport 以换行分隔的 JSON 消息读写子进程 stdin/stdout
+type stdioTransport struct {
+	w      io.WriteCloser
+	r      io.ReadCloser
+	cmd    *exec.Cmd
+	notify notifyFunc
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[string]chan *rpcMessage
+	closed  bool
+	done    chan struct{}
+	err     error
+}
+
+// dialStdio 解析 stdio:///path/to/server?arg=a&arg=b 并拉起子进程
+func dialStdio(ctx context.Context, u *url.URL, env map[string]string, notify notifyFunc) (*stdioTransport, error) {
+	bin := u.Host + u.Path
+	if bin == "" {
+		return nil, fmt.Errorf("stdio address %q has no command", u.String())
+	}
+	cmd := exec.Command(bin, u.Query()["arg"]...)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start mcp server %s: %w", bin, err)
+	}
+	logger.Debug(ctx, "mcp stdio server started", zap.String("cmd", bin), zap.Int("pid", cmd.Process.Pid))
+	t := newStdioTransport(stdout, stdin, notify)
+	t.cmd = cmd
+	return t, nil
+}
+
+func newStdioTransport(r io.ReadCloser, w io.WriteCloser, notify notifyFunc) *stdioTransport {
+	t := &stdioTransport{
+		r:       r,
+		w:       w,
+		notify:  notify,
+		pending: make(map[string]chan *rpcMessage),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop()
+	return t
+}
+
+func (t *stdioTransport) readLoop() {
+	defer close(t.done)
+	sc := bufio.NewScanner(t.r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var msg rpcMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			logger.Warn(context.Background(), "mcp stdio: drop malformed message", zap.Error(err))
+			continue
+		}
+		switch {
+		case msg.isResponse():
+			t.mu.Lock()
+			ch, ok := t.pending[string(msg.ID)]
+			delete(t.pending, string(msg.ID))
+			t.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+		case msg.isRequest():
+			// 客户端不提供 sampling/roots 等能力，仅应答 ping
+			resp := newErrorResponse(msg.ID, rpcMethodNotFound, "method not found: "+msg.Method)
+			if msg.Method == "ping" {
+				resp = newResponse(msg.ID, struct{}{})
+			}
+			_ = t.write(resp)
+		case msg.isNotification():
+			if t.notify != nil {
+				t.notify(&msg)
+			}
+		}
+	}
+	t.mu.Lock()
+	t.closed = true
+	t.err = sc.Err()
+	if t.err == nil {
+		t.err = io.EOF
+	}
+	for id, ch := range t.pending {
+		close(ch)
+		delete(t.pending, id)
+	}
+	t.mu.Unlock()
+}
+
+func (t *stdioTransport) write(msg *rpcMessage) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.w.Write(append(raw, '\n'))
+	return err
+}
+
+func (t *stdioTransport) Call(ctx context.Context, req *rpcMessage) (*rpcMessage, error) {
+	ch := make(chan *rpcMessage, 1)
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("mcp stdio transport closed: %w", t.err)
+	}
+	t.pending[string(req.ID)] = ch
+	t.mu.Unlock()
+
+	if err := t.write(req); err != nil {
+		t.mu.Lock()
+		delete(t.pending, string(req.ID))
+		t.mu.Unlock()
+		return nil, err
+	}
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, fmt.Errorf("mcp stdio transport closed: %w", t.err)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		t.mu.Lock()
+		delete(t.pending, string(req.ID))
+		t.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) Notify(_ context.Context, msg *rpcMessage) error {
+	return t.write(msg)
+}
+
+func (t *stdioTransport) Close() error {
+	err := t.w.Close()
+	if t.cmd != nil {
+		// stdin 已关闭，直接回收子进程
+		if t.cmd.Process != nil {
+			_ = t.cmd.Process.Kill()
+		}
+		_ = t.cmd.Wait()
+	}
+	_ = t.r.Close()
+	<-t.done
+	return err
+}
+
+// ------------------ streamable HTTP ------------------
+
+const (
+	mcpSessionHeader  = "Mcp-Session-Id"
+	mcpProtocolHeader = "MCP-Protocol-Version"
+)
+
+// httpTransport 实现 MCP streamable HTTP：每条消息一次 POST，
+// 响应可能是单个 JSON，也可能是 text/event-stream
+type httpTransport struct {
+	endpoint string
+	client   *http.Client
+	headers  map[string]string
+	notify   notifyFunc
+
+	mu       sync.RWMutex
+	session  string
+	protocol string
+}
+
+func newHTTPTransport(endpoint string, client *http.Client, headers map[string]string, notify notifyFunc) *httpTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpTransport{endpoint: endpoint, client: client, headers: headers, notify: notify}
+}
+
+func (t *httpTransport) setProtocol(v string) {
+	t.mu.Lock()
+	t.protocol = v
+	t.mu.Unlock()
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.RLock()
+	if t.session != "" {
+		req.Header.Set(mcpSessionHeader, t.session)
+	}
+	if t.protocol != "" {
+		req.Header.Set(mcpProtocolHeader, t.protocol)
+	}
+	t.mu.RUnlock()
+	return req, nil
+}
+
+func (t *httpTransport) post(ctx context.Context, msg *rpcMessage) (*http.Response, error) {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := t.newRequest(ctx, http.MethodPost, bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if sid := resp.Header.Get(mcpSessionHeader); sid != "" {
+		t.mu.Lock()
+		t.session = sid
+		t.mu.Unlock()
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("mcp http %s: %d %s", msg.Method, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) Call(ctx context.Context, req *rpcMessage) (*rpcMessage, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		var out rpcMessage
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("decode mcp response: %w", err)
+		}
+		return &out, nil
+	}
+
+	var result *rpcMessage
+	err = readSSE(resp.Body, func(ev sseEvent) bool {
+		var msg rpcMessage
+		if err := json.Unmarshal([]byte(ev.Data), &msg); err != nil {
+			logger.Warn(ctx, "mcp sse: drop malformed event", zap.Error(err))
+			return true
+		}
+		switch {
+		case msg.isResponse() && string(msg.ID) == string(req.ID):
+			result = &msg
+			return false
+		case msg.isNotification() && t.notify != nil:
+			t.notify(&msg)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("mcp sse stream closed before response to %s", req.Method)
+	}
+	return result, nil
+}
+
+func (t *httpTransport) Notify(ctx context.Context, msg *rpcMessage) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+func (t *httpTransport) Close() error {
+	t.mu.RLock()
+	sid := t.session
+	t.mu.RUnlock()
+	if sid == "" {
+		return nil
+	}
+	// 显式结束会话，服务端可能返回 405，忽略
+	req, err := t.newRequest(context.Background(), http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	return resp.Body.Close()
+}
+
+// ------------------ SSE ------------------
+
+type sseEvent struct {
+	Event string
+	ID    string
+	Data  string
+}
+
+// readSSE 逐个事件回调，fn 返回 false 时停止读取
+func readSSE(r io.Reader, fn func(sseEvent) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var ev sseEvent
+	var data []string
+	flush := func() bool {
+		if len(data) == 0 && ev.Event == "" {
+			return true
+		}
+		ev.Data = strings.Join(data, "\n")
+		cont := fn(ev)
+		ev, data = sseEvent{}, nil
+		return cont
+	}
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if !flush() {
+				return nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Event = value
+		case "id":
+			ev.ID = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
+
+//Personal.AI order the ending
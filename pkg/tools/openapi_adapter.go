@@ -2,114 +2,599 @@
 package tools
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
-	"time"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
 
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
 	"github.com/turtacn/agenticai/pkg/apis"
 )
 
+// bodyArg 请求体在工具入参中的键名
+const bodyArg = "body"
+
+// maxResponseBytes 单次响应读取上限
+const maxResponseBytes = 10 << 20
+
 // OpenAPIAdapter 适配器
 type OpenAPIAdapter interface {
 	LoadSpec(ctx context.Context, raw []byte) error
-	ListTools() []*apis.Metadata
+	ListTools() []*apis.ToolSpec
 	Invoke(
 		ctx context.Context,
 		toolName string,
 		input map[string]interface{},
 	) (*apis.ToolResult, error)
+	// InvokeStream 边读边推送响应：text/event-stream 按事件、其它按到达的数据块推送 output 事件，
+	// 名为 progress 的 SSE 事件（data 为 {"progress","total","message"}）转为 progress 事件
+	InvokeStream(
+		ctx context.Context,
+		toolName string,
+		input map[string]interface{},
+		onEvent ToolEventHandler,
+	) (*apis.ToolResult, error)
+}
+
+// OpenAPIOption 适配器可选项
+type OpenAPIOption func(*openAPIAdapter)
+
+// WithOpenAPIBinding 使用 Tool CRD 中的绑定（BaseURL 优先于文档 servers）
+func WithOpenAPIBinding(b *apis.OpenAPIBinding) OpenAPIOption {
+	return func(a *openAPIAdapter) { a.binding = b }
+}
+
+// WithOpenAPIHTTPClient 替换调用上游使用的 http.Client
+func WithOpenAPIHTTPClient(hc *http.Client) OpenAPIOption {
+	return func(a *openAPIAdapter) { a.http = hc }
+}
+
+// openAPIOperation 单个 operation 展开后的调用信息
+type openAPIOperation struct {
+	spec     *apis.ToolSpec
+	method   string
+	path     string
+	op       *openapi3.Operation
+	params   openapi3.Parameters
+	body     *openapi3.RequestBody
+	servers  openapi3.Servers
+	security openapi3.SecurityRequirements
 }
 
 type openAPIAdapter struct {
-	doc   *openapi3.T
-	cache map[string]*apis.ToolSpec // name->spec
-	http  *http.Client
-	trace trace.Tracer
+	doc     *openapi3.T
+	binding *apis.OpenAPIBinding
+	cache   map[string]*openAPIOperation // id->operation
+	names   map[string]string            // name->id
+	http    *http.Client
+	trace   trace.Tracer
+
+	mu     sync.Mutex
+	tokens map[string]oauth2.TokenSource // oauth2 令牌缓存
 }
 
-func NewOpenAPIAdapter() OpenAPIAdapter {
-	return &openAPIAdapter{
-		cache: make(map[string]*apis.ToolSpec),
-		http:  &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+func NewOpenAPIAdapter(opts ...OpenAPIOption) OpenAPIAdapter {
+	a := &openAPIAdapter{
+		cache:  make(map[string]*openAPIOperation),
+		names:  make(map[string]string),
+		tokens: make(map[string]oauth2.TokenSource),
+		// 不设 Client.Timeout：它会截断长时间的流式响应，超时改由 ctx 控制
+		http:  &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
 		trace: otel.Tracer("openapi"),
 	}
+	for _, o := range opts {
+		o(a)
+	}
+	if a.binding == nil {
+		a.binding = &apis.OpenAPIBinding{}
+	}
+	return a
 }
 
 func (a *openAPIAdapter) LoadSpec(_ context.Context, raw []byte) error {
 	doc, err := openapi3.NewLoader().LoadFromData(raw)
 	if err != nil {
-		return fmt.Errorf("invalid openapi: %w", err)
+		return errors.Validation(err, "invalid openapi")
 	}
-	// 扁平化路径生成工具
+	a.doc = doc
+	// 扁平化路径生成工具：一个 operation 对应一个工具
 	for _, path := range doc.Paths.InMatchingOrder() {
-		pItem := doc.Paths.Find(path)
+		pItem := doc.Paths.Value(path)
 		for method, op := range pItem.Operations() {
 			id := fmt.Sprintf("%s %s", method, path)
-			a.cache[id] = &apis.ToolSpec{
+			o := &openAPIOperation{
+				method:   method,
+				path:     path,
+				op:       op,
+				params:   mergeParams(pItem.Parameters, op.Parameters),
+				servers:  doc.Servers,
+				security: doc.Security,
+			}
+			if len(pItem.Servers) > 0 {
+				o.servers = pItem.Servers
+			}
+			if op.Servers != nil && len(*op.Servers) > 0 {
+				o.servers = *op.Servers
+			}
+			if op.Security != nil {
+				o.security = *op.Security
+			}
+			if op.RequestBody != nil {
+				o.body = op.RequestBody.Value
+			}
+			name := op.OperationID
+			if name == "" {
+				name = operationName(method, path)
+			}
+			desc := op.Description
+			if desc == "" {
+				desc = op.Summary
+			}
+			o.spec = &apis.ToolSpec{
 				ID:          id,
-				Name:        op.OperationID,
+				Name:        name,
 				Version:     doc.Info.Version,
-				Description: op.Description,
+				DisplayName: op.Summary,
+				Description: desc,
 				Category:    "openapi",
-				ArgsSchema:  convertOpenAPIArgs(op.Parameters),
+				ArgsSchema:  convertOpenAPIArgs(doc, o.params, o.body),
+				OpenAPI:     a.binding,
 			}
+			o.spec.Digest = ComputeDigest(o.spec)
+			a.cache[id] = o
+			a.names[name] = id
 		}
 	}
 	return nil
 }
 
-func (a *openAPIAdapter) ListTools() []*apis.Metadata {
-	out := make([]*apis.Metadata, 0, len(a.cache))
+// ListTools 按 ID 排序返回，保证输出稳定
+func (a *openAPIAdapter) ListTools() []*apis.ToolSpec {
+	out := make([]*apis.ToolSpec, 0, len(a.cache))
 	for _, v := range a.cache {
-		out = append(out, &apis.Metadata{
-			ID:      v.ID,
-			Name:    v.Name,
-			Version: v.Version,
-			Digest:  v.Digest,
-		})
+		out = append(out, v.spec)
 	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
 	return out
 }
 
+// Invoke toolName 可以是 "METHOD /path" 形式的 ID，也可以是 operationId
 func (a *openAPIAdapter) Invoke(
 	ctx context.Context,
 	toolName string,
 	input map[string]interface{},
+) (*apis.ToolResult, error) {
+	return a.InvokeStream(ctx, toolName, input, nil)
+}
+
+func (a *openAPIAdapter) InvokeStream(
+	ctx context.Context,
+	toolName string,
+	input map[string]interface{},
+	onEvent ToolEventHandler,
 ) (*apis.ToolResult, error) {
 	ctx, span := a.trace.Start(ctx, "OpenAPIAdapter.Invoke")
 	defer span.End()
-	_, ok := a.cache[toolName]
+	op, ok := a.lookup(toolName)
 	if !ok {
-		return nil, fmt.Errorf("tool %s not found", toolName)
+		return nil, errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", toolName))
 	}
-	// 简易：用 GET 方式访问 path
-	path := toolName
-	req, _ := http.NewRequestWithContext(ctx, "GET", path, nil)
-	q := req.URL.Query()
-	for k, v := range input {
-		q.Set(k, fmt.Sprintf("%v", v))
+	span.SetAttributes(
+		attribute.String("openapi.tool", op.spec.ID),
+		attribute.String("http.method", op.method),
+	)
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, constants.DefaultTimeout)
+		defer cancel()
 	}
-	req.URL.RawQuery = q.Encode()
-	resp, err := a.http.Do(req)
+
+	input, err := ValidateArgs(op.spec.ArgsSchema, input)
 	if err != nil {
 		return nil, err
 	}
+
+	req, err := a.buildRequest(ctx, op, input)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Timeout(err, fmt.Sprintf("invoke %s", op.spec.ID))
+		}
+		return nil, errors.Unavailable(err, fmt.Sprintf("invoke %s", op.spec.ID))
+	}
 	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	return &apis.ToolResult{
-		Output: string(body),
+	body, err := readResponse(resp, onEvent)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Timeout(err, fmt.Sprintf("read %s response", op.spec.ID))
+		}
+		return nil, errors.Unavailable(err, fmt.Sprintf("read %s response", op.spec.ID))
+	}
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	out := &apis.ToolResult{
+		Output: body,
 		Status: int32(resp.StatusCode),
-	}, nil
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		out.Error = http.StatusText(resp.StatusCode)
+	}
+	return out, nil
+}
+
+// readResponse 无回调时整体读取；有回调时边读边推送，SSE 的各事件 data 以换行拼接为最终输出
+func readResponse(resp *http.Response, onEvent ToolEventHandler) (string, error) {
+	body := io.LimitReader(resp.Body, maxResponseBytes)
+	if onEvent == nil {
+		raw, err := io.ReadAll(body)
+		return string(raw), err
+	}
+	out := &limitedBuffer{max: maxResponseBytes}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		var parts []string
+		err := readSSE(body, func(ev sseEvent) bool {
+			if ev.Event == "progress" {
+				var p struct {
+					Progress float64 `json:"progress"`
+					Total    float64 `json:"total"`
+					Message  string  `json:"message"`
+				}
+				if json.Unmarshal([]byte(ev.Data), &p) == nil {
+					onEvent(apis.ToolEvent{Type: apis.ToolEventProgress, Progress: p.Progress, Total: p.Total, Message: p.Message})
+					return true
+				}
+			}
+			parts = append(parts, ev.Data)
+			onEvent(apis.ToolEvent{Type: apis.ToolEventOutput, Chunk: ev.Data})
+			return true
+		})
+		return strings.Join(parts, "\n"), err
+	}
+	_, err := io.CopyBuffer(&outputWriter{buf: out, emit: onEvent}, body, make([]byte, 32<<10))
+	return out.String(), err
+}
+
+func (a *openAPIAdapter) lookup(toolName string) (*openAPIOperation, bool) {
+	if op, ok := a.cache[toolName]; ok {
+		return op, true
+	}
+	if id, ok := a.names[toolName]; ok {
+		return a.cache[id], true
+	}
+	return nil, false
+}
+
+// buildRequest 按参数 In 放置 path/query/header/cookie，body 键作为请求体
+func (a *openAPIAdapter) buildRequest(
+	ctx context.Context,
+	op *openAPIOperation,
+	input map[string]interface{},
+) (*http.Request, error) {
+	// 凭据缺失时在任何网络调用之前失败
+	creds, err := a.resolveSecurity(op)
+	if err != nil {
+		return nil, err
+	}
+	base, err := a.baseURL(op)
+	if err != nil {
+		return nil, err
+	}
+
+	path := op.path
+	query := url.Values{}
+	header := http.Header{}
+	var cookies []*http.Cookie
+	for _, ref := range op.params {
+		p := ref.Value
+		if p == nil {
+			continue
+		}
+		v, ok := input[p.Name]
+		if !ok || v == nil {
+			if p.Required {
+				return nil, errors.E(errors.KindValidation,
+					fmt.Sprintf("missing required %s parameter %q", p.In, p.Name))
+			}
+			continue
+		}
+		switch p.In {
+		case openapi3.ParameterInPath:
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(paramString(v)))
+		case openapi3.ParameterInQuery:
+			if arr, ok := v.([]interface{}); ok && (p.Explode == nil || *p.Explode) {
+				for _, e := range arr {
+					query.Add(p.Name, paramString(e))
+				}
+			} else {
+				query.Set(p.Name, paramString(v))
+			}
+		case openapi3.ParameterInHeader:
+			header.Set(p.Name, paramString(v))
+		case openapi3.ParameterInCookie:
+			cookies = append(cookies, &http.Cookie{Name: p.Name, Value: paramString(v)})
+		}
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	if v, ok := input[bodyArg]; ok && v != nil && op.body != nil {
+		contentType = pickContentType(op.body.Content)
+		raw, err := encodeBody(contentType, v)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(raw)
+	} else if op.body != nil && op.body.Required {
+		return nil, errors.E(errors.KindValidation, "missing required request body")
+	}
+
+	u := strings.TrimRight(base, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(op.method), u, body)
+	if err != nil {
+		return nil, errors.Validation(err, fmt.Sprintf("build %s request", op.spec.ID))
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json, */*;q=0.8")
+	if err := a.applySecurity(req, creds); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// baseURL 优先级：绑定 BaseURL > operation/path/doc servers；相对地址基于 SpecURL 解析
+func (a *openAPIAdapter) baseURL(op *openAPIOperation) (string, error) {
+	if a.binding.BaseURL != "" {
+		return a.binding.BaseURL, nil
+	}
+	if len(op.servers) == 0 || op.servers[0] == nil {
+		return "", errors.E(errors.KindValidation,
+			fmt.Sprintf("no base url for %s: set baseURL or servers", op.spec.ID))
+	}
+	srv := op.servers[0]
+	raw := srv.URL
+	for name, v := range srv.Variables {
+		if v != nil {
+			raw = strings.ReplaceAll(raw, "{"+name+"}", v.Default)
+		}
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", errors.Validation(err, fmt.Sprintf("invalid server url %q", raw))
+	}
+	if !u.IsAbs() && a.binding.SpecURL != "" {
+		specURL, err := url.Parse(a.binding.SpecURL)
+		if err != nil {
+			return "", errors.Validation(err, fmt.Sprintf("invalid spec url %q", a.binding.SpecURL))
+		}
+		u = specURL.ResolveReference(u)
+	}
+	if !u.IsAbs() {
+		return "", errors.E(errors.KindValidation, fmt.Sprintf("relative server url %q without specURL", raw))
+	}
+	return u.String(), nil
 }
 
 /* -------------- helper --------------- */
-func convertOpenAPIArgs(params openapi3.Parameters) apis.AnyMap { return nil }
-func hashSpec(s *apis.ToolSpec) uint64                    { return 0 }
+
+// mergeParams operation 级参数覆盖同名同位置的 path 级参数
+func mergeParams(pathParams, opParams openapi3.Parameters) openapi3.Parameters {
+	out := make(openapi3.Parameters, 0, len(pathParams)+len(opParams))
+	for _, p := range pathParams {
+		if p.Value != nil && opParams.GetByInAndName(p.Value.In, p.Value.Name) != nil {
+			continue
+		}
+		out = append(out, p)
+	}
+	return append(out, opParams...)
+}
+
+// operationName 缺少 operationId 时用 method+path 生成可读名
+func operationName(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.Trim(seg, "{}")
+		if seg == "" {
+			continue
+		}
+		b.WriteByte('_')
+		b.WriteString(seg)
+	}
+	return b.String()
+}
+
+// convertOpenAPIArgs 参数与请求体合成一个 object 类型 JSON Schema
+func convertOpenAPIArgs(doc *openapi3.T, params openapi3.Parameters, body *openapi3.RequestBody) apis.AnyMap {
+	props := map[string]interface{}{}
+	var required []interface{}
+	for _, ref := range params {
+		p := ref.Value
+		if p == nil {
+			continue
+		}
+		s := schemaToMap(doc, p.Schema, 0)
+		if s == nil {
+			s = map[string]interface{}{}
+		}
+		if p.Description != "" {
+			s["description"] = p.Description
+		}
+		s["x-in"] = p.In
+		props[p.Name] = s
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	if body != nil {
+		s := map[string]interface{}{}
+		if mt := body.Content.Get(pickContentType(body.Content)); mt != nil {
+			if m := schemaToMap(doc, mt.Schema, 0); m != nil {
+				s = m
+			}
+		}
+		if body.Description != "" {
+			s["description"] = body.Description
+		}
+		s["x-in"] = bodyArg
+		props[bodyArg] = s
+		if body.Required {
+			required = append(required, bodyArg)
+		}
+	}
+	out := apis.AnyMap{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// maxSchemaDepth 防止递归 $ref 无限展开
+const maxSchemaDepth = 8
+
+// schemaToMap 序列化 schema 并内联 components 引用，便于 agent 直接阅读
+func schemaToMap(doc *openapi3.T, ref *openapi3.SchemaRef, depth int) map[string]interface{} {
+	if ref == nil || ref.Value == nil {
+		return nil
+	}
+	raw, err := json.Marshal(ref.Value)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	return inlineRefs(doc, m, depth).(map[string]interface{})
+}
+
+func inlineRefs(doc *openapi3.T, v interface{}, depth int) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if r, ok := t["$ref"].(string); ok {
+			name := strings.TrimPrefix(r, "#/components/schemas/")
+			if depth >= maxSchemaDepth || doc == nil || doc.Components == nil {
+				return map[string]interface{}{}
+			}
+			if s := schemaToMap(doc, doc.Components.Schemas[name], depth+1); s != nil {
+				return s
+			}
+			return map[string]interface{}{}
+		}
+		for k, e := range t {
+			t[k] = inlineRefs(doc, e, depth)
+		}
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = inlineRefs(doc, e, depth)
+		}
+		return t
+	}
+	return v
+}
+
+// pickContentType 优先 JSON，其次表单，最后按字典序取第一个
+func pickContentType(content openapi3.Content) string {
+	if len(content) == 0 {
+		return "application/json"
+	}
+	if _, ok := content["application/json"]; ok {
+		return "application/json"
+	}
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasSuffix(k, "+json") {
+			return k
+		}
+	}
+	if _, ok := content["application/x-www-form-urlencoded"]; ok {
+		return "application/x-www-form-urlencoded"
+	}
+	return keys[0]
+}
+
+func encodeBody(contentType string, v interface{}) ([]byte, error) {
+	switch {
+	case contentType == "application/x-www-form-urlencoded":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.E(errors.KindValidation, "form body must be an object")
+		}
+		form := url.Values{}
+		for k, e := range m {
+			form.Set(k, paramString(e))
+		}
+		return []byte(form.Encode()), nil
+	case strings.HasPrefix(contentType, "text/"):
+		return []byte(paramString(v)), nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Validation(err, "encode request body")
+	}
+	return raw, nil
+}
+
+// paramString simple 风格序列化：数组逗号拼接，数字不带指数
+func paramString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case []interface{}:
+		parts := make([]string, len(t))
+		for i, e := range t {
+			parts[i] = paramString(e)
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}, []map[string]interface{}:
+		raw, _ := json.Marshal(t)
+		return string(raw)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
 //Personal.AI order the ending
@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+const petstoreSpec = `
+openapi: 3.0.3
+info:
+  title: petstore
+  version: 1.0.0
+servers:
+  - url: /{base}
+    variables:
+      base:
+        default: v1
+paths:
+  /pets:
+    get:
+      summary: list pets
+      parameters:
+        - name: tag
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+      responses:
+        "200":
+          description: ok
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+    put:
+      operationId: updatePet
+      description: update a pet
+      parameters:
+        - name: X-Request-Id
+          in: header
+          schema:
+            type: string
+        - name: session
+          in: cookie
+          schema:
+            type: string
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "200":
+          description: ok
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        owner:
+          $ref: '#/components/schemas/Owner'
+    Owner:
+      type: object
+      properties:
+        email:
+          type: string
+`
+
+// recordedRequest 记录上游收到的请求
+type recordedRequest struct {
+	method, path, query, header, cookie string
+	body                                map[string]interface{}
+}
+
+func petstoreServer(t *testing.T, rec *recordedRequest) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.method = r.Method
+		rec.path = r.URL.Path
+		rec.query = r.URL.RawQuery
+		rec.header = r.Header.Get("X-Request-Id")
+		if c, err := r.Cookie("session"); err == nil {
+			rec.cookie = c.Value
+		}
+		raw, _ := io.ReadAll(r.Body)
+		rec.body = nil
+		if len(raw) > 0 {
+			require.NoError(t, json.Unmarshal(raw, &rec.body))
+		}
+		if r.URL.Path == "/v1/pets/404" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestOpenAPIAdapterArgsSchema(t *testing.T) {
+	a := NewOpenAPIAdapter(WithOpenAPIBinding(&apis.OpenAPIBinding{BaseURL: "http://example.invalid"}))
+	require.NoError(t, a.LoadSpec(context.Background(), []byte(petstoreSpec)))
+
+	tools := a.ListTools()
+	require.Len(t, tools, 2)
+	assert.Equal(t, "GET /pets", tools[0].ID)
+	assert.Equal(t, "get_pets", tools[0].Name)
+	assert.Equal(t, "list pets", tools[0].Description)
+	assert.Equal(t, "PUT /pets/{id}", tools[1].ID)
+	assert.Equal(t, "updatePet", tools[1].Name)
+
+	schema := tools[1].ArgsSchema
+	assert.Equal(t, "object", schema["type"])
+	assert.ElementsMatch(t, []interface{}{"id", "body"}, schema["required"])
+	props := schema["properties"].(map[string]interface{})
+	assert.Equal(t, "path", props["id"].(map[string]interface{})["x-in"])
+	assert.Equal(t, "header", props["X-Request-Id"].(map[string]interface{})["x-in"])
+	assert.Equal(t, "cookie", props["session"].(map[string]interface{})["x-in"])
+
+	// $ref 被内联，agent 无需 components 上下文
+	body := props["body"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"name"}, body["required"])
+	owner := body["properties"].(map[string]interface{})["owner"].(map[string]interface{})
+	assert.Contains(t, owner["properties"], "email")
+}
+
+func TestOpenAPIAdapterInvoke(t *testing.T) {
+	var rec recordedRequest
+	ts := petstoreServer(t, &rec)
+	ctx := context.Background()
+
+	a := NewOpenAPIAdapter(WithOpenAPIBinding(&apis.OpenAPIBinding{BaseURL: ts.URL + "/v1"}))
+	require.NoError(t, a.LoadSpec(ctx, []byte(petstoreSpec)))
+
+	res, err := a.Invoke(ctx, "updatePet", map[string]interface{}{
+		"id":           float64(42),
+		"X-Request-Id": "req-1",
+		"session":      "s3cr3t",
+		"body":         map[string]interface{}{"name": "rex"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(http.StatusOK), res.Status)
+	assert.Equal(t, `{"ok":true}`, res.Output)
+	assert.Equal(t, http.MethodPut, rec.method)
+	assert.Equal(t, "/v1/pets/42", rec.path)
+	assert.Equal(t, "req-1", rec.header)
+	assert.Equal(t, "s3cr3t", rec.cookie)
+	assert.Equal(t, "rex", rec.body["name"])
+
+	res, err = a.Invoke(ctx, "GET /pets", map[string]interface{}{"tag": []interface{}{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, rec.method)
+	assert.Equal(t, "/v1/pets", rec.path)
+	assert.Equal(t, "tag=a&tag=b", rec.query)
+	assert.Nil(t, rec.body)
+
+	res, err = a.Invoke(ctx, "updatePet", map[string]interface{}{"id": "404", "body": map[string]interface{}{"name": "ghost"}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(http.StatusNotFound), res.Status)
+	assert.NotEmpty(t, res.Error)
+}
+
+func TestOpenAPIAdapterServerURL(t *testing.T) {
+	var rec recordedRequest
+	ts := petstoreServer(t, &rec)
+	ctx := context.Background()
+
+	// 无 BaseURL 时，相对 servers 基于 SpecURL 解析并展开变量默认值
+	a := NewOpenAPIAdapter(WithOpenAPIBinding(&apis.OpenAPIBinding{SpecURL: ts.URL + "/openapi.yaml"}))
+	require.NoError(t, a.LoadSpec(ctx, []byte(petstoreSpec)))
+	_, err := a.Invoke(ctx, "get_pets", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/pets", rec.path)
+}
+
+func TestOpenAPIAdapterInvokeErrors(t *testing.T) {
+	ctx := context.Background()
+	a := NewOpenAPIAdapter()
+	require.NoError(t, a.LoadSpec(ctx, []byte(petstoreSpec)))
+
+	_, err := a.Invoke(ctx, "nope", nil)
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+
+	// 相对 servers 且没有 SpecURL
+	_, err = a.Invoke(ctx, "get_pets", nil)
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+
+	a = NewOpenAPIAdapter(WithOpenAPIBinding(&apis.OpenAPIBinding{BaseURL: "http://example.invalid"}))
+	require.NoError(t, a.LoadSpec(ctx, []byte(petstoreSpec)))
+	_, err = a.Invoke(ctx, "updatePet", map[string]interface{}{"body": map[string]interface{}{}})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	_, err = a.Invoke(ctx, "updatePet", map[string]interface{}{"id": 1})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+}
+
+// SpecURL 来自用户创建的 Tool，不能借此读取控制器或网关主机上的文件
+func TestFetchOpenAPISpecRejectsLocalFiles(t *testing.T) {
+	for _, ref := range []string{"/etc/hostname", "file:///etc/hostname", "file://localhost/etc/hostname", "ftp://example.com/spec.yaml"} {
+		_, err := fetchOpenAPISpec(context.Background(), nil, ref)
+		assert.Equal(t, errors.KindValidation, errors.KindOf(err), ref)
+	}
+}
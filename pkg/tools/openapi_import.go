@@ -0,0 +1,142 @@
+// pkg/tools/openapi_import.go
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// OpenAPIImport 一次导入的结果
+type OpenAPIImport struct {
+	// SpecDigest 文档原文的 sha256，文档未变时导入结果不变
+	SpecDigest string
+	// Tools 每个 operation 一个工具，按 ID 排序；ValidationErrors 非空时为空
+	Tools []*apis.ToolSpec
+	// ValidationErrors 文档能拉到但不合法
+	ValidationErrors []string
+}
+
+// ImportOpenAPI 拉取 src.OpenAPI.SpecURL，校验后按 operation 展开。
+// 展开出的工具名为 <src.Name>_<operationId>，绑定沿用 src 并以 "METHOD /path" 钉死 Operation，
+// 标签、限流、缓存、超时等平台属性继承自 src，版本缺省取文档 info.version。
+// 返回 error 表示文档没能拉到（地址非法或上游不可达）
+func ImportOpenAPI(ctx context.Context, hc *http.Client, src *apis.ToolSpec) (*OpenAPIImport, error) {
+	if src.OpenAPI == nil {
+		return nil, errors.E(errors.KindValidation, fmt.Sprintf("tool %s has no openapi binding", src.Name))
+	}
+	raw, err := fetchOpenAPISpec(ctx, hc, src.OpenAPI.SpecURL)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	out := &OpenAPIImport{SpecDigest: digestPrefix + hex.EncodeToString(sum[:])}
+
+	a := NewOpenAPIAdapter(WithOpenAPIBinding(src.OpenAPI)).(*openAPIAdapter)
+	if err := a.LoadSpec(ctx, raw); err != nil {
+		out.ValidationErrors = []string{err.Error()}
+		return out, nil
+	}
+	// 示例值与 schema 不符不影响调用，不拦截
+	if err := a.doc.Validate(ctx, openapi3.DisableExamplesValidation()); err != nil {
+		out.ValidationErrors = append(out.ValidationErrors, err.Error())
+	}
+	if len(a.cache) == 0 {
+		out.ValidationErrors = append(out.ValidationErrors, "spec declares no operations")
+	}
+
+	seen := make(map[string]string, len(a.cache))
+	for _, op := range a.ListTools() {
+		name := src.Name + "_" + op.Name
+		if prev, ok := seen[name]; ok {
+			out.ValidationErrors = append(out.ValidationErrors,
+				fmt.Sprintf("operations %q and %q both map to tool name %q", prev, op.ID, name))
+			continue
+		}
+		seen[name] = op.ID
+		out.Tools = append(out.Tools, expandOperation(src, op, name))
+	}
+	if len(out.ValidationErrors) > 0 {
+		out.Tools = nil
+		return out, nil
+	}
+	sort.Slice(out.Tools, func(i, j int) bool { return out.Tools[i].ID < out.Tools[j].ID })
+	return out, nil
+}
+
+// expandOperation op 为适配器生成的单 operation 工具，ID 为 "METHOD /path"
+func expandOperation(src, op *apis.ToolSpec, name string) *apis.ToolSpec {
+	binding := *src.OpenAPI
+	binding.Operation = op.ID
+	spec := &apis.ToolSpec{
+		Name:                name,
+		Version:             src.Version,
+		DisplayName:         op.DisplayName,
+		Description:         op.Description,
+		Author:              src.Author,
+		Category:            src.Category,
+		Tags:                src.Tags,
+		ArgsSchema:          op.ArgsSchema,
+		OpenAPI:             &binding,
+		RequiredPermissions: src.RequiredPermissions,
+		NetworkPolicy:       src.NetworkPolicy,
+		CORS:                src.CORS,
+		RateLimit:           src.RateLimit,
+		Cache:               src.Cache,
+		DefaultTimeout:      src.DefaultTimeout,
+	}
+	if spec.Version == "" {
+		spec.Version = op.Version
+	}
+	if spec.Category == "" {
+		spec.Category = op.Category
+	}
+	// sealSpec 深拷贝，与 src 不共享 map/指针；名字非空时不会失败
+	sealed, _ := sealSpec(spec)
+	return sealed
+}
+
+// fetchOpenAPISpec 只支持 http(s)://。SpecURL 来自 Tool CR，在控制器与 tool-gateway 中执行，
+// 不能读取本地文件
+func fetchOpenAPISpec(ctx context.Context, hc *http.Client, specURL string) ([]byte, error) {
+	u, err := url.Parse(specURL)
+	if err != nil || specURL == "" {
+		return nil, errors.E(errors.KindValidation, fmt.Sprintf("invalid openapi spec url %q", specURL))
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.E(errors.KindValidation, fmt.Sprintf("unsupported openapi spec scheme %q, want http or https", u.Scheme))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, errors.Validation(err, fmt.Sprintf("invalid openapi spec url %q", specURL))
+	}
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, errors.Unavailable(err, fmt.Sprintf("fetch openapi spec %s", specURL))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.E(errors.KindUnavailable, fmt.Sprintf("fetch openapi spec %s: %s", specURL, resp.Status))
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, errors.Unavailable(err, fmt.Sprintf("fetch openapi spec %s", specURL))
+	}
+	return raw, nil
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,164 @@
+// pkg/tools/openapi_security.go
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/turtacn/agenticai/internal/errors"
+)
+
+// securityCredential 某个 securityScheme 与 AuthMethods 中对应的值
+type securityCredential struct {
+	name   string
+	scheme *openapi3.SecurityScheme
+	value  string
+	scopes []string
+}
+
+// resolveSecurity 在 operation 的 security 要求中选出第一组凭据齐全的方案。
+// security 各项之间为 OR，单项内的多个 scheme 为 AND；空项表示允许匿名。
+func (a *openAPIAdapter) resolveSecurity(op *openAPIOperation) ([]securityCredential, error) {
+	if len(op.security) == 0 {
+		return nil, nil
+	}
+	var missing []string
+	for _, req := range op.security {
+		if len(req) == 0 {
+			return nil, nil
+		}
+		creds, lack := a.credentialsFor(req)
+		if len(lack) == 0 {
+			return creds, nil
+		}
+		missing = append(missing, lack...)
+	}
+	sort.Strings(missing)
+	return nil, errors.E(errors.KindValidation,
+		fmt.Sprintf("%s requires credentials for security scheme(s) %s", op.spec.ID, strings.Join(missing, ", ")))
+}
+
+func (a *openAPIAdapter) credentialsFor(req openapi3.SecurityRequirement) ([]securityCredential, []string) {
+	names := make([]string, 0, len(req))
+	for name := range req {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		creds []securityCredential
+		lack  []string
+	)
+	for _, name := range names {
+		value, ok := a.binding.AuthMethods[name]
+		scheme := a.securityScheme(name)
+		if !ok || value == "" || scheme == nil {
+			lack = append(lack, name)
+			continue
+		}
+		creds = append(creds, securityCredential{name: name, scheme: scheme, value: value, scopes: req[name]})
+	}
+	return creds, lack
+}
+
+func (a *openAPIAdapter) securityScheme(name string) *openapi3.SecurityScheme {
+	if a.doc == nil || a.doc.Components == nil {
+		return nil
+	}
+	ref := a.doc.Components.SecuritySchemes[name]
+	if ref == nil {
+		return nil
+	}
+	return ref.Value
+}
+
+// applySecurity 把凭据注入请求；OAuth2 会按需向 tokenUrl 取令牌
+func (a *openAPIAdapter) applySecurity(req *http.Request, creds []securityCredential) error {
+	for _, c := range creds {
+		s := c.scheme
+		switch strings.ToLower(s.Type) {
+		case "apikey":
+			switch s.In {
+			case openapi3.ParameterInHeader:
+				req.Header.Set(s.Name, c.value)
+			case openapi3.ParameterInQuery:
+				q := req.URL.Query()
+				q.Set(s.Name, c.value)
+				req.URL.RawQuery = q.Encode()
+			case openapi3.ParameterInCookie:
+				req.AddCookie(&http.Cookie{Name: s.Name, Value: c.value})
+			default:
+				return errors.E(errors.KindValidation, fmt.Sprintf("security scheme %s: unsupported apiKey location %q", c.name, s.In))
+			}
+		case "http":
+			switch strings.ToLower(s.Scheme) {
+			case "basic":
+				user, pass, ok := strings.Cut(c.value, ":")
+				if !ok {
+					return errors.E(errors.KindValidation, fmt.Sprintf("security scheme %s: basic credentials must be user:password", c.name))
+				}
+				req.SetBasicAuth(user, pass)
+			case "bearer":
+				req.Header.Set("Authorization", "Bearer "+c.value)
+			default:
+				return errors.E(errors.KindValidation, fmt.Sprintf("security scheme %s: unsupported http scheme %q", c.name, s.Scheme))
+			}
+		case "oauth2":
+			tok, err := a.oauth2Token(c)
+			if err != nil {
+				return err
+			}
+			tok.SetAuthHeader(req)
+		default:
+			return errors.E(errors.KindValidation, fmt.Sprintf("security scheme %s: unsupported type %q", c.name, s.Type))
+		}
+	}
+	return nil
+}
+
+// tokenFetchTimeout 请求 oauth2 令牌端点的超时
+const tokenFetchTimeout = 10 * time.Second
+
+// oauth2Token client-credentials 流程；TokenSource 按 scheme+scope 缓存，到期前自动刷新
+func (a *openAPIAdapter) oauth2Token(c securityCredential) (*oauth2.Token, error) {
+	if c.scheme.Flows == nil || c.scheme.Flows.ClientCredentials == nil {
+		return nil, errors.E(errors.KindValidation, fmt.Sprintf("security scheme %s: only clientCredentials flow is supported", c.name))
+	}
+	id, secret, ok := strings.Cut(c.value, ":")
+	if !ok {
+		return nil, errors.E(errors.KindValidation, fmt.Sprintf("security scheme %s: client credentials must be clientId:clientSecret", c.name))
+	}
+
+	key := c.name + "|" + id + "|" + strings.Join(c.scopes, " ")
+	a.mu.Lock()
+	ts, ok := a.tokens[key]
+	if !ok {
+		cfg := &clientcredentials.Config{
+			ClientID:     id,
+			ClientSecret: secret,
+			TokenURL:     c.scheme.Flows.ClientCredentials.TokenURL,
+			Scopes:       c.scopes,
+		}
+		// 令牌缓存跨请求复用，不能绑定到单次调用的 ctx，改用带超时的 client 兜底
+		hc := &http.Client{Transport: a.http.Transport, Timeout: tokenFetchTimeout}
+		ts = cfg.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, hc))
+		a.tokens[key] = ts
+	}
+	a.mu.Unlock()
+
+	tok, err := ts.Token()
+	if err != nil {
+		return nil, errors.Unavailable(err, fmt.Sprintf("security scheme %s: fetch oauth2 token", c.name))
+	}
+	return tok, nil
+}
+
+//Personal.AI order the ending
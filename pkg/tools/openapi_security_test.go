@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+const securedSpec = `
+openapi: 3.0.3
+info:
+  title: secured
+  version: 1.0.0
+security:
+  - bearer: []
+paths:
+  /key:
+    get:
+      operationId: key
+      security:
+        - headerKey: []
+          queryKey: []
+      responses: {"200": {description: ok}}
+  /cookie:
+    get:
+      operationId: cookie
+      security:
+        - cookieKey: []
+      responses: {"200": {description: ok}}
+  /either:
+    get:
+      operationId: either
+      security:
+        - basic: []
+        - bearer: []
+      responses: {"200": {description: ok}}
+  /default:
+    get:
+      operationId: default
+      responses: {"200": {description: ok}}
+  /open:
+    get:
+      operationId: open
+      security: []
+      responses: {"200": {description: ok}}
+  /oauth:
+    get:
+      operationId: oauth
+      security:
+        - cc: [pets.read]
+      responses: {"200": {description: ok}}
+components:
+  securitySchemes:
+    headerKey: {type: apiKey, in: header, name: X-API-Key}
+    queryKey: {type: apiKey, in: query, name: api_key}
+    cookieKey: {type: apiKey, in: cookie, name: sid}
+    basic: {type: http, scheme: basic}
+    bearer: {type: http, scheme: bearer}
+    cc:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: %s/token
+          scopes:
+            pets.read: read pets
+`
+
+func TestOpenAPISecurity(t *testing.T) {
+	var (
+		tokenHits atomic.Int32
+		upstream  atomic.Int32
+		last      *http.Request
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenHits.Add(1)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+			assert.Equal(t, "pets.read", r.Form.Get("scope"))
+			id, secret, ok := r.BasicAuth()
+			if !ok {
+				id, secret = r.Form.Get("client_id"), r.Form.Get("client_secret")
+			}
+			assert.Equal(t, "cid", id)
+			assert.Equal(t, "csecret", secret)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"tok-1","token_type":"Bearer","expires_in":3600}`)
+			return
+		}
+		upstream.Add(1)
+		last = r.Clone(context.Background())
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+
+	newAdapter := func(auth map[string]string) OpenAPIAdapter {
+		a := NewOpenAPIAdapter(WithOpenAPIBinding(&apis.OpenAPIBinding{BaseURL: ts.URL, AuthMethods: auth}))
+		require.NoError(t, a.LoadSpec(ctx, []byte(fmt.Sprintf(securedSpec, ts.URL))))
+		return a
+	}
+
+	a := newAdapter(map[string]string{
+		"headerKey": "h-key",
+		"queryKey":  "q-key",
+		"cookieKey": "c-key",
+		"basic":     "alice:pw",
+		"cc":        "cid:csecret",
+	})
+
+	_, err := a.Invoke(ctx, "key", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "h-key", last.Header.Get("X-API-Key"))
+	assert.Equal(t, "q-key", last.URL.Query().Get("api_key"))
+
+	_, err = a.Invoke(ctx, "cookie", nil)
+	require.NoError(t, err)
+	c, err := last.Cookie("sid")
+	require.NoError(t, err)
+	assert.Equal(t, "c-key", c.Value)
+
+	// basic 与 bearer 二选一，只配置了 basic
+	_, err = a.Invoke(ctx, "either", nil)
+	require.NoError(t, err)
+	user, pass, ok := last.BasicAuth()
+	require.True(t, ok)
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, "pw", pass)
+
+	_, err = a.Invoke(ctx, "open", nil)
+	require.NoError(t, err)
+	assert.Empty(t, last.Header.Get("Authorization"))
+
+	// 令牌在过期前被复用
+	for i := 0; i < 3; i++ {
+		_, err = a.Invoke(ctx, "oauth", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer tok-1", last.Header.Get("Authorization"))
+	}
+	assert.Equal(t, int32(1), tokenHits.Load())
+
+	// 文档级 bearer 缺失：校验错误且不触达上游
+	before := upstream.Load()
+	_, err = a.Invoke(ctx, "default", nil)
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "bearer")
+	assert.Equal(t, before, upstream.Load())
+
+	b := newAdapter(map[string]string{"bearer": "b-tok"})
+	_, err = b.Invoke(ctx, "default", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer b-tok", last.Header.Get("Authorization"))
+
+	// 多 scheme 组合缺一不可
+	_, err = b.Invoke(ctx, "key", nil)
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	assert.Equal(t, before+1, upstream.Load())
+}
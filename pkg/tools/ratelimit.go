@@ -0,0 +1,239 @@
+// pkg/tools/ratelimit.go
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+const (
+	// anonymousCaller 未认证调用共享同一配额
+	anonymousCaller = "anonymous"
+	// defaultRateWindow RateLimit.Window 为空时的统计窗口
+	defaultRateWindow = time.Second
+	// concurrencyRetryAfter 并发超限时无法预知何时空出，给一个保守的重试建议
+	concurrencyRetryAfter = time.Second
+	// idleBucketTTL 长时间未使用的桶被回收，防止调用方数量无界增长
+	idleBucketTTL = 10 * time.Minute
+)
+
+// Limiter 按 工具×调用方 执行 ToolSpec.RateLimit
+type Limiter interface {
+	// Acquire 成功时返回的 release 必须在调用结束后执行；超限时最多排队 MaxWait，
+	// 仍不满足则返回 KindRateLimited，RetryAfterOf 给出建议的重试间隔
+	Acquire(ctx context.Context, spec *apis.ToolSpec, caller string) (release func(), err error)
+}
+
+var (
+	rateAllowed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "agenticai_tool_ratelimit_allowed_total",
+		Help: "tool calls admitted by the per-tool limiter",
+	}, []string{"tool"})
+	rateRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "agenticai_tool_ratelimit_rejected_total",
+		Help: "tool calls rejected by the per-tool limiter; reason is rate or concurrency",
+	}, []string{"tool", "reason"})
+	rateWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "agenticai_tool_ratelimit_wait_seconds",
+		Help:    "time admitted calls spent queued in the per-tool limiter",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+	}, []string{"tool"})
+	rateInflight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "agenticai_tool_inflight",
+		Help: "tool calls currently holding a concurrency slot",
+	}, []string{"tool"})
+)
+
+type limitBucket struct {
+	cfg      apis.RateLimit
+	rate     *rate.Limiter // nil 表示不限速
+	slots    chan struct{} // nil 表示不限并发
+	inflight int
+	lastUsed time.Time
+}
+
+type limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*limitBucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewLimiter 进程内限流器；多副本部署时配额按副本计算
+func NewLimiter() Limiter {
+	return &limiter{buckets: make(map[string]*limitBucket), now: time.Now}
+}
+
+func (l *limiter) Acquire(ctx context.Context, spec *apis.ToolSpec, caller string) (func(), error) {
+	if spec.RateLimit == nil || (spec.RateLimit.Max <= 0 && spec.RateLimit.MaxConcurrent <= 0) {
+		return func() {}, nil
+	}
+	if caller == "" {
+		caller = anonymousCaller
+	}
+	start := l.now()
+	b := l.bucket(spec, caller, start)
+	var maxWait time.Duration
+	if spec.RateLimit.MaxWait != nil {
+		maxWait = *spec.RateLimit.MaxWait
+	}
+	deadline := start.Add(maxWait)
+
+	// 先占并发槽，避免拿到令牌后又因并发被拒而白白消耗配额
+	if b.slots != nil {
+		if err := acquireSlot(ctx, spec.ID, b.slots, remaining(deadline, l.now())); err != nil {
+			l.done(b)
+			if errors.KindOf(err) == errors.KindRateLimited {
+				rateRejected.WithLabelValues(spec.Name, "concurrency").Inc()
+			}
+			return nil, err
+		}
+	}
+	release := l.releaser(spec.Name, b)
+
+	if b.rate != nil {
+		now := l.now()
+		r := b.rate.ReserveN(now, 1)
+		delay := r.DelayFrom(now)
+		if !r.OK() || delay > remaining(deadline, now) {
+			r.CancelAt(now)
+			release()
+			rateRejected.WithLabelValues(spec.Name, "rate").Inc()
+			return nil, errors.RateLimited(delay,
+				fmt.Sprintf("tool %s: rate limit of %d per %s exceeded for %s", spec.ID, b.cfg.Max, windowOf(b.cfg), caller))
+		}
+		if delay > 0 {
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				r.Cancel()
+				release()
+				return nil, errors.Timeout(ctx.Err(), fmt.Sprintf("tool %s: waiting for rate limit", spec.ID))
+			}
+		}
+	}
+	rateAllowed.WithLabelValues(spec.Name).Inc()
+	rateWait.WithLabelValues(spec.Name).Observe(l.now().Sub(start).Seconds())
+	return release, nil
+}
+
+// bucket 取出或新建桶；配置变化（重新注册）时换新桶，旧桶上的在途调用照常释放
+func (l *limiter) bucket(spec *apis.ToolSpec, caller string, now time.Time) *limitBucket {
+	key := spec.ID + "\x00" + caller
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweepLocked(now)
+	b, ok := l.buckets[key]
+	if !ok || !sameRateLimit(&b.cfg, spec.RateLimit) {
+		b = newLimitBucket(*spec.RateLimit)
+		l.buckets[key] = b
+	}
+	b.inflight++
+	b.lastUsed = now
+	return b
+}
+
+// releaser 在已占到并发槽后调用；返回的函数幂等
+func (l *limiter) releaser(tool string, b *limitBucket) func() {
+	if b.slots != nil {
+		rateInflight.WithLabelValues(tool).Inc()
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if b.slots != nil {
+				<-b.slots
+				rateInflight.WithLabelValues(tool).Dec()
+			}
+			l.done(b)
+		})
+	}
+}
+
+// done 归还 bucket 中预占的 inflight 计数，之后该桶才可能被回收
+func (l *limiter) done(b *limitBucket) {
+	l.mu.Lock()
+	b.inflight--
+	b.lastUsed = l.now()
+	l.mu.Unlock()
+}
+
+// sweepLocked 每个 TTL 周期最多扫描一次
+func (l *limiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketTTL {
+		return
+	}
+	l.lastSweep = now
+	for k, b := range l.buckets {
+		if b.inflight == 0 && now.Sub(b.lastUsed) >= idleBucketTTL {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+func newLimitBucket(cfg apis.RateLimit) *limitBucket {
+	b := &limitBucket{cfg: cfg}
+	if cfg.Max > 0 {
+		b.rate = rate.NewLimiter(rate.Limit(float64(cfg.Max)/windowOf(cfg).Seconds()), int(cfg.Max))
+	}
+	if cfg.MaxConcurrent > 0 {
+		b.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return b
+}
+
+func acquireSlot(ctx context.Context, tool string, slots chan struct{}, wait time.Duration) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if wait <= 0 {
+		return errors.RateLimited(concurrencyRetryAfter, fmt.Sprintf("tool %s: concurrency limit of %d reached", tool, cap(slots)))
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-t.C:
+		return errors.RateLimited(concurrencyRetryAfter, fmt.Sprintf("tool %s: concurrency limit of %d reached", tool, cap(slots)))
+	case <-ctx.Done():
+		return errors.Timeout(ctx.Err(), fmt.Sprintf("tool %s: waiting for concurrency slot", tool))
+	}
+}
+
+func remaining(deadline, now time.Time) time.Duration {
+	if d := deadline.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func windowOf(cfg apis.RateLimit) time.Duration {
+	if cfg.Window == nil || *cfg.Window <= 0 {
+		return defaultRateWindow
+	}
+	return *cfg.Window
+}
+
+func sameRateLimit(a, b *apis.RateLimit) bool {
+	durEq := func(x, y *time.Duration) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	}
+	return a.Max == b.Max && a.MaxConcurrent == b.MaxConcurrent &&
+		durEq(a.Window, b.Window) && durEq(a.MaxWait, b.MaxWait)
+}
+
+//Personal.AI order the ending
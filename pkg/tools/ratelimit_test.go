@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+	"github.com/turtacn/agenticai/pkg/security"
+)
+
+func dur(d time.Duration) *time.Duration { return &d }
+
+func TestLimiterRate(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter()
+	spec := &apis.ToolSpec{ID: "paid@1", Name: "paid", RateLimit: &apis.RateLimit{Max: 2, Window: dur(time.Hour)}}
+
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire(ctx, spec, "agent-a")
+		require.NoError(t, err)
+		release()
+	}
+	rejected := counterDelta(rateRejected.WithLabelValues("paid", "rate"))
+	_, err := l.Acquire(ctx, spec, "agent-a")
+	assert.Equal(t, errors.KindRateLimited, errors.KindOf(err))
+	assert.InDelta(t, 30*time.Minute, errors.RetryAfterOf(err), float64(time.Second))
+	assert.Equal(t, 1.0, rejected())
+
+	// 其它调用方的配额互不影响
+	release, err := l.Acquire(ctx, spec, "agent-b")
+	require.NoError(t, err)
+	release()
+
+	// 配置变化后重新计数
+	spec.RateLimit = &apis.RateLimit{Max: 3, Window: dur(time.Hour)}
+	release, err = l.Acquire(ctx, spec, "agent-a")
+	require.NoError(t, err)
+	release()
+
+	// 未配置限制时直接放行
+	release, err = l.Acquire(ctx, &apis.ToolSpec{ID: "free"}, "")
+	require.NoError(t, err)
+	release()
+}
+
+func TestLimiterRateQueue(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter()
+	spec := &apis.ToolSpec{ID: "q", Name: "q", RateLimit: &apis.RateLimit{Max: 1, Window: dur(50 * time.Millisecond), MaxWait: dur(time.Second)}}
+
+	release, err := l.Acquire(ctx, spec, "")
+	require.NoError(t, err)
+	release()
+	start := time.Now()
+	release, err = l.Acquire(ctx, spec, "")
+	require.NoError(t, err)
+	release()
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	// 排队期间 ctx 结束
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(cctx, spec, "")
+	assert.Equal(t, errors.KindTimeout, errors.KindOf(err))
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter()
+	spec := &apis.ToolSpec{ID: "c", Name: "c", RateLimit: &apis.RateLimit{MaxConcurrent: 1}}
+
+	release, err := l.Acquire(ctx, spec, "")
+	require.NoError(t, err)
+	_, err = l.Acquire(ctx, spec, "")
+	assert.Equal(t, errors.KindRateLimited, errors.KindOf(err))
+	assert.Equal(t, concurrencyRetryAfter, errors.RetryAfterOf(err))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rateInflight.WithLabelValues("c")))
+	release()
+	release() // 幂等
+	assert.Equal(t, 0.0, testutil.ToFloat64(rateInflight.WithLabelValues("c")))
+
+	// 允许排队时等待在途调用释放
+	spec.RateLimit = &apis.RateLimit{MaxConcurrent: 1, MaxWait: dur(time.Second)}
+	release, err = l.Acquire(ctx, spec, "")
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+	release2, err := l.Acquire(ctx, spec, "")
+	require.NoError(t, err)
+	release2()
+}
+
+func TestLimiterSweep(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiter().(*limiter)
+	l.now = clock.Now
+	spec := &apis.ToolSpec{ID: "s", Name: "s", RateLimit: &apis.RateLimit{Max: 1}}
+
+	release, err := l.Acquire(context.Background(), spec, "a")
+	require.NoError(t, err)
+	clock.Advance(2 * idleBucketTTL)
+	// 在途调用的桶不回收
+	other := &apis.ToolSpec{ID: "other", RateLimit: spec.RateLimit}
+	releaseOther, err := l.Acquire(context.Background(), other, "b")
+	require.NoError(t, err)
+	assert.Len(t, l.buckets, 2)
+	release()
+	releaseOther()
+
+	clock.Advance(2 * idleBucketTTL)
+	_, err = l.Acquire(context.Background(), other, "c")
+	require.NoError(t, err)
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestInvokerRateLimit(t *testing.T) {
+	ctx := context.Background()
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{
+		Name:       "limited",
+		CustomExec: &apis.CustomBinding{Image: "noop"},
+		RateLimit:  &apis.RateLimit{Max: 1, Window: dur(time.Hour)},
+	}))
+	inv := NewInvoker(reg, &fakeSandboxes{run: func(*sandbox.SandboxSpec) error { return nil }})
+
+	alice := security.WithCaller(ctx, "spiffe://agenticai/agent/alice")
+	_, err := inv.Invoke(alice, "limited", nil)
+	require.NoError(t, err)
+	limited := counterDelta(invokeErrors.WithLabelValues("limited", bindingCustom, string(errors.KindRateLimited)))
+	_, err = inv.Invoke(alice, "limited", nil)
+	assert.Equal(t, errors.KindRateLimited, errors.KindOf(err))
+	assert.Equal(t, 1.0, limited())
+
+	_, err = inv.Invoke(security.WithCaller(ctx, "spiffe://agenticai/agent/bob"), "limited", nil)
+	require.NoError(t, err)
+}
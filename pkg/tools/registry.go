@@ -4,6 +4,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -22,6 +23,190 @@ type Registry interface {
 	Deregister(ctx context.Context, toolID string) error
 	List(ctx context.Context, filter *apis.ToolFilter) ([]*apis.Metadata, error)
 	Get(ctx context.Context, toolID string) (*apis.Metadata, error)
+	// Heartbeat 续期，超过 TTL 未续期的工具视为下线
+	Heartbeat(ctx context.Context, toolID string) error
+	// Watch 先回放当前匹配的工具（Added），之后推送增量事件；ctx 结束时关闭通道
+	Watch(ctx context.Context, filter *apis.ToolFilter) (<-chan RegistryEvent, error)
+	// Resolve 解析 id / name / name@<semver 约束> / name@sha256:<hex>，见 ParseToolRef
+	Resolve(ctx context.Context, ref string) (*apis.ToolSpec, error)
+}
+
+// RegistryEventType 注册表事件类型
+type RegistryEventType string
+
+const (
+	RegistryEventAdded   RegistryEventType = "Added"
+	RegistryEventUpdated RegistryEventType = "Updated"
+	RegistryEventDeleted RegistryEventType = "Deleted"
+)
+
+// RegistryEvent Watch 推送的事件；Deleted 时 Tool 为删除前的最后状态
+type RegistryEvent struct {
+	Type RegistryEventType
+	Tool *apis.ToolSpec
+}
+
+// RegistryOption 注册表可选项
+type RegistryOption func(*registryOptions)
+
+type registryOptions struct {
+	ttl   time.Duration
+	now   func() time.Time
+	sweep time.Duration // 后台清理周期，默认 ttl/2
+}
+
+// WithTTL 心跳超时时间
+func WithTTL(ttl time.Duration) RegistryOption {
+	return func(o *registryOptions) { o.ttl = ttl }
+}
+
+func newRegistryOptions(opts []RegistryOption) registryOptions {
+	o := registryOptions{ttl: defaultTTL, now: time.Now}
+	for _, fn := range opts {
+		fn(&o)
+	}
+	if o.sweep <= 0 {
+		o.sweep = o.ttl / 2
+	}
+	if o.sweep <= 0 {
+		o.sweep = time.Second
+	}
+	return o
+}
+
+var (
+	registeredTotal = promauto.NewCounter(prometheus.CounterOpts{Name: "agenticai_tools_registered_total", Help: "total tools ever registered"})
+	deregistered    = promauto.NewCounter(prometheus.CounterOpts{Name: "agenticai_tools_deregistered_total", Help: "total tools deregistered"})
+	expiredTotal    = promauto.NewCounter(prometheus.CounterOpts{Name: "agenticai_tools_expired_total", Help: "total tools expired without heartbeat"})
+	activeGauge     = promauto.NewGauge(prometheus.GaugeOpts{Name: "agenticai_tools_active", Help: "currently active tools"})
+)
+
+// MatchFilter Name/Category 精确匹配；Tags 中每个键都须存在，值为空时只要求键存在
+func MatchFilter(spec *apis.ToolSpec, filter *apis.ToolFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.Name != "" && spec.Name != filter.Name {
+		return false
+	}
+	if filter.Category != "" && spec.Category != filter.Category {
+		return false
+	}
+	for k, v := range filter.Tags {
+		got, ok := spec.Tags[k]
+		if !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+func metadataOf(spec *apis.ToolSpec) *apis.Metadata {
+	return &apis.Metadata{ID: spec.ID, Name: spec.Name, Version: spec.Version, Digest: spec.Digest}
+}
+
+func sortMetadata(out []*apis.Metadata) {
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+}
+
+// ------------------ Watch 分发 ------------------
+
+// watchHub 每个订阅者独立的无界队列，发布方永不阻塞
+type watchHub struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]*subscriber
+}
+
+type subscriber struct {
+	filter *apis.ToolFilter
+	mu     sync.Mutex
+	queue  []RegistryEvent
+	notify chan struct{}
+	out    chan RegistryEvent
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{subs: make(map[int]*subscriber)}
+}
+
+// subscribe initial 作为首批 Added 事件入队，调用方需保证与 publish 互斥
+func (h *watchHub) subscribe(ctx context.Context, filter *apis.ToolFilter, initial []*apis.ToolSpec) <-chan RegistryEvent {
+	s := &subscriber{
+		filter: filter,
+		notify: make(chan struct{}, 1),
+		out:    make(chan RegistryEvent),
+	}
+	for _, spec := range initial {
+		if MatchFilter(spec, filter) {
+			s.queue = append(s.queue, RegistryEvent{Type: RegistryEventAdded, Tool: spec.DeepCopy()})
+		}
+	}
+	h.mu.Lock()
+	id := h.next
+	h.next++
+	h.subs[id] = s
+	h.mu.Unlock()
+
+	go func() {
+		s.run(ctx)
+		h.mu.Lock()
+		delete(h.subs, id)
+		h.mu.Unlock()
+	}()
+	return s.out
+}
+
+// publish 按订阅者过滤条件把 old→cur 的变化翻译成 Added/Updated/Deleted
+func (h *watchHub) publish(old, cur *apis.ToolSpec) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range h.subs {
+		om := old != nil && MatchFilter(old, s.filter)
+		nm := cur != nil && MatchFilter(cur, s.filter)
+		switch {
+		case om && nm:
+			s.push(RegistryEvent{Type: RegistryEventUpdated, Tool: cur.DeepCopy()})
+		case nm:
+			s.push(RegistryEvent{Type: RegistryEventAdded, Tool: cur.DeepCopy()})
+		case om:
+			s.push(RegistryEvent{Type: RegistryEventDeleted, Tool: old.DeepCopy()})
+		}
+	}
+}
+
+func (s *subscriber) push(ev RegistryEvent) {
+	s.mu.Lock()
+	s.queue = append(s.queue, ev)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscriber) run(ctx context.Context) {
+	defer close(s.out)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.notify:
+				continue
+			}
+		}
+		ev := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		select {
+		case s.out <- ev:
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 // ------------------ 内存实现 ------------------
@@ -37,80 +222,161 @@ type item struct {
 type inMemRegistry struct {
 	mu   sync.RWMutex
 	data map[string]*item
-	ttl  time.Duration
-
-	registeredTotal prometheus.Counter
-	deregistered    prometheus.Counter
-	activeGauge     prometheus.Gauge
+	opts registryOptions
+	hub  *watchHub
 }
 
-func NewInMemRegistry() Registry {
+// NewInMemRegistry 进程内注册表；条目需在 TTL 内 Register/Heartbeat 续期
+func NewInMemRegistry(opts ...RegistryOption) Registry {
 	return &inMemRegistry{
-		data:     make(map[string]*item),
-		ttl:      defaultTTL,
-		registeredTotal: promauto.NewCounter(prometheus.CounterOpts{Name: "agenticai_tools_registered_total", Help: "total tools ever registered"}),
-		deregistered:   promauto.NewCounter(prometheus.CounterOpts{Name: "agenticai_tools_deregistered_total", Help: "total tools deregistered"}),
-		activeGauge:    promauto.NewGauge(prometheus.GaugeOpts{Name: "agenticai_tools_active", Help: "currently active tools"}),
+		data: make(map[string]*item),
+		opts: newRegistryOptions(opts),
+		hub:  newWatchHub(),
 	}
 }
 
 func (r *inMemRegistry) Register(ctx context.Context, spec *apis.ToolSpec) error {
 	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer("tools").Start(ctx, "Registry.Register")
 	defer span.End()
+	spec, err := sealSpec(spec)
+	if err != nil {
+		return err
+	}
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	now := time.Now()
+	now := r.opts.now()
+	r.sweepLocked(now)
 	id := spec.ID
-	r.data[id] = &item{
-		meta:      apis.Metadata{ID: id, Name: spec.Name, Version: spec.Version, Digest: spec.Digest},
+	var old *apis.ToolSpec
+	if prev, ok := r.data[id]; ok {
+		old = &prev.spec
+	}
+	it := &item{
+		meta:      *metadataOf(spec),
 		spec:      *spec,
-		expiresAt: now.Add(r.ttl),
+		expiresAt: now.Add(r.opts.ttl),
 	}
-	r.registeredTotal.Inc()
-	r.activeGauge.Set(float64(len(r.data)))
-	logger.Info(ctx, "tool registered", zap.String("id", id), zap.String("name", spec.Name))
+	r.data[id] = it
+	r.hub.publish(old, &it.spec)
+	registeredTotal.Inc()
+	activeGauge.Set(float64(len(r.data)))
+	logger.Info(ctx, "tool registered", zap.String("id", id), zap.String("name", spec.Name),
+		zap.String("version", spec.Version), zap.String("digest", spec.Digest))
 	return nil
 }
 
 func (r *inMemRegistry) Deregister(ctx context.Context, toolID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, ok := r.data[toolID]; !ok {
+	r.sweepLocked(r.opts.now())
+	it, ok := r.data[toolID]
+	if !ok {
 		return errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", toolID))
 	}
 	delete(r.data, toolID)
-	r.deregistered.Inc()
-	r.activeGauge.Set(float64(len(r.data)))
+	r.hub.publish(&it.spec, nil)
+	deregistered.Inc()
+	activeGauge.Set(float64(len(r.data)))
 	logger.Info(ctx, "tool deregistered", zap.String("toolID", toolID))
 	return nil
 }
 
 func (r *inMemRegistry) List(ctx context.Context, filter *apis.ToolFilter) ([]*apis.Metadata, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweepLocked(r.opts.now())
 
 	out := make([]*apis.Metadata, 0, len(r.data))
 	for _, v := range r.data {
-		if filter == nil {
-			out = append(out, &v.meta)
+		if !MatchFilter(&v.spec, filter) {
 			continue
 		}
-		if filter.Name != "" && v.meta.Name != filter.Name {
-			continue
-		}
-		out = append(out, &v.meta)
+		meta := v.meta
+		out = append(out, &meta)
 	}
+	sortMetadata(out)
 	return out, nil
 }
 
 func (r *inMemRegistry) Get(ctx context.Context, toolID string) (*apis.Metadata, error) {
-	r.mu.RLock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweepLocked(r.opts.now())
 	item, ok := r.data[toolID]
-	r.mu.RUnlock()
 	if !ok {
 		return nil, errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", toolID))
 	}
-	return &item.meta, nil
+	meta := item.meta
+	return &meta, nil
+}
+
+func (r *inMemRegistry) Heartbeat(ctx context.Context, toolID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.opts.now()
+	r.sweepLocked(now)
+	it, ok := r.data[toolID]
+	if !ok {
+		return errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", toolID))
+	}
+	it.expiresAt = now.Add(r.opts.ttl)
+	return nil
+}
+
+func (r *inMemRegistry) Watch(ctx context.Context, filter *apis.ToolFilter) (<-chan RegistryEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweepLocked(r.opts.now())
+	initial := make([]*apis.ToolSpec, 0, len(r.data))
+	for _, v := range r.data {
+		initial = append(initial, &v.spec)
+	}
+	sort.Slice(initial, func(i, j int) bool { return initial[i].ID < initial[j].ID })
+	go r.sweepLoop(ctx)
+	return r.hub.subscribe(ctx, filter, initial), nil
+}
+
+// sweepLoop 订阅期间定时清理，没有其它调用时订阅者也能及时收到过期的 Deleted；
+// 随订阅的 ctx 结束
+func (r *inMemRegistry) sweepLoop(ctx context.Context) {
+	t := time.NewTicker(r.opts.sweep)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			r.mu.Lock()
+			r.sweepLocked(r.opts.now())
+			r.mu.Unlock()
+		}
+	}
 }
+
+func (r *inMemRegistry) Resolve(ctx context.Context, ref string) (*apis.ToolSpec, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweepLocked(r.opts.now())
+	candidates := make([]*apis.ToolSpec, 0, len(r.data))
+	for _, v := range r.data {
+		candidates = append(candidates, &v.spec)
+	}
+	return resolveRef(ref, candidates)
+}
+
+// sweepLocked 清理过期条目并通知订阅者；每次调用前与订阅期间定时执行
+func (r *inMemRegistry) sweepLocked(now time.Time) {
+	for id, it := range r.data {
+		if now.Before(it.expiresAt) {
+			continue
+		}
+		delete(r.data, id)
+		r.hub.publish(&it.spec, nil)
+		expiredTotal.Inc()
+		logger.Info(context.Background(), "tool expired", zap.String("toolID", id))
+	}
+	activeGauge.Set(float64(len(r.data)))
+}
+
 //Personal.AI order the ending
@@ -0,0 +1,426 @@
+// pkg/tools/registry_crd.go
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/internal/logger"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// HeartbeatAnnotation 记录最近一次心跳（RFC3339）；没有该注解的 Tool 不参与过期
+const HeartbeatAnnotation = "agenticai.io/heartbeat"
+
+// maxObjectName 为哈希后缀预留空间，保证对象名不超过 63
+const maxObjectName = 52
+
+type crdEntry struct {
+	spec      apis.ToolSpec
+	key       types.NamespacedName
+	heartbeat time.Time
+	visible   bool // 订阅者视角下是否存在
+}
+
+// crdRegistry 写走 API Server，读走 informer 喂养的本地缓存
+type crdRegistry struct {
+	client    client.Client
+	namespace string
+	opts      registryOptions
+
+	mu    sync.RWMutex
+	tools map[string]*crdEntry // spec.ID -> entry
+	hub   *watchHub
+}
+
+// NewCRDRegistry 以 Tool CRD 为后端；informers 通常为 manager.GetCache()。
+// 过期工具在本地即不可见，ctx 结束时后台的过期通知随之退出；对象删除只由 ExpirySweeper 执行。
+// namespace 为空时写入 default 并读取全部命名空间。
+func NewCRDRegistry(
+	ctx context.Context,
+	c client.Client,
+	informers cache.Informers,
+	namespace string,
+	opts ...RegistryOption,
+) (Registry, error) {
+	r := &crdRegistry{
+		client:    c,
+		namespace: namespace,
+		opts:      newRegistryOptions(opts),
+		tools:     make(map[string]*crdEntry),
+		hub:       newWatchHub(),
+	}
+	inf, err := informers.GetInformer(ctx, &apis.Tool{}, cache.BlockUntilSynced(false))
+	if err != nil {
+		return nil, errors.Unavailable(err, "get tool informer")
+	}
+	if _, err := inf.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { r.onUpsert(obj) },
+		UpdateFunc: func(_, obj interface{}) { r.onUpsert(obj) },
+		DeleteFunc: r.onDelete,
+	}); err != nil {
+		return nil, errors.Internal(err, "add tool event handler")
+	}
+	go r.sweepLoop(ctx)
+	return r, nil
+}
+
+func (r *crdRegistry) writeNamespace() string {
+	if r.namespace == "" {
+		return metav1.NamespaceDefault
+	}
+	return r.namespace
+}
+
+func (r *crdRegistry) Register(ctx context.Context, spec *apis.ToolSpec) error {
+	spec, err := sealSpec(spec)
+	if err != nil {
+		return err
+	}
+	now := r.opts.now().UTC().Format(time.RFC3339Nano)
+	key := r.keyFor(spec.ID)
+
+	var tool apis.Tool
+	err = r.client.Get(ctx, key, &tool)
+	switch {
+	case apierrors.IsNotFound(err):
+		tool = apis.Tool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        key.Name,
+				Namespace:   key.Namespace,
+				Annotations: map[string]string{HeartbeatAnnotation: now},
+			},
+			Spec: *spec,
+		}
+		if err := r.client.Create(ctx, &tool); err != nil {
+			return apiError(err, fmt.Sprintf("create tool %s", spec.ID))
+		}
+	case err != nil:
+		return apiError(err, fmt.Sprintf("get tool %s", spec.ID))
+	default:
+		tool.Spec = *spec
+		if tool.Annotations == nil {
+			tool.Annotations = map[string]string{}
+		}
+		tool.Annotations[HeartbeatAnnotation] = now
+		if err := r.client.Update(ctx, &tool); err != nil {
+			return apiError(err, fmt.Sprintf("update tool %s", spec.ID))
+		}
+	}
+	registeredTotal.Inc()
+	logger.Info(ctx, "tool registered", zap.String("id", spec.ID), zap.String("object", key.String()))
+	return nil
+}
+
+func (r *crdRegistry) Deregister(ctx context.Context, toolID string) error {
+	tool := &apis.Tool{}
+	key := r.keyFor(toolID)
+	tool.Name, tool.Namespace = key.Name, key.Namespace
+	if err := r.client.Delete(ctx, tool); err != nil {
+		return apiError(err, fmt.Sprintf("delete tool %s", toolID))
+	}
+	deregistered.Inc()
+	logger.Info(ctx, "tool deregistered", zap.String("toolID", toolID))
+	return nil
+}
+
+func (r *crdRegistry) List(ctx context.Context, filter *apis.ToolFilter) ([]*apis.Metadata, error) {
+	now := r.opts.now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*apis.Metadata, 0, len(r.tools))
+	for _, e := range r.tools {
+		if r.expired(e, now) || !MatchFilter(&e.spec, filter) {
+			continue
+		}
+		out = append(out, metadataOf(&e.spec))
+	}
+	sortMetadata(out)
+	return out, nil
+}
+
+func (r *crdRegistry) Get(ctx context.Context, toolID string) (*apis.Metadata, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.tools[toolID]
+	if !ok || r.expired(e, r.opts.now()) {
+		return nil, errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", toolID))
+	}
+	return metadataOf(&e.spec), nil
+}
+
+// Heartbeat 以 merge patch 更新注解，避免与其它写者的 resourceVersion 冲突
+func (r *crdRegistry) Heartbeat(ctx context.Context, toolID string) error {
+	key := r.keyFor(toolID)
+	tool := &apis.Tool{}
+	tool.Name, tool.Namespace = key.Name, key.Namespace
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`,
+		HeartbeatAnnotation, r.opts.now().UTC().Format(time.RFC3339Nano))
+	if err := r.client.Patch(ctx, tool, client.RawPatch(types.MergePatchType, []byte(patch))); err != nil {
+		return apiError(err, fmt.Sprintf("heartbeat tool %s", toolID))
+	}
+	return nil
+}
+
+func (r *crdRegistry) Watch(ctx context.Context, filter *apis.ToolFilter) (<-chan RegistryEvent, error) {
+	now := r.opts.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	initial := make([]*apis.ToolSpec, 0, len(r.tools))
+	for _, e := range r.tools {
+		if !r.expired(e, now) {
+			initial = append(initial, &e.spec)
+		}
+	}
+	sort.Slice(initial, func(i, j int) bool { return initial[i].ID < initial[j].ID })
+	return r.hub.subscribe(ctx, filter, initial), nil
+}
+
+func (r *crdRegistry) Resolve(ctx context.Context, ref string) (*apis.ToolSpec, error) {
+	now := r.opts.now()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	candidates := make([]*apis.ToolSpec, 0, len(r.tools))
+	for _, e := range r.tools {
+		if !r.expired(e, now) {
+			candidates = append(candidates, &e.spec)
+		}
+	}
+	return resolveRef(ref, candidates)
+}
+
+// keyFor 优先使用缓存中已知的对象，否则按 ID 推导对象名
+func (r *crdRegistry) keyFor(toolID string) types.NamespacedName {
+	r.mu.RLock()
+	e, ok := r.tools[toolID]
+	r.mu.RUnlock()
+	if ok {
+		return e.key
+	}
+	return types.NamespacedName{Namespace: r.writeNamespace(), Name: ToolObjectName(toolID)}
+}
+
+func (r *crdRegistry) expired(e *crdEntry, now time.Time) bool {
+	return !e.heartbeat.IsZero() && !now.Before(e.heartbeat.Add(r.opts.ttl))
+}
+
+/* -------------- informer 回调 --------------- */
+
+func (r *crdRegistry) onUpsert(obj interface{}) {
+	tool, ok := obj.(*apis.Tool)
+	if !ok || (r.namespace != "" && tool.Namespace != r.namespace) {
+		return
+	}
+	e := &crdEntry{
+		spec: *tool.Spec.DeepCopy(),
+		key:  types.NamespacedName{Namespace: tool.Namespace, Name: tool.Name},
+	}
+	if e.spec.ID == "" {
+		e.spec.ID = tool.Name
+	}
+	// 摘要始终按内容重算，kubectl 手工修改的 spec 不会沿用旧摘要
+	e.spec.Digest = ComputeDigest(&e.spec)
+	if hb, ok := tool.Annotations[HeartbeatAnnotation]; ok {
+		if t, err := time.Parse(time.RFC3339Nano, hb); err == nil {
+			e.heartbeat = t
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var old *apis.ToolSpec
+	if prev, ok := r.tools[e.spec.ID]; ok && prev.visible {
+		old = &prev.spec
+	}
+	e.visible = !r.expired(e, r.opts.now())
+	r.tools[e.spec.ID] = e
+	activeGauge.Set(float64(len(r.tools)))
+	switch {
+	case !e.visible:
+		// 过期对象由 ExpirySweeper 负责删除
+		if old != nil {
+			r.hub.publish(old, nil)
+		}
+	case old != nil && specEqual(old, &e.spec):
+		// 仅心跳变化不产生 Updated 事件
+	default:
+		r.hub.publish(old, &e.spec)
+	}
+}
+
+func (r *crdRegistry) onDelete(obj interface{}) {
+	if d, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	tool, ok := obj.(*apis.Tool)
+	if !ok {
+		return
+	}
+	id := tool.Spec.ID
+	if id == "" {
+		id = tool.Name
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, ok := r.tools[id]
+	if !ok || prev.key.Name != tool.Name || prev.key.Namespace != tool.Namespace {
+		return
+	}
+	delete(r.tools, id)
+	activeGauge.Set(float64(len(r.tools)))
+	if prev.visible {
+		r.hub.publish(&prev.spec, nil)
+	}
+}
+
+// sweepLoop 只在本地把超时工具标记为不可见，订阅者在过期时即收到 Deleted
+func (r *crdRegistry) sweepLoop(ctx context.Context) {
+	t := time.NewTicker(r.opts.sweep)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			r.expire(r.opts.now())
+		}
+	}
+}
+
+// expire 隐藏过期条目并通知订阅者，返回全部过期条目
+func (r *crdRegistry) expire(now time.Time) []*crdEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var stale []*crdEntry
+	for _, e := range r.tools {
+		if !r.expired(e, now) {
+			continue
+		}
+		if e.visible {
+			e.visible = false
+			r.hub.publish(&e.spec, nil)
+		}
+		stale = append(stale, e)
+	}
+	return stale
+}
+
+// sweep 删除心跳超时的 Tool 对象
+func (r *crdRegistry) sweep(ctx context.Context) {
+	for _, e := range r.expire(r.opts.now()) {
+		tool := &apis.Tool{}
+		tool.Name, tool.Namespace = e.key.Name, e.key.Namespace
+		if err := r.client.Delete(ctx, tool); err != nil && !apierrors.IsNotFound(err) {
+			logger.Warn(ctx, "delete expired tool", zap.String("toolID", e.spec.ID), zap.Error(err))
+			continue
+		}
+		expiredTotal.Inc()
+		logger.Info(ctx, "tool expired", zap.String("toolID", e.spec.ID))
+	}
+}
+
+// ExpirySweeper 定时删除心跳超时的 Tool 对象。作为 manager.Runnable 只在选主成功的
+// 控制器中运行，多副本与网关等进程不会重复删除
+type ExpirySweeper struct {
+	r *crdRegistry
+}
+
+var _ manager.LeaderElectionRunnable = (*ExpirySweeper)(nil)
+
+// NewExpirySweeper reg 须由 NewCRDRegistry 创建
+func NewExpirySweeper(reg Registry) (*ExpirySweeper, error) {
+	r, ok := reg.(*crdRegistry)
+	if !ok {
+		return nil, errors.E(errors.KindValidation, fmt.Sprintf("expiry sweeper needs a CRD registry, got %T", reg))
+	}
+	return &ExpirySweeper{r: r}, nil
+}
+
+// Start 按清理周期删除过期对象，直到 ctx 结束
+func (s *ExpirySweeper) Start(ctx context.Context) error {
+	t := time.NewTicker(s.r.opts.sweep)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			s.r.sweep(ctx)
+		}
+	}
+}
+
+func (s *ExpirySweeper) NeedLeaderElection() bool { return true }
+
+/* -------------- helper --------------- */
+
+// ToolObjectName 把任意工具 ID 转成合法的 DNS-1123 对象名；有损转换时追加哈希后缀防冲突
+func ToolObjectName(id string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(id) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '.':
+			b.WriteRune(c)
+			dash = false
+		default:
+			if !dash {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+	name := strings.Trim(b.String(), "-.")
+	if name == id && len(name) <= 63 {
+		return name
+	}
+	if len(name) > maxObjectName {
+		name = strings.Trim(name[:maxObjectName], "-.")
+	}
+	sum := sha256.Sum256([]byte(id))
+	suffix := hex.EncodeToString(sum[:])[:8]
+	if name == "" {
+		return "tool-" + suffix
+	}
+	return name + "-" + suffix
+}
+
+func specEqual(a, b *apis.ToolSpec) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+// apiError 把 API Server 错误映射为内部错误分类
+func apiError(err error, msg string) error {
+	switch {
+	case apierrors.IsNotFound(err):
+		return errors.NotFound(err, msg)
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+		return errors.Conflict(err, msg)
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+		return errors.Permission(err, msg)
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return errors.Validation(err, msg)
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return errors.Timeout(err, msg)
+	}
+	return errors.Unavailable(err, msg)
+}
+
+//Personal.AI order the ending
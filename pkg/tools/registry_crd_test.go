@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+type crdFixture struct {
+	reg      *crdRegistry
+	client   client.Client
+	informer *controllertest.FakeInformer
+	clock    *fakeClock
+}
+
+func newCRDFixture(t *testing.T, ctx context.Context, opts ...RegistryOption) *crdFixture {
+	s := runtime.NewScheme()
+	require.NoError(t, apis.AddToScheme(s))
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+	fi := &informertest.FakeInformers{Scheme: s}
+	clock := newFakeClock()
+
+	opts = append([]RegistryOption{WithTTL(time.Minute), withClock(clock)}, opts...)
+	reg, err := NewCRDRegistry(ctx, c, fi, "agents", opts...)
+	require.NoError(t, err)
+	inf, err := fi.FakeInformerFor(ctx, &apis.Tool{})
+	require.NoError(t, err)
+	return &crdFixture{reg: reg.(*crdRegistry), client: c, informer: inf, clock: clock}
+}
+
+// sync 把 API Server 中的对象推给 informer，模拟 watch 回流
+func (f *crdFixture) sync(t *testing.T, id string) *apis.Tool {
+	t.Helper()
+	var tool apis.Tool
+	require.NoError(t, f.client.Get(context.Background(), f.reg.keyFor(id), &tool))
+	f.informer.Add(&tool)
+	return &tool
+}
+
+func TestCRDRegistry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := newCRDFixture(t, ctx)
+
+	ch, err := f.reg.Watch(ctx, &apis.ToolFilter{Category: "web"})
+	require.NoError(t, err)
+
+	spec := &apis.ToolSpec{ID: "GET /search", Name: "search", Category: "web", Tags: map[string]string{"tier": "free"}}
+	require.NoError(t, f.reg.Register(ctx, spec))
+	tool := f.sync(t, spec.ID)
+	assert.Equal(t, "agents", tool.Namespace)
+	assert.Equal(t, ToolObjectName(spec.ID), tool.Name)
+	assert.NotEmpty(t, tool.Annotations[HeartbeatAnnotation])
+
+	ev := nextEvent(t, ch)
+	assert.Equal(t, RegistryEventAdded, ev.Type)
+	assert.Equal(t, "GET /search", ev.Tool.ID)
+
+	// 用户通过 kubectl 创建、不带心跳注解的 Tool 不会过期
+	manual := &apis.Tool{
+		ObjectMeta: metav1.ObjectMeta{Name: "calc", Namespace: "agents"},
+		Spec:       apis.ToolSpec{Name: "calc", Category: "math"},
+	}
+	require.NoError(t, f.client.Create(ctx, manual))
+	f.sync(t, "calc")
+	// 其它命名空间被忽略
+	f.informer.Add(&apis.Tool{ObjectMeta: metav1.ObjectMeta{Name: "foreign", Namespace: "other"}})
+
+	all, err := f.reg.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /search", "calc"}, ids(all))
+	free, err := f.reg.List(ctx, &apis.ToolFilter{Tags: map[string]string{"tier": "free"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /search"}, ids(free))
+
+	// 心跳只刷新注解，不产生 Updated
+	f.clock.Advance(40 * time.Second)
+	require.NoError(t, f.reg.Heartbeat(ctx, spec.ID))
+	f.sync(t, spec.ID)
+	noEvent(t, ch)
+
+	spec.Version = "2"
+	require.NoError(t, f.reg.Register(ctx, spec))
+	f.sync(t, spec.ID)
+	ev = nextEvent(t, ch)
+	assert.Equal(t, RegistryEventUpdated, ev.Type)
+	assert.Equal(t, "2", ev.Tool.Version)
+
+	// 超过 TTL：立即不可见，本地过期通知订阅者但不删除对象，由 sweep 删除
+	f.clock.Advance(2 * time.Minute)
+	_, err = f.reg.Get(ctx, spec.ID)
+	assert.Error(t, err)
+	f.reg.expire(f.clock.Now())
+	ev = nextEvent(t, ch)
+	assert.Equal(t, RegistryEventDeleted, ev.Type)
+	require.NoError(t, f.client.Get(ctx, types.NamespacedName{Namespace: "agents", Name: tool.Name}, &apis.Tool{}))
+	f.reg.sweep(ctx)
+	noEvent(t, ch)
+	err = f.client.Get(ctx, types.NamespacedName{Namespace: "agents", Name: tool.Name}, &apis.Tool{})
+	assert.True(t, apierrors.IsNotFound(err))
+	f.informer.Delete(tool)
+	noEvent(t, ch)
+
+	_, err = f.reg.Get(ctx, "calc")
+	require.NoError(t, err)
+}
+
+func TestCRDRegistryDeregister(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := newCRDFixture(t, ctx)
+
+	require.NoError(t, f.reg.Register(ctx, &apis.ToolSpec{ID: "echo", Name: "echo"}))
+	tool := f.sync(t, "echo")
+	ch, err := f.reg.Watch(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, RegistryEventAdded, nextEvent(t, ch).Type)
+
+	require.NoError(t, f.reg.Deregister(ctx, "echo"))
+	f.informer.Delete(tool)
+	ev := nextEvent(t, ch)
+	assert.Equal(t, RegistryEventDeleted, ev.Type)
+	assert.Equal(t, "echo", ev.Tool.ID)
+
+	_, err = f.reg.Get(ctx, "echo")
+	assert.Error(t, err)
+	assert.Error(t, f.reg.Deregister(ctx, "echo"))
+}
+
+func TestExpirySweeper(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := newCRDFixture(t, ctx, withSweep(10*time.Millisecond))
+
+	_, err := NewExpirySweeper(NewInMemRegistry())
+	assert.Error(t, err)
+	s, err := NewExpirySweeper(f.reg)
+	require.NoError(t, err)
+	assert.True(t, s.NeedLeaderElection())
+
+	require.NoError(t, f.reg.Register(ctx, &apis.ToolSpec{ID: "echo", Name: "echo"}))
+	tool := f.sync(t, "echo")
+	f.clock.Advance(2 * time.Minute)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Start(ctx) }()
+	assert.Eventually(t, func() bool {
+		err := f.client.Get(ctx, types.NamespacedName{Namespace: "agents", Name: tool.Name}, &apis.Tool{})
+		return apierrors.IsNotFound(err)
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestToolObjectName(t *testing.T) {
+	assert.Equal(t, "echo", ToolObjectName("echo"))
+	n := ToolObjectName("PUT /pets/{id}")
+	assert.Regexp(t, `^put-pets-id-[0-9a-f]{8}$`, n)
+	assert.NotEqual(t, n, ToolObjectName("PUT /pets/{id}/"))
+	assert.LessOrEqual(t, len(ToolObjectName(string(make([]byte, 200)))), 63)
+}
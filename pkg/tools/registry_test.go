@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newFakeClock() *fakeClock { return &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)} }
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+func withClock(c *fakeClock) RegistryOption {
+	return func(o *registryOptions) { o.now = c.Now }
+}
+
+func withSweep(d time.Duration) RegistryOption {
+	return func(o *registryOptions) { o.sweep = d }
+}
+
+func nextEvent(t *testing.T, ch <-chan RegistryEvent) RegistryEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		require.True(t, ok, "watch channel closed")
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for registry event")
+	}
+	return RegistryEvent{}
+}
+
+func noEvent(t *testing.T, ch <-chan RegistryEvent) {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event %s %s", ev.Type, ev.Tool.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func ids(metas []*apis.Metadata) []string {
+	out := make([]string, len(metas))
+	for i, m := range metas {
+		out[i] = m.ID
+	}
+	return out
+}
+
+func TestInMemRegistryFilter(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemRegistry()
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "search", Name: "search", Category: "web", Tags: map[string]string{"tier": "free", "lang": "en"}}))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "fetch", Name: "fetch", Category: "web", Tags: map[string]string{"tier": "paid"}}))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "calc", Name: "calc", Category: "math"}))
+
+	all, err := r.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"calc", "fetch", "search"}, ids(all))
+
+	web, err := r.List(ctx, &apis.ToolFilter{Category: "web"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fetch", "search"}, ids(web))
+
+	free, err := r.List(ctx, &apis.ToolFilter{Tags: map[string]string{"tier": "free"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"search"}, ids(free))
+
+	// 空值只要求键存在
+	tiered, err := r.List(ctx, &apis.ToolFilter{Category: "web", Tags: map[string]string{"tier": ""}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fetch", "search"}, ids(tiered))
+
+	assert.Error(t, r.Register(ctx, &apis.ToolSpec{}))
+}
+
+func TestInMemRegistryExpiry(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	r := NewInMemRegistry(WithTTL(time.Minute), withClock(clock))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "a", Name: "a"}))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "b", Name: "b"}))
+
+	clock.Advance(40 * time.Second)
+	require.NoError(t, r.Heartbeat(ctx, "a"))
+	clock.Advance(40 * time.Second)
+
+	_, err := r.Get(ctx, "a")
+	require.NoError(t, err)
+	_, err = r.Get(ctx, "b")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(r.Heartbeat(ctx, "b")))
+
+	clock.Advance(time.Minute)
+	all, err := r.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func TestInMemRegistryWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := newFakeClock()
+	r := NewInMemRegistry(WithTTL(time.Minute), withClock(clock))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "existing", Name: "existing", Category: "web"}))
+
+	ch, err := r.Watch(ctx, &apis.ToolFilter{Category: "web"})
+	require.NoError(t, err)
+
+	// 先回放已有工具
+	ev := nextEvent(t, ch)
+	assert.Equal(t, RegistryEventAdded, ev.Type)
+	assert.Equal(t, "existing", ev.Tool.ID)
+
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "other", Name: "other", Category: "math"}))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "new", Name: "new", Category: "web"}))
+	ev = nextEvent(t, ch)
+	assert.Equal(t, RegistryEventAdded, ev.Type)
+	assert.Equal(t, "new", ev.Tool.ID)
+
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "new", Name: "new", Category: "web", Version: "2"}))
+	ev = nextEvent(t, ch)
+	assert.Equal(t, RegistryEventUpdated, ev.Type)
+	assert.Equal(t, "2", ev.Tool.Version)
+
+	// 移出过滤范围等同删除
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "new", Name: "new", Category: "math"}))
+	ev = nextEvent(t, ch)
+	assert.Equal(t, RegistryEventDeleted, ev.Type)
+	assert.Equal(t, "web", ev.Tool.Category)
+
+	require.NoError(t, r.Deregister(ctx, "other"))
+	noEvent(t, ch)
+
+	clock.Advance(2 * time.Minute)
+	_, err = r.List(ctx, nil)
+	require.NoError(t, err)
+	ev = nextEvent(t, ch)
+	assert.Equal(t, RegistryEventDeleted, ev.Type)
+	assert.Equal(t, "existing", ev.Tool.ID)
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch channel not closed after cancel")
+	}
+}
+
+// 没有其它调用时，订阅期间的后台清理也会发出过期的 Deleted
+func TestInMemRegistryWatchExpiry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := newFakeClock()
+	r := NewInMemRegistry(WithTTL(time.Minute), withClock(clock), withSweep(10*time.Millisecond))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "search", Name: "search"}))
+	ch, err := r.Watch(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, RegistryEventAdded, nextEvent(t, ch).Type)
+
+	noEvent(t, ch)
+	clock.Advance(2 * time.Minute)
+	ev := nextEvent(t, ch)
+	assert.Equal(t, RegistryEventDeleted, ev.Type)
+	assert.Equal(t, "search", ev.Tool.ID)
+}
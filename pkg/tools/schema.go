@@ -0,0 +1,594 @@
+// pkg/tools/schema.go
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// ArgsViolation 单条参数校验失败，Pointer 为 RFC 6901 JSON Pointer
+type ArgsViolation struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// ArgsError 汇总全部校验失败，作为 KindValidation 错误的底层错误返回
+type ArgsError struct {
+	Violations []ArgsViolation `json:"violations"`
+}
+
+func (e *ArgsError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		ptr := v.Pointer
+		if ptr == "" {
+			ptr = "(root)"
+		}
+		parts[i] = ptr + ": " + v.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ValidateArgs 按 JSON Schema（draft 2020-12 子集）校验工具入参。
+// 返回补齐 default 并完成标量类型转换（如 "42"→42）后的副本，原 args 不被修改。
+// 支持 type/enum/const、object/array/string/number 约束、allOf/anyOf/oneOf/not、
+// 本地 $ref（#/$defs、#/definitions）以及 OpenAPI 3.0 的 nullable。
+func ValidateArgs(schema apis.AnyMap, args map[string]interface{}) (map[string]interface{}, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	if len(schema) == 0 {
+		return args, nil
+	}
+	root := map[string]interface{}(schema)
+	v := &schemaValidator{root: root}
+	out := v.validate(root, deepCopyJSON(args), "")
+	if len(v.violations) > 0 {
+		sort.SliceStable(v.violations, func(i, j int) bool { return v.violations[i].Pointer < v.violations[j].Pointer })
+		return nil, errors.E(errors.KindValidation, "invalid tool arguments", &ArgsError{Violations: v.violations})
+	}
+	m, ok := out.(map[string]interface{})
+	if !ok {
+		return args, nil
+	}
+	return m, nil
+}
+
+// maxRefDepth 防止循环 $ref
+const maxRefDepth = 32
+
+type schemaValidator struct {
+	root       map[string]interface{}
+	violations []ArgsViolation
+	depth      int
+	strict     bool // 组合关键字分支内不做类型转换，避免多分支同时命中
+}
+
+func (v *schemaValidator) fail(ptr, format string, a ...interface{}) {
+	v.violations = append(v.violations, ArgsViolation{Pointer: ptr, Message: fmt.Sprintf(format, a...)})
+}
+
+// sub 用于组合关键字的试探校验，失败不污染外层结果
+func (v *schemaValidator) sub() *schemaValidator {
+	return &schemaValidator{root: v.root, depth: v.depth, strict: true}
+}
+
+// validate 返回（可能被补默认值/转换后的）value
+func (v *schemaValidator) validate(schema interface{}, value interface{}, ptr string) interface{} {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(ptr, "not allowed")
+		}
+		return value
+	case map[string]interface{}:
+		return v.validateObjectSchema(s, value, ptr)
+	case apis.AnyMap:
+		return v.validateObjectSchema(map[string]interface{}(s), value, ptr)
+	}
+	return value
+}
+
+func (v *schemaValidator) validateObjectSchema(s map[string]interface{}, value interface{}, ptr string) interface{} {
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := v.resolveRef(ref)
+		if err != nil {
+			v.fail(ptr, "%v", err)
+			return value
+		}
+		v.depth++
+		value = v.validate(target, value, ptr)
+		v.depth--
+	}
+
+	if value == nil && s["nullable"] == true {
+		return value
+	}
+
+	// 类型：不匹配时先尝试转换
+	if types := schemaTypes(s["type"]); len(types) > 0 {
+		if !matchesAnyType(value, types) {
+			if c, ok := coerce(value, types); ok && !v.strict {
+				value = c
+			} else {
+				v.fail(ptr, "expected %s, got %s", strings.Join(types, " or "), jsonType(value))
+				return value
+			}
+		}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(ptr, "must be one of %s", compactJSON(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !jsonEqual(c, value) {
+		v.fail(ptr, "must equal %s", compactJSON(c))
+	}
+
+	switch t := value.(type) {
+	case map[string]interface{}:
+		value = v.validateObject(s, t, ptr)
+	case []interface{}:
+		value = v.validateArray(s, t, ptr)
+	case string:
+		v.validateString(s, t, ptr)
+	default:
+		if n, ok := toNumber(value); ok {
+			v.validateNumber(s, n, ptr)
+		}
+	}
+
+	// 组合关键字
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sch := range all {
+			value = v.validate(sch, value, ptr)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sch := range anyOf {
+			sv := v.sub()
+			out := sv.validate(sch, deepCopyJSON(value), ptr)
+			if len(sv.violations) == 0 {
+				value, matched = out, true
+				break
+			}
+		}
+		if !matched {
+			v.fail(ptr, "must match at least one schema in anyOf")
+		}
+	}
+	if one, ok := s["oneOf"].([]interface{}); ok {
+		var (
+			hits int
+			res  interface{}
+		)
+		for _, sch := range one {
+			sv := v.sub()
+			out := sv.validate(sch, deepCopyJSON(value), ptr)
+			if len(sv.violations) == 0 {
+				hits++
+				res = out
+			}
+		}
+		if hits == 1 {
+			value = res
+		} else {
+			v.fail(ptr, "must match exactly one schema in oneOf, matched %d", hits)
+		}
+	}
+	if not, ok := s["not"]; ok {
+		sv := v.sub()
+		sv.validate(not, deepCopyJSON(value), ptr)
+		if len(sv.violations) == 0 {
+			v.fail(ptr, "must not match schema in not")
+		}
+	}
+	return value
+}
+
+func (v *schemaValidator) validateObject(s map[string]interface{}, obj map[string]interface{}, ptr string) interface{} {
+	props, _ := s["properties"].(map[string]interface{})
+
+	// default 仅作用于缺失的属性
+	for name, ps := range props {
+		if _, ok := obj[name]; ok {
+			continue
+		}
+		if psm, ok := ps.(map[string]interface{}); ok {
+			if d, ok := psm["default"]; ok {
+				obj[name] = deepCopyJSON(d)
+			}
+		}
+	}
+
+	for _, r := range toStrings(s["required"]) {
+		if _, ok := obj[r]; !ok {
+			v.fail(ptr+"/"+escapePointer(r), "is required")
+		}
+	}
+
+	var patterns []*regexp.Regexp
+	var patternSchemas []interface{}
+	if pp, ok := s["patternProperties"].(map[string]interface{}); ok {
+		for p, ps := range pp {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				v.fail(ptr, "invalid patternProperties regexp %q", p)
+				continue
+			}
+			patterns = append(patterns, re)
+			patternSchemas = append(patternSchemas, ps)
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		child := ptr + "/" + escapePointer(k)
+		known := false
+		if ps, ok := props[k]; ok {
+			known = true
+			obj[k] = v.validate(ps, obj[k], child)
+		}
+		for i, re := range patterns {
+			if re.MatchString(k) {
+				known = true
+				obj[k] = v.validate(patternSchemas[i], obj[k], child)
+			}
+		}
+		if known {
+			continue
+		}
+		if ap, ok := s["additionalProperties"]; ok {
+			if b, isBool := ap.(bool); isBool && !b {
+				v.fail(child, "additional property not allowed")
+				continue
+			}
+			obj[k] = v.validate(ap, obj[k], child)
+		}
+	}
+
+	if n, ok := toInt(s["minProperties"]); ok && len(obj) < n {
+		v.fail(ptr, "must have at least %d properties", n)
+	}
+	if n, ok := toInt(s["maxProperties"]); ok && len(obj) > n {
+		v.fail(ptr, "must have at most %d properties", n)
+	}
+	return obj
+}
+
+func (v *schemaValidator) validateArray(s map[string]interface{}, arr []interface{}, ptr string) interface{} {
+	prefix, _ := s["prefixItems"].([]interface{})
+	for i := range arr {
+		child := ptr + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(prefix):
+			arr[i] = v.validate(prefix[i], arr[i], child)
+		case s["items"] != nil:
+			arr[i] = v.validate(s["items"], arr[i], child)
+		}
+	}
+	if n, ok := toInt(s["minItems"]); ok && len(arr) < n {
+		v.fail(ptr, "must have at least %d items", n)
+	}
+	if n, ok := toInt(s["maxItems"]); ok && len(arr) > n {
+		v.fail(ptr, "must have at most %d items", n)
+	}
+	if s["uniqueItems"] == true {
+		seen := make(map[string]int, len(arr))
+		for i, e := range arr {
+			key := compactJSON(e)
+			if j, dup := seen[key]; dup {
+				v.fail(ptr+"/"+strconv.Itoa(i), "duplicates item %d", j)
+				continue
+			}
+			seen[key] = i
+		}
+	}
+	return arr
+}
+
+func (v *schemaValidator) validateString(s map[string]interface{}, str, ptr string) {
+	n := utf8.RuneCountInString(str)
+	if min, ok := toInt(s["minLength"]); ok && n < min {
+		v.fail(ptr, "must be at least %d characters", min)
+	}
+	if max, ok := toInt(s["maxLength"]); ok && n > max {
+		v.fail(ptr, "must be at most %d characters", max)
+	}
+	if p, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			v.fail(ptr, "invalid pattern %q", p)
+		} else if !re.MatchString(str) {
+			v.fail(ptr, "must match pattern %q", p)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(s map[string]interface{}, n float64, ptr string) {
+	// OpenAPI 3.0 中 exclusiveMinimum/Maximum 为 bool，修饰 minimum/maximum
+	exMin, _ := s["exclusiveMinimum"].(bool)
+	exMax, _ := s["exclusiveMaximum"].(bool)
+	if min, ok := toNumber(s["minimum"]); ok {
+		if exMin && n <= min {
+			v.fail(ptr, "must be > %v", min)
+		} else if n < min {
+			v.fail(ptr, "must be >= %v", min)
+		}
+	}
+	if max, ok := toNumber(s["maximum"]); ok {
+		if exMax && n >= max {
+			v.fail(ptr, "must be < %v", max)
+		} else if n > max {
+			v.fail(ptr, "must be <= %v", max)
+		}
+	}
+	if min, ok := toNumber(s["exclusiveMinimum"]); ok && n <= min {
+		v.fail(ptr, "must be > %v", min)
+	}
+	if max, ok := toNumber(s["exclusiveMaximum"]); ok && n >= max {
+		v.fail(ptr, "must be < %v", max)
+	}
+	if m, ok := toNumber(s["multipleOf"]); ok && m > 0 {
+		q := n / m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(ptr, "must be a multiple of %v", m)
+		}
+	}
+}
+
+// resolveRef 仅支持文档内 JSON Pointer 引用
+func (v *schemaValidator) resolveRef(ref string) (interface{}, error) {
+	if v.depth >= maxRefDepth {
+		return nil, fmt.Errorf("$ref %q nested too deeply", ref)
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported remote $ref %q", ref)
+	}
+	var cur interface{} = v.root
+	for _, tok := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if tok == "" {
+			continue
+		}
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if cur, ok = m[tok]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+	return cur, nil
+}
+
+/* -------------- helper --------------- */
+
+func schemaTypes(t interface{}) []string {
+	switch x := t.(type) {
+	case string:
+		return []string{x}
+	case []interface{}:
+		return toStrings(x)
+	case []string:
+		return x
+	}
+	return nil
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(value interface{}, t string) bool {
+	switch t {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "number":
+		_, ok := toNumber(value)
+		return ok
+	case "integer":
+		n, ok := toNumber(value)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	}
+	return false
+}
+
+// coerce 标量之间的宽松转换，按声明顺序取第一个成功的目标类型
+func coerce(value interface{}, types []string) (interface{}, bool) {
+	for _, t := range types {
+		switch t {
+		case "integer", "number":
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+				continue
+			}
+			if t == "integer" && n != math.Trunc(n) {
+				continue
+			}
+			return n, true
+		case "boolean":
+			if s, ok := value.(string); ok {
+				if b, err := strconv.ParseBool(s); err == nil {
+					return b, true
+				}
+			}
+		case "string":
+			switch x := value.(type) {
+			case bool:
+				return strconv.FormatBool(x), true
+			default:
+				if n, ok := toNumber(value); ok {
+					return strconv.FormatFloat(n, 'f', -1, 64), true
+				}
+			}
+		case "array":
+			// 单个标量包装为一元数组
+			if value != nil {
+				if _, isMap := value.(map[string]interface{}); !isMap {
+					return []interface{}{value}, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if n, ok := toNumber(value); ok {
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toInt(v interface{}) (int, bool) {
+	n, ok := toNumber(v)
+	return int(n), ok
+}
+
+func toStrings(v interface{}) []string {
+	switch x := v.(type) {
+	case []string:
+		return x
+	case []interface{}:
+		out := make([]string, 0, len(x))
+		for _, e := range x {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// jsonEqual 以 JSON 序列化结果比较，消除 int/float64 差异
+func jsonEqual(a, b interface{}) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+func compactJSON(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(raw)
+}
+
+// deepCopyJSON 复制 map/slice 结构，避免补默认值时修改调用方或 schema 中的数据
+func deepCopyJSON(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			out[k] = deepCopyJSON(e)
+		}
+		return out
+	case apis.AnyMap:
+		return deepCopyJSON(map[string]interface{}(x))
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, e := range x {
+			out[i] = deepCopyJSON(e)
+		}
+		return out
+	}
+	return v
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+var searchSchema = apis.AnyMap{
+	"type":     "object",
+	"required": []interface{}{"query"},
+	"properties": map[string]interface{}{
+		"query": map[string]interface{}{"type": "string", "minLength": float64(1)},
+		"limit": map[string]interface{}{"type": "integer", "minimum": float64(1), "maximum": float64(100), "default": float64(10)},
+		"exact": map[string]interface{}{"type": "boolean"},
+		"sort":  map[string]interface{}{"enum": []interface{}{"asc", "desc"}, "default": "asc"},
+		"tags": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"uniqueItems": true,
+		},
+		"filter": map[string]interface{}{"$ref": "#/$defs/filter"},
+	},
+	"additionalProperties": false,
+	"$defs": map[string]interface{}{
+		"filter": map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"field"},
+			"properties": map[string]interface{}{
+				"field": map[string]interface{}{"type": "string", "pattern": "^[a-z]+$"},
+				"value": map[string]interface{}{"anyOf": []interface{}{
+					map[string]interface{}{"type": "string"},
+					map[string]interface{}{"type": "number"},
+				}},
+			},
+		},
+	},
+}
+
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	require.Error(t, err)
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	var ae *ArgsError
+	require.True(t, stderrors.As(err, &ae))
+	out := make([]string, len(ae.Violations))
+	for i, v := range ae.Violations {
+		out[i] = v.Pointer
+	}
+	return out
+}
+
+func TestValidateArgsDefaultsAndCoercion(t *testing.T) {
+	in := map[string]interface{}{
+		"query": "pets",
+		"exact": "true",
+		"tags":  "dog",
+		"filter": map[string]interface{}{
+			"field": "age",
+			"value": float64(3),
+		},
+	}
+	out, err := ValidateArgs(searchSchema, in)
+	require.NoError(t, err)
+	assert.Equal(t, float64(10), out["limit"])
+	assert.Equal(t, "asc", out["sort"])
+	assert.Equal(t, true, out["exact"])
+	assert.Equal(t, []interface{}{"dog"}, out["tags"])
+
+	// 原始入参不被修改
+	assert.NotContains(t, in, "limit")
+	assert.Equal(t, "true", in["exact"])
+
+	out, err = ValidateArgs(searchSchema, map[string]interface{}{"query": "x", "limit": "25"})
+	require.NoError(t, err)
+	assert.Equal(t, float64(25), out["limit"])
+}
+
+func TestValidateArgsViolations(t *testing.T) {
+	_, err := ValidateArgs(searchSchema, map[string]interface{}{
+		"limit":   float64(1000),
+		"sort":    "random",
+		"tags":    []interface{}{"a", "a"},
+		"unknown": 1,
+		"filter": map[string]interface{}{
+			"field": "Bad Field",
+			"value": true,
+		},
+	})
+	assert.Equal(t, []string{
+		"/filter/field",
+		"/filter/value",
+		"/limit",
+		"/query",
+		"/sort",
+		"/tags/1",
+		"/unknown",
+	}, violations(t, err))
+
+	_, err = ValidateArgs(searchSchema, map[string]interface{}{"query": "x", "limit": "2.5"})
+	assert.Equal(t, []string{"/limit"}, violations(t, err))
+}
+
+func TestValidateArgsCombinators(t *testing.T) {
+	schema := apis.AnyMap{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"oneOf": []interface{}{
+				map[string]interface{}{"type": "integer"},
+				map[string]interface{}{"type": "string", "pattern": "^[a-z]+$"},
+			}},
+			"name": map[string]interface{}{"type": "string", "nullable": true},
+			"mode": map[string]interface{}{"not": map[string]interface{}{"const": "unsafe"}},
+			"a/b":  map[string]interface{}{"type": "integer", "exclusiveMinimum": float64(0)},
+		},
+	}
+	_, err := ValidateArgs(schema, map[string]interface{}{"id": 7, "name": nil, "mode": "safe", "a/b": 1})
+	require.NoError(t, err)
+
+	_, err = ValidateArgs(schema, map[string]interface{}{"id": 1.5, "mode": "unsafe", "a/b": 0})
+	assert.Equal(t, []string{"/a~1b", "/id", "/mode"}, violations(t, err))
+
+	// 空 schema 不做任何约束
+	out, err := ValidateArgs(nil, map[string]interface{}{"x": 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, out["x"])
+}
@@ -0,0 +1,56 @@
+// pkg/tools/stream.go
+package tools
+
+import (
+	"sync"
+
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// ToolEventHandler 接收流式调用的中间事件；同一次调用内串行回调，调用返回后不再回调。
+// 回调运行在传输层的读协程上，应尽快返回
+type ToolEventHandler func(ev apis.ToolEvent)
+
+// guardHandler 包装 h，stop 之后的事件被丢弃，防止后端在调用返回后仍然推送
+func guardHandler(h ToolEventHandler) (ToolEventHandler, func()) {
+	if h == nil {
+		return nil, func() {}
+	}
+	var mu sync.Mutex
+	stopped := false
+	guarded := func(ev apis.ToolEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped {
+			h(ev)
+		}
+	}
+	stop := func() {
+		mu.Lock()
+		stopped = true
+		mu.Unlock()
+	}
+	return guarded, stop
+}
+
+// emit 允许 h 为 nil
+func (h ToolEventHandler) emit(ev apis.ToolEvent) {
+	if h != nil {
+		h(ev)
+	}
+}
+
+// outputWriter 把写入的字节同时存入 buf 并作为 output 事件推送
+type outputWriter struct {
+	buf  *limitedBuffer
+	emit ToolEventHandler
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.emit.emit(apis.ToolEvent{Type: apis.ToolEventOutput, Chunk: string(p)})
+	}
+	return w.buf.Write(p)
+}
+
+//Personal.AI order the ending
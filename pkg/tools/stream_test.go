@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/sandbox"
+)
+
+// eventLog 并发安全地收集事件
+type eventLog struct {
+	mu     sync.Mutex
+	events []apis.ToolEvent
+}
+
+func (l *eventLog) handle(ev apis.ToolEvent) {
+	l.mu.Lock()
+	l.events = append(l.events, ev)
+	l.mu.Unlock()
+}
+
+func (l *eventLog) of(typ apis.ToolEventType) []apis.ToolEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []apis.ToolEvent
+	for _, ev := range l.events {
+		if ev.Type == typ {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func (l *eventLog) output() string {
+	var sb strings.Builder
+	for _, ev := range l.of(apis.ToolEventOutput) {
+		sb.WriteString(ev.Chunk)
+	}
+	return sb.String()
+}
+
+func TestMCPClientProgress(t *testing.T) {
+	ctx := context.Background()
+	srv := newFakeMCPServer()
+
+	check := func(t *testing.T, c MCPClient) {
+		var log eventLog
+		res, err := c.CallToolStream(ctx, "echo", map[string]interface{}{"msg": "hi"}, log.handle)
+		require.NoError(t, err)
+		assert.Equal(t, "hi", res.Output)
+		progress := log.of(apis.ToolEventProgress)
+		require.Len(t, progress, 2)
+		assert.Equal(t, apis.ToolEvent{Type: apis.ToolEventProgress, Progress: 2, Total: 2, Message: "step 2"}, progress[1])
+
+		// 不需要进度时不带 progressToken
+		_, err = c.CallTool(ctx, "echo", map[string]interface{}{"msg": "quiet"})
+		require.NoError(t, err)
+		assert.Nil(t, srv.calls[len(srv.calls)-1].Meta)
+	}
+	t.Run("stdio", func(t *testing.T) { check(t, pipeClient(t, srv)) })
+	t.Run("http", func(t *testing.T) {
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewMCPClient()
+		require.NoError(t, c.Connect(ctx, ts.URL))
+		defer c.Close()
+		check(t, c)
+	})
+}
+
+func TestMCPClientCancel(t *testing.T) {
+	srv := newFakeMCPServer()
+	c := pipeClient(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := c.CallTool(ctx, "hang", nil)
+	assert.Equal(t, errors.KindTimeout, errors.KindOf(err))
+
+	select {
+	case p := <-srv.cancelled:
+		assert.Equal(t, c.nextID.Load(), p.RequestID)
+		assert.NotEmpty(t, p.Reason)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not receive notifications/cancelled")
+	}
+}
+
+func TestOpenAPIAdapterStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		if r.URL.Path == "/v1/pets" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: progress\ndata: {\"progress\":1,\"total\":3,\"message\":\"crawling\"}\n\n")
+			flusher.Flush()
+			fmt.Fprint(w, "data: first\n\n")
+			flusher.Flush()
+			fmt.Fprint(w, "event: output\ndata: second\n\n")
+			return
+		}
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "part%d;", i)
+			flusher.Flush()
+		}
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+	a := NewOpenAPIAdapter(WithOpenAPIBinding(&apis.OpenAPIBinding{BaseURL: ts.URL + "/v1"}))
+	require.NoError(t, a.LoadSpec(ctx, []byte(petstoreSpec)))
+
+	var log eventLog
+	res, err := a.InvokeStream(ctx, "GET /pets", nil, log.handle)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond", res.Output)
+	assert.Equal(t, []apis.ToolEvent{{Type: apis.ToolEventProgress, Progress: 1, Total: 3, Message: "crawling"}}, log.of(apis.ToolEventProgress))
+	assert.Len(t, log.of(apis.ToolEventOutput), 2)
+
+	// 非 SSE 按数据块推送
+	log = eventLog{}
+	res, err = a.InvokeStream(ctx, "updatePet", map[string]interface{}{"id": 1, "body": map[string]interface{}{"name": "rex"}}, log.handle)
+	require.NoError(t, err)
+	assert.Equal(t, "part0;part1;part2;", res.Output)
+	assert.Equal(t, res.Output, log.output())
+	assert.NotEmpty(t, log.of(apis.ToolEventOutput))
+}
+
+func TestInvokerStream(t *testing.T) {
+	ctx := context.Background()
+	sb := &fakeSandboxes{run: func(spec *sandbox.SandboxSpec) error {
+		fmt.Fprint(spec.Stdout, "line 1\n")
+		fmt.Fprint(spec.Stdout, "line 2\n")
+		return nil
+	}}
+	reg := NewInMemRegistry()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{
+		Name: "crawl", CustomExec: &apis.CustomBinding{Image: "crawl"}, Cache: &apis.CachePolicy{Cacheable: true},
+	}))
+	inv := NewInvoker(reg, sb)
+
+	var log eventLog
+	res, err := inv.InvokeStream(ctx, "crawl", nil, log.handle)
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\n", res.Output)
+	assert.Len(t, log.of(apis.ToolEventOutput), 2)
+	assert.Equal(t, res.Output, log.output())
+
+	// 缓存命中没有中间事件
+	log = eventLog{}
+	res, err = inv.InvokeStream(ctx, "crawl", nil, log.handle)
+	require.NoError(t, err)
+	assert.True(t, res.Cached)
+	assert.Empty(t, log.events)
+}
+
+func TestGuardHandler(t *testing.T) {
+	var log eventLog
+	h, stop := guardHandler(log.handle)
+	h(apis.ToolEvent{Type: apis.ToolEventOutput, Chunk: "a"})
+	stop()
+	h(apis.ToolEvent{Type: apis.ToolEventOutput, Chunk: "b"})
+	assert.Equal(t, "a", log.output())
+
+	h, stop = guardHandler(nil)
+	assert.Nil(t, h)
+	stop()
+}
@@ -0,0 +1,201 @@
+// pkg/tools/version.go
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// digestPrefix 目前只支持 sha256
+const digestPrefix = "sha256:"
+
+// ToolRef 解析后的工具引用：
+//
+//	search              精确 ID，否则同名最高版本（都不是 semver 时见 exactName）
+//	search@1.2.3        精确版本
+//	search@^1.2         semver 约束
+//	search@sha256:<hex> 按摘要钉死
+type ToolRef struct {
+	Name       string
+	Constraint string
+	Digest     string
+}
+
+func (r ToolRef) String() string {
+	switch {
+	case r.Digest != "":
+		return r.Name + "@" + r.Digest
+	case r.Constraint != "":
+		return r.Name + "@" + r.Constraint
+	}
+	return r.Name
+}
+
+// PinRef 按摘要钉死的引用；没有 Name 的工具按 ID 解析，见 resolveRef
+func PinRef(id, name, digest string) string {
+	if name == "" {
+		name = id
+	}
+	return ToolRef{Name: name, Digest: digest}.String()
+}
+
+// ParseToolRef 拆分 name@selector
+func ParseToolRef(ref string) (ToolRef, error) {
+	name, sel, hasSel := strings.Cut(strings.TrimSpace(ref), "@")
+	if name == "" {
+		return ToolRef{}, errors.E(errors.KindValidation, fmt.Sprintf("invalid tool reference %q", ref))
+	}
+	out := ToolRef{Name: name}
+	if !hasSel {
+		return out, nil
+	}
+	switch {
+	case sel == "":
+		return ToolRef{}, errors.E(errors.KindValidation, fmt.Sprintf("invalid tool reference %q: empty selector", ref))
+	case strings.HasPrefix(sel, digestPrefix):
+		if _, err := hex.DecodeString(strings.TrimPrefix(sel, digestPrefix)); err != nil || len(sel) != len(digestPrefix)+64 {
+			return ToolRef{}, errors.E(errors.KindValidation, fmt.Sprintf("invalid tool reference %q: malformed digest", ref))
+		}
+		out.Digest = sel
+	default:
+		out.Constraint = sel
+	}
+	return out, nil
+}
+
+// ComputeDigest 对去掉 Digest 字段后的规范 JSON 求 sha256；map 键由 encoding/json 排序保证稳定
+func ComputeDigest(spec *apis.ToolSpec) string {
+	c := spec.DeepCopy()
+	c.Digest = ""
+	raw, _ := json.Marshal(c)
+	sum := sha256.Sum256(raw)
+	return digestPrefix + hex.EncodeToString(sum[:])
+}
+
+// sealSpec 填充派生字段并校验发布方给出的摘要
+func sealSpec(spec *apis.ToolSpec) (*apis.ToolSpec, error) {
+	out := spec.DeepCopy()
+	if out.ID == "" && out.Name != "" {
+		out.ID = out.Name
+		if out.Version != "" {
+			out.ID += "@" + out.Version
+		}
+	}
+	if out.ID == "" {
+		return nil, errors.E(errors.KindValidation, "tool id or name is required")
+	}
+	// 没有 Name 时引用按 ID 拼写，含 @ 就解析不回来
+	if out.Name == "" && strings.Contains(out.ID, "@") {
+		return nil, errors.E(errors.KindValidation, fmt.Sprintf("tool %s: an id containing '@' requires a name", out.ID))
+	}
+	digest := ComputeDigest(out)
+	if out.Digest != "" && out.Digest != digest {
+		return nil, errors.E(errors.KindValidation,
+			fmt.Sprintf("tool %s: digest %s does not match content %s", out.ID, out.Digest, digest))
+	}
+	out.Digest = digest
+	return out, nil
+}
+
+// resolveRef 在候选集合中挑选 ref 命中的工具；约束匹配时取最高版本
+func resolveRef(ref string, candidates []*apis.ToolSpec) (*apis.ToolSpec, error) {
+	r, err := ParseToolRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if r.Constraint == "" && r.Digest == "" {
+		for _, c := range candidates {
+			if c.ID == r.Name {
+				return c.DeepCopy(), nil
+			}
+		}
+	}
+
+	var named []*apis.ToolSpec
+	for _, c := range candidates {
+		if c.Name == r.Name {
+			named = append(named, c)
+		}
+	}
+	if r.Digest != "" {
+		for _, c := range candidates {
+			if (c.Name == r.Name || c.Name == "" && c.ID == r.Name) && c.Digest == r.Digest {
+				return c.DeepCopy(), nil
+			}
+		}
+		return nil, errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", ref))
+	}
+
+	var constraint *semver.Constraints
+	if r.Constraint != "" {
+		if constraint, err = semver.NewConstraint(r.Constraint); err != nil {
+			// 非 semver 的版本号只做字面匹配
+			for _, c := range named {
+				if c.Version == r.Constraint {
+					return c.DeepCopy(), nil
+				}
+			}
+			return nil, errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", ref))
+		}
+	}
+
+	type versioned struct {
+		v    *semver.Version
+		spec *apis.ToolSpec
+	}
+	var matches []versioned
+	anySemver := false
+	for _, c := range named {
+		v, err := semver.NewVersion(c.Version)
+		if err != nil {
+			continue
+		}
+		anySemver = true
+		if constraint == nil {
+			// 裸名字默认不选预发布版本
+			if v.Prerelease() != "" {
+				continue
+			}
+		} else if !constraint.Check(v) {
+			continue
+		}
+		matches = append(matches, versioned{v: v, spec: c})
+	}
+	if len(matches) == 0 && constraint == nil && !anySemver {
+		return exactName(ref, named)
+	}
+	if len(matches) == 0 {
+		return nil, errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", ref))
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].v.GreaterThan(matches[j].v) })
+	return matches[0].spec.DeepCopy(), nil
+}
+
+// exactName 同名工具都没有 semver 版本号时无从比较高低：没有版本号的优先，
+// 否则只有唯一一个时取它，多个时要求引用写明版本
+func exactName(ref string, named []*apis.ToolSpec) (*apis.ToolSpec, error) {
+	for _, c := range named {
+		if c.Version == "" {
+			return c.DeepCopy(), nil
+		}
+	}
+	switch len(named) {
+	case 0:
+		return nil, errors.E(errors.KindNotFound, fmt.Sprintf("tool %s not found", ref))
+	case 1:
+		return named[0].DeepCopy(), nil
+	}
+	return nil, errors.E(errors.KindValidation,
+		fmt.Sprintf("tool %s has %d non-semver versions, reference one as %s@<version>", ref, len(named), ref))
+}
+
+//Personal.AI order the ending
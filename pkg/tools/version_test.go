@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/turtacn/agenticai/internal/errors"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+func TestParseToolRef(t *testing.T) {
+	r, err := ParseToolRef("search@^1.2")
+	require.NoError(t, err)
+	assert.Equal(t, ToolRef{Name: "search", Constraint: "^1.2"}, r)
+
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	r, err = ParseToolRef("search@" + digest)
+	require.NoError(t, err)
+	assert.Equal(t, digest, r.Digest)
+	assert.Equal(t, "search@"+digest, r.String())
+
+	for _, bad := range []string{"", "@1.0", "search@", "search@sha256:zz"} {
+		_, err := ParseToolRef(bad)
+		assert.Equal(t, errors.KindValidation, errors.KindOf(err), bad)
+	}
+}
+
+func TestComputeDigest(t *testing.T) {
+	a := &apis.ToolSpec{Name: "search", Version: "1.0.0", Tags: map[string]string{"a": "1", "b": "2"}}
+	b := &apis.ToolSpec{Name: "search", Version: "1.0.0", Tags: map[string]string{"b": "2", "a": "1"}}
+	assert.Equal(t, ComputeDigest(a), ComputeDigest(b))
+	assert.True(t, strings.HasPrefix(ComputeDigest(a), "sha256:"))
+
+	// Digest 字段本身不参与计算
+	b.Digest = ComputeDigest(b)
+	assert.Equal(t, ComputeDigest(a), ComputeDigest(b))
+
+	b.Version = "1.0.1"
+	assert.NotEqual(t, ComputeDigest(a), ComputeDigest(b))
+}
+
+func TestRegistryResolve(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemRegistry()
+	for _, v := range []string{"1.1.0", "1.2.5", "1.4.0", "2.0.0", "2.1.0-beta.1"} {
+		require.NoError(t, r.Register(ctx, &apis.ToolSpec{Name: "search", Version: v}))
+	}
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "calc", Name: "calc", Version: "nightly"}))
+
+	// 多版本共存，ID 默认为 name@version
+	all, err := r.List(ctx, &apis.ToolFilter{Name: "search"})
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+	for _, m := range all {
+		assert.Equal(t, ComputeDigest(mustResolve(t, r, m.ID)), m.Digest)
+	}
+
+	cases := map[string]string{
+		"search":           "2.0.0",
+		"search@^1.2":      "1.4.0",
+		"search@~1.2":      "1.2.5",
+		"search@1.1.0":     "1.1.0",
+		"search@>=2.1.0-0": "2.1.0-beta.1",
+		"search@1.2.5":     "1.2.5",
+		"calc":             "nightly",
+		"calc@nightly":     "nightly",
+	}
+	for ref, want := range cases {
+		assert.Equal(t, want, mustResolve(t, r, ref).Version, ref)
+	}
+
+	pinned := mustResolve(t, r, "search@1.2.5")
+	got := mustResolve(t, r, "search@"+pinned.Digest)
+	assert.Equal(t, "search@1.2.5", got.ID)
+
+	for _, ref := range []string{"search@^3", "missing", "search@sha256:" + strings.Repeat("0", 64)} {
+		_, err := r.Resolve(ctx, ref)
+		assert.Equal(t, errors.KindNotFound, errors.KindOf(err), ref)
+	}
+
+	// 发布方声明的摘要必须与内容一致
+	err = r.Register(ctx, &apis.ToolSpec{Name: "search", Version: "3.0.0", Digest: pinned.Digest})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+}
+
+func TestResolveNonSemver(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemRegistry()
+	// ID 默认为 name@version，裸名字只能按 Name 找到
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{Name: "calc", Version: "nightly"}))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "fetch-v1", Name: "fetch"}))
+	assert.Equal(t, "calc@nightly", mustResolve(t, r, "calc").ID)
+	assert.Equal(t, "fetch-v1", mustResolve(t, r, "fetch").ID)
+
+	// 没有可比较的版本号时不猜
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{Name: "calc", Version: "stable"}))
+	_, err := r.Resolve(ctx, "calc")
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err))
+	assert.Equal(t, "stable", mustResolve(t, r, "calc@stable").Version)
+
+	// 有 semver 版本时仍取最高的正式版
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{Name: "calc", Version: "1.0.0"}))
+	assert.Equal(t, "1.0.0", mustResolve(t, r, "calc").Version)
+}
+
+func TestPinRef(t *testing.T) {
+	ctx := context.Background()
+	r := NewInMemRegistry()
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{Name: "search", Version: "1.0.0"}))
+	require.NoError(t, r.Register(ctx, &apis.ToolSpec{ID: "adder"}))
+
+	// 有 Name 时按 Name，没有时按 ID，都能解析回同一个工具
+	for _, ref := range []string{"search", "adder"} {
+		spec := mustResolve(t, r, ref)
+		pinned := PinRef(spec.ID, spec.Name, spec.Digest)
+		assert.Equal(t, spec.ID, mustResolve(t, r, pinned).ID, pinned)
+	}
+	assert.Equal(t, "search@sha256:ab", PinRef("search@1.0.0", "search", "sha256:ab"))
+
+	err := r.Register(ctx, &apis.ToolSpec{ID: "adder@1"})
+	assert.Equal(t, errors.KindValidation, errors.KindOf(err), "unresolvable without a name")
+}
+
+func mustResolve(t *testing.T, r Registry, ref string) *apis.ToolSpec {
+	t.Helper()
+	spec, err := r.Resolve(context.Background(), ref)
+	require.NoError(t, err, ref)
+	return spec
+}
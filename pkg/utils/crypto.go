@@ -2,11 +2,11 @@
 package utils
 
 import (
-	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"strings"
 	"time"
 )
 
@@ -168,34 +169,62 @@ func SecureToken() (string, error) {
 
 type Claims map[string]interface{}
 
-// SignHS256 签名； key == []byte
+// hs256Header 固定的 JWT 头
+var hs256Header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// SignHS256 以 HMAC-SHA256(secret) 对 header.payload 签名
 func SignHS256(claims Claims, secret []byte) (string, error) {
-	header := base64.RawURLEncoding.EncodeToString([]byte(`{\"alg\":\"HS256\",\"typ\":\"JWT\"}`))
-	payload, _ := json.Marshal(claims)
-	body := base64.RawURLEncoding.EncodeToString(payload)
-	mac := sha256.Sum256([]byte(header + "." + body))
-	return header + "." + body + "." + base64.RawURLEncoding.EncodeToString(mac[:]), nil
+	if len(secret) == 0 {
+		return "", errors.New("empty secret")
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signing := hs256Header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signing + "." + base64.RawURLEncoding.EncodeToString(hs256(signing, secret)), nil
 }
 
-// VerifyHS256 解析 & 校验签名，返回 Claims
+// VerifyHS256 解析 & 校验签名，带 exp 时一并校验是否过期，返回 Claims
 func VerifyHS256(token string, secret []byte) (Claims, error) {
-	parts := bytes.Split([]byte(token), []byte{'.'})
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
 		return nil, errors.New("invalid JWT")
 	}
-	mac := sha256.Sum256([]byte(parts[0])) // 再次签名
-	expected := base64.RawURLEncoding.EncodeToString(mac[:])
-	if string(parts[2]) != expected {
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(raw, &header) != nil || header.Alg != "HS256" {
+		return nil, errors.New("invalid JWT header")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, hs256(parts[0]+"."+parts[1], secret)) {
 		return nil, errors.New("invalid signature")
 	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
 	var c Claims
-	body, _ := base64.RawURLEncoding.DecodeString(string(parts[1]))
 	if err := json.Unmarshal(body, &c); err != nil {
 		return nil, err
 	}
+	if exp, ok := c["exp"].(float64); ok && time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("token expired")
+	}
 	return c, nil
 }
 
+func hs256(signing string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signing))
+	return mac.Sum(nil)
+}
+
 // ------------------------------------------------------------------
 // KeyRotation 内存轻量轮换示例
 // ------------------------------------------------------------------
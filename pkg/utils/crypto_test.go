@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHS256(t *testing.T) {
+	secret := []byte("s3cret")
+	tok, err := SignHS256(Claims{"sub": "alice", "exp": time.Now().Add(time.Minute).Unix()}, secret)
+	require.NoError(t, err)
+
+	c, err := VerifyHS256(tok, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", c["sub"])
+
+	_, err = VerifyHS256(tok, []byte("other"))
+	assert.Error(t, err, "signature must depend on the secret")
+	parts := strings.Split(tok, ".")
+	forged, _ := SignHS256(Claims{"sub": "mallory"}, []byte("other"))
+	_, err = VerifyHS256(parts[0]+"."+strings.Split(forged, ".")[1]+"."+parts[2], secret)
+	assert.Error(t, err, "payload is covered by the signature")
+
+	expired, err := SignHS256(Claims{"sub": "alice", "exp": time.Now().Add(-time.Minute).Unix()}, secret)
+	require.NoError(t, err)
+	_, err = VerifyHS256(expired, secret)
+	assert.Error(t, err)
+	_, err = SignHS256(Claims{}, nil)
+	assert.Error(t, err)
+
+	// 空密钥签出的 token 不被接受
+	unsigned := parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(hs256(parts[0]+"."+parts[1], nil))
+	_, err = VerifyHS256(unsigned, nil)
+	assert.Error(t, err)
+	_, err = VerifyHS256(unsigned, []byte{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+// pkg/webhook/agent_webhook.go
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/pkg/apis"
+)
+
+// maxMIGInstances 单块 GPU 最多切分的 MIG 实例数
+const maxMIGInstances = 7
+
+// sandboxTypes 支持的沙箱类型
+var sandboxTypes = []string{"gvisor", "kata", "firecracker"}
+
+type agentWebhook struct {
+	opts   Options
+	images *regexp.Regexp
+}
+
+//+kubebuilder:webhook:path=/mutate-agenticai-io-v1-agent,mutating=true,failurePolicy=fail,sideEffects=None,groups=agenticai.io,resources=agents,verbs=create;update,versions=v1,name=magent.agenticai.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-agenticai-io-v1-agent,mutating=false,failurePolicy=fail,sideEffects=None,groups=agenticai.io,resources=agents,verbs=create;update,versions=v1,name=vagent.agenticai.io,admissionReviewVersions=v1
+
+// Default 补齐沙箱类型与资源请求
+func (w *agentWebhook) Default(_ context.Context, obj runtime.Object) error {
+	agent, ok := obj.(*apis.Agent)
+	if !ok {
+		return fmt.Errorf("expected an Agent but got %T", obj)
+	}
+	DefaultAgentSpec(&agent.Spec, w.opts.Sandbox)
+	return nil
+}
+
+// DefaultAgentSpec 补齐沙箱类型与资源请求，已设置的不覆盖。
+// 未启用 webhook 时控制器在生成 Deployment 前对副本调用，结果不写回 Agent
+func DefaultAgentSpec(spec *apis.AgentSpec, sb config.Sandbox) {
+	if spec.Sandbox.Type == "" {
+		spec.Sandbox.Type = sb.Type
+		if spec.Sandbox.Type == "" {
+			spec.Sandbox.Type = constants.DefaultSandboxType
+		}
+	}
+	defaultRequests(&spec.Resources, sb)
+}
+
+func (w *agentWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, w.validate(nil, obj)
+}
+
+// ValidateUpdate 镜像白名单收紧后，未改镜像的 Agent 仍可更新（如缩容）
+func (w *agentWebhook) ValidateUpdate(_ context.Context, oldObj, obj runtime.Object) (admission.Warnings, error) {
+	old, ok := oldObj.(*apis.Agent)
+	if !ok {
+		return nil, fmt.Errorf("expected an Agent but got %T", oldObj)
+	}
+	return nil, w.validate(old, obj)
+}
+
+func (w *agentWebhook) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate old 为 nil 表示创建
+func (w *agentWebhook) validate(old *apis.Agent, obj runtime.Object) error {
+	agent, ok := obj.(*apis.Agent)
+	if !ok {
+		return fmt.Errorf("expected an Agent but got %T", obj)
+	}
+	spec := field.NewPath("spec")
+	var errs field.ErrorList
+	if old == nil || old.Spec.ImageRef != agent.Spec.ImageRef {
+		errs = append(errs, validateImage(w.images, spec.Child("imageRef"), agent.Spec.ImageRef)...)
+	}
+	if agent.Spec.Replicas < 0 {
+		errs = append(errs, field.Invalid(spec.Child("replicas"), agent.Spec.Replicas, "must be non-negative"))
+	}
+	if t := agent.Spec.Sandbox.Type; t != "" && !contains(sandboxTypes, t) {
+		errs = append(errs, field.NotSupported(spec.Child("sandbox", "type"), t, sandboxTypes))
+	}
+	errs = append(errs, validateResources(spec.Child("resources"), agent.Spec.Resources)...)
+	errs = append(errs, validateGPU(spec.Child("gpu"), agent.Spec.GPU)...)
+	if len(errs) > 0 {
+		return apierrs.NewInvalid(apis.SchemeGroupVersion.WithKind("Agent").GroupKind(), agent.Name, errs)
+	}
+	return nil
+}
+
+// validateGPU MIG 模式下 Device 为 MIG 规格（如 1g.10gb），Count 为实例数
+func validateGPU(path *field.Path, gpu apis.AgentGPU) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case gpu.Count < 0:
+		errs = append(errs, field.Invalid(path.Child("count"), gpu.Count, "must be non-negative"))
+	case gpu.MIGMode && gpu.Count == 0:
+		errs = append(errs, field.Required(path.Child("count"), "migMode requires at least one MIG instance"))
+	case gpu.MIGMode && gpu.Count > maxMIGInstances:
+		errs = append(errs, field.Invalid(path.Child("count"), gpu.Count,
+			fmt.Sprintf("at most %d MIG instances per GPU", maxMIGInstances)))
+	case !gpu.MIGMode && gpu.Count == 0 && gpu.Device != "":
+		errs = append(errs, field.Invalid(path.Child("device"), gpu.Device, "device requires count > 0"))
+	}
+	if gpu.MIGMode && gpu.Device == "" {
+		errs = append(errs, field.Required(path.Child("device"), "migMode requires a MIG profile such as 1g.10gb"))
+	}
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+//Personal.AI order the ending
@@ -0,0 +1,201 @@
+// pkg/webhook/task_webhook.go
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/internal/errors"
+	agenticaiov1 "github.com/turtacn/agenticai/pkg/apis/agenticai.io/v1"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+// taskPhases 依赖可以等待的任务阶段
+var taskPhases = []string{
+	string(agenticaiov1.TaskPending), string(agenticaiov1.TaskScheduled), string(agenticaiov1.TaskRunning),
+	string(agenticaiov1.TaskCompleted), string(agenticaiov1.TaskFailed), string(agenticaiov1.TaskCancelled),
+}
+
+type taskWebhook struct {
+	opts   Options
+	images *regexp.Regexp
+}
+
+//+kubebuilder:webhook:path=/mutate-agenticai-io-v1-task,mutating=true,failurePolicy=fail,sideEffects=None,groups=agenticai.io,resources=tasks,verbs=create;update,versions=v1,name=mtask.agenticai.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-agenticai-io-v1-task,mutating=false,failurePolicy=fail,sideEffects=None,groups=agenticai.io,resources=tasks,verbs=create;update,versions=v1,name=vtask.agenticai.io,admissionReviewVersions=v1
+
+// Default 补齐超时与资源请求
+func (w *taskWebhook) Default(_ context.Context, obj runtime.Object) error {
+	task, ok := obj.(*agenticaiov1.Task)
+	if !ok {
+		return fmt.Errorf("expected a Task but got %T", obj)
+	}
+	if task.Spec.Timeout.Duration == 0 {
+		task.Spec.Timeout = metav1.Duration{Duration: constants.DefaultTaskTimeout}
+	}
+	defaultRequests(&task.Spec.Resources, w.opts.Sandbox)
+	return nil
+}
+
+func (w *taskWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, w.validate(ctx, nil, obj)
+}
+
+// ValidateUpdate 镜像、工具与依赖未变更时不再校验，白名单收紧或工具下线不会阻塞状态以外的更新
+func (w *taskWebhook) ValidateUpdate(ctx context.Context, oldObj, obj runtime.Object) (admission.Warnings, error) {
+	old, ok := oldObj.(*agenticaiov1.Task)
+	if !ok {
+		return nil, fmt.Errorf("expected a Task but got %T", oldObj)
+	}
+	return nil, w.validate(ctx, old, obj)
+}
+
+func (w *taskWebhook) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate old 为 nil 表示创建
+func (w *taskWebhook) validate(ctx context.Context, old *agenticaiov1.Task, obj runtime.Object) error {
+	task, ok := obj.(*agenticaiov1.Task)
+	if !ok {
+		return fmt.Errorf("expected a Task but got %T", obj)
+	}
+	spec := field.NewPath("spec")
+	var errs field.ErrorList
+	if old == nil || old.Spec.ImageRef != task.Spec.ImageRef {
+		errs = append(errs, validateImage(w.images, spec.Child("imageRef"), task.Spec.ImageRef)...)
+	}
+	if task.Spec.Timeout.Duration < 0 {
+		errs = append(errs, field.Invalid(spec.Child("timeout"), task.Spec.Timeout.Duration.String(), "must be non-negative"))
+	}
+	if task.Spec.RetryPolicy.Limit < 0 {
+		errs = append(errs, field.Invalid(spec.Child("retryPolicy", "limit"), task.Spec.RetryPolicy.Limit, "must be non-negative"))
+	}
+	if task.Spec.MaxConcurrency < 0 {
+		errs = append(errs, field.Invalid(spec.Child("maxConcurrency"), task.Spec.MaxConcurrency, "must be non-negative"))
+	}
+	errs = append(errs, validateResources(spec.Child("resources"), task.Spec.Resources)...)
+	if old == nil || !slices.Equal(old.Spec.Tools, task.Spec.Tools) {
+		toolErrs, err := w.validateTools(ctx, spec.Child("tools"), task.Spec.Tools)
+		if err != nil {
+			return apierrs.NewInternalError(err)
+		}
+		errs = append(errs, toolErrs...)
+	}
+	if old == nil || !slices.Equal(old.Spec.Dependencies, task.Spec.Dependencies) {
+		depErrs, err := w.validateDependencies(ctx, spec.Child("dependencies"), task)
+		if err != nil {
+			return apierrs.NewInternalError(err)
+		}
+		errs = append(errs, depErrs...)
+	}
+	if len(errs) > 0 {
+		return apierrs.NewInvalid(agenticaiov1.GroupVersion.WithKind("Task").GroupKind(), task.Name, errs)
+	}
+	return nil
+}
+
+// validateTools 引用格式非法或工具未注册时拒绝；注册表不可用时返回 error
+func (w *taskWebhook) validateTools(ctx context.Context, path *field.Path, refs []string) (field.ErrorList, error) {
+	var errs field.ErrorList
+	seen := make(map[string]bool, len(refs))
+	for i, ref := range refs {
+		p := path.Index(i)
+		if seen[ref] {
+			errs = append(errs, field.Duplicate(p, ref))
+			continue
+		}
+		seen[ref] = true
+		if _, err := tools.ParseToolRef(ref); err != nil {
+			errs = append(errs, field.Invalid(p, ref, err.Error()))
+			continue
+		}
+		if w.opts.Tools == nil {
+			continue
+		}
+		_, err := w.opts.Tools.Resolve(ctx, ref)
+		switch {
+		case err == nil:
+		case errors.KindOf(err) == errors.KindNotFound:
+			errs = append(errs, field.NotFound(p, ref))
+		case errors.KindOf(err) == errors.KindValidation:
+			errs = append(errs, field.Invalid(p, ref, err.Error()))
+		default:
+			return nil, err
+		}
+	}
+	return errs, nil
+}
+
+// validateDependencies 依赖的任务可以尚未创建，但不能构成环
+func (w *taskWebhook) validateDependencies(ctx context.Context, path *field.Path, task *agenticaiov1.Task) (field.ErrorList, error) {
+	var errs field.ErrorList
+	for i, dep := range task.Spec.Dependencies {
+		p := path.Index(i)
+		switch {
+		case dep.TaskID == "":
+			errs = append(errs, field.Required(p.Child("taskId"), ""))
+		case dep.TaskID == task.Name:
+			errs = append(errs, field.Invalid(p.Child("taskId"), dep.TaskID, "a task cannot depend on itself"))
+		}
+		if !contains(taskPhases, string(dep.State)) {
+			errs = append(errs, field.NotSupported(p.Child("state"), dep.State, taskPhases))
+		}
+	}
+	if len(errs) > 0 || len(task.Spec.Dependencies) == 0 || w.opts.Reader == nil {
+		return errs, nil
+	}
+
+	var list agenticaiov1.TaskList
+	if err := w.opts.Reader.List(ctx, &list, client.InNamespace(task.Namespace)); err != nil {
+		return nil, err
+	}
+	graph := make(map[string][]string, len(list.Items)+1)
+	for _, t := range list.Items {
+		for _, d := range t.Spec.Dependencies {
+			graph[t.Name] = append(graph[t.Name], d.TaskID)
+		}
+	}
+	graph[task.Name] = nil
+	for _, d := range task.Spec.Dependencies {
+		graph[task.Name] = append(graph[task.Name], d.TaskID)
+	}
+	for i, dep := range task.Spec.Dependencies {
+		if cycle := findPath(graph, dep.TaskID, task.Name, map[string]bool{}); cycle != nil {
+			chain := append([]string{task.Name}, cycle...)
+			errs = append(errs, field.Invalid(path.Index(i).Child("taskId"), dep.TaskID,
+				"dependency cycle: "+strings.Join(chain, " -> ")))
+		}
+	}
+	return errs, nil
+}
+
+// findPath 深度优先查找 from 到 to 的依赖路径，找不到返回 nil
+func findPath(graph map[string][]string, from, to string, visited map[string]bool) []string {
+	if from == to {
+		return []string{to}
+	}
+	if visited[from] {
+		return nil
+	}
+	visited[from] = true
+	for _, next := range graph[from] {
+		if rest := findPath(graph, next, to, visited); rest != nil {
+			return append([]string{from}, rest...)
+		}
+	}
+	return nil
+}
+
+//Personal.AI order the ending
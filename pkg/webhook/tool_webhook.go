@@ -0,0 +1,97 @@
+// pkg/webhook/tool_webhook.go
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/turtacn/agenticai/pkg/apis"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+type toolWebhook struct {
+	images *regexp.Regexp
+}
+
+//+kubebuilder:webhook:path=/mutate-agenticai-io-v1-tool,mutating=true,failurePolicy=fail,sideEffects=None,groups=agenticai.io,resources=tools,verbs=create;update,versions=v1,name=mtool.agenticai.io,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-agenticai-io-v1-tool,mutating=false,failurePolicy=fail,sideEffects=None,groups=agenticai.io,resources=tools,verbs=create;update,versions=v1,name=vtool.agenticai.io,admissionReviewVersions=v1
+
+// Default 未指定 ID 时与注册表一致地取 name@version
+func (w *toolWebhook) Default(_ context.Context, obj runtime.Object) error {
+	tool, ok := obj.(*apis.Tool)
+	if !ok {
+		return fmt.Errorf("expected a Tool but got %T", obj)
+	}
+	if s := &tool.Spec; s.ID == "" && s.Name != "" {
+		s.ID = s.Name
+		if s.Version != "" {
+			s.ID += "@" + s.Version
+		}
+	}
+	return nil
+}
+
+func (w *toolWebhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, w.validate(obj)
+}
+
+func (w *toolWebhook) ValidateUpdate(_ context.Context, _, obj runtime.Object) (admission.Warnings, error) {
+	return nil, w.validate(obj)
+}
+
+func (w *toolWebhook) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *toolWebhook) validate(obj runtime.Object) error {
+	tool, ok := obj.(*apis.Tool)
+	if !ok {
+		return fmt.Errorf("expected a Tool but got %T", obj)
+	}
+	spec := field.NewPath("spec")
+	s := &tool.Spec
+	var errs field.ErrorList
+	if s.Name == "" {
+		errs = append(errs, field.Required(spec.Child("name"), ""))
+	}
+	if s.Digest != "" {
+		if want := tools.ComputeDigest(s); s.Digest != want {
+			errs = append(errs, field.Invalid(spec.Child("digest"), s.Digest, "does not match content digest "+want))
+		}
+	}
+	if s.DefaultTimeout.Duration < 0 {
+		errs = append(errs, field.Invalid(spec.Child("defaultTimeout"), s.DefaultTimeout.Duration.String(), "must be non-negative"))
+	}
+	if s.MCP != nil {
+		errs = append(errs, validateURL(spec.Child("mcp", "serverUrl"), s.MCP.ServerURL)...)
+	}
+	if s.OpenAPI != nil {
+		errs = append(errs, validateURL(spec.Child("openapi", "specUrl"), s.OpenAPI.SpecURL)...)
+	}
+	if s.CustomExec != nil {
+		errs = append(errs, validateImage(w.images, spec.Child("custom", "image"), s.CustomExec.Image)...)
+	}
+	if len(errs) > 0 {
+		return apierrs.NewInvalid(apis.SchemeGroupVersion.WithKind("Tool").GroupKind(), tool.Name, errs)
+	}
+	return nil
+}
+
+func validateURL(path *field.Path, raw string) field.ErrorList {
+	if raw == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	if u, err := url.Parse(raw); err != nil || u.Scheme == "" || u.Host == "" {
+		return field.ErrorList{field.Invalid(path, raw, "must be an absolute URL")}
+	}
+	return nil
+}
+
+//Personal.AI order the ending
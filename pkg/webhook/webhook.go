@@ -0,0 +1,127 @@
+// pkg/webhook/webhook.go
+package webhook
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/pkg/apis"
+	agenticaiov1 "github.com/turtacn/agenticai/pkg/apis/agenticai.io/v1"
+	"github.com/turtacn/agenticai/pkg/security"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+// Options 准入 webhook 的默认值与校验依据
+type Options struct {
+	// Sandbox 未指定沙箱类型与资源请求时的默认值，空字段取 constants 中的默认值
+	Sandbox config.Sandbox
+	// AllowedImages 镜像 glob 白名单，空为不限制
+	AllowedImages []string
+	// Tools 校验 Task 引用的工具是否已注册，为 nil 时只校验引用格式
+	Tools tools.Registry
+	// Reader 读取同命名空间的 Task 以检测依赖环，为 nil 时只检测自依赖
+	Reader client.Reader
+}
+
+// Setup 为 Agent、Task、Tool 注册 defaulting 与 validating webhook
+func Setup(mgr ctrl.Manager, opts Options) error {
+	images, err := compileImages(opts.AllowedImages)
+	if err != nil {
+		return err
+	}
+	agent := &agentWebhook{opts: opts, images: images}
+	task := &taskWebhook{opts: opts, images: images}
+	tool := &toolWebhook{images: images}
+	if err := ctrl.NewWebhookManagedBy(mgr).For(&apis.Agent{}).
+		WithDefaulter(agent).WithValidator(agent).Complete(); err != nil {
+		return fmt.Errorf("setup agent webhook: %w", err)
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).For(&agenticaiov1.Task{}).
+		WithDefaulter(task).WithValidator(task).Complete(); err != nil {
+		return fmt.Errorf("setup task webhook: %w", err)
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).For(&apis.Tool{}).
+		WithDefaulter(tool).WithValidator(tool).Complete(); err != nil {
+		return fmt.Errorf("setup tool webhook: %w", err)
+	}
+	return nil
+}
+
+func compileImages(globs []string) (*regexp.Regexp, error) {
+	if len(globs) == 0 {
+		return nil, nil
+	}
+	re, err := security.CompileGlobs(globs)
+	if err != nil {
+		return nil, fmt.Errorf("allowed images: %w", err)
+	}
+	return re, nil
+}
+
+// validateImage images 为 nil 时不限制
+func validateImage(images *regexp.Regexp, path *field.Path, image string) field.ErrorList {
+	switch {
+	case image == "":
+		return field.ErrorList{field.Required(path, "")}
+	case images != nil && !images.MatchString(image):
+		return field.ErrorList{field.Forbidden(path, fmt.Sprintf("image %q is not in the allowed image list", image))}
+	}
+	return nil
+}
+
+// defaultRequests 补齐 CPU 与内存请求，已设置的不覆盖；默认值超过 limit 时取 limit
+func defaultRequests(res *corev1.ResourceRequirements, sb config.Sandbox) {
+	if res.Requests == nil {
+		res.Requests = corev1.ResourceList{}
+	}
+	defaults := map[corev1.ResourceName]resource.Quantity{
+		corev1.ResourceCPU:    quantityOr(sb.CPULimit, constants.DefaultSandboxCPU),
+		corev1.ResourceMemory: quantityOr(sb.MemoryLimit, constants.DefaultSandboxMemory),
+	}
+	for name, def := range defaults {
+		if _, ok := res.Requests[name]; ok {
+			continue
+		}
+		if limit, ok := res.Limits[name]; ok && def.Cmp(limit) > 0 {
+			def = limit.DeepCopy()
+		}
+		res.Requests[name] = def
+	}
+}
+
+// validateResources 请求不能超过同名资源的 limit
+func validateResources(path *field.Path, res corev1.ResourceRequirements) field.ErrorList {
+	names := make([]string, 0, len(res.Requests))
+	for name := range res.Requests {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	var errs field.ErrorList
+	for _, name := range names {
+		req := res.Requests[corev1.ResourceName(name)]
+		if limit, ok := res.Limits[corev1.ResourceName(name)]; ok && req.Cmp(limit) > 0 {
+			errs = append(errs, field.Invalid(path.Child("requests").Key(name), req.String(),
+				fmt.Sprintf("must be less than or equal to %s limit %s", name, limit.String())))
+		}
+	}
+	return errs
+}
+
+// quantityOr 配置值非法时退回默认值
+func quantityOr(v, def string) resource.Quantity {
+	if q, err := resource.ParseQuantity(v); err == nil && !q.IsZero() {
+		return q
+	}
+	return resource.MustParse(def)
+}
+
+//Personal.AI order the ending
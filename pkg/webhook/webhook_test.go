@@ -0,0 +1,227 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/turtacn/agenticai/internal/config"
+	"github.com/turtacn/agenticai/internal/constants"
+	"github.com/turtacn/agenticai/pkg/apis"
+	agenticaiov1 "github.com/turtacn/agenticai/pkg/apis/agenticai.io/v1"
+	"github.com/turtacn/agenticai/pkg/tools"
+)
+
+// invalidFields 返回 Invalid 错误中的字段路径
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+	require.True(t, apierrs.IsInvalid(err), "%v", err)
+	var fields []string
+	for _, c := range err.(apierrs.APIStatus).Status().Details.Causes {
+		fields = append(fields, c.Field)
+	}
+	return fields
+}
+
+func TestAgentWebhook(t *testing.T) {
+	images, err := compileImages([]string{"registry.example.com/*"})
+	require.NoError(t, err)
+	w := &agentWebhook{opts: Options{Sandbox: config.Sandbox{Type: "kata", CPULimit: "250m"}}, images: images}
+	ctx := context.Background()
+
+	agent := &apis.Agent{Spec: apis.AgentSpec{
+		ImageRef:  "registry.example.com/agents/coder:1",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}},
+	}}
+	require.NoError(t, w.Default(ctx, agent))
+	assert.Equal(t, "kata", agent.Spec.Sandbox.Type)
+	assert.Equal(t, "250m", agent.Spec.Resources.Requests.Cpu().String())
+	assert.Equal(t, "1Gi", agent.Spec.Resources.Requests.Memory().String(), "explicit requests are kept")
+	_, err = w.ValidateCreate(ctx, agent)
+	require.NoError(t, err)
+
+	bad := agent.DeepCopy()
+	bad.Spec.ImageRef = "docker.io/evil:latest"
+	bad.Spec.Sandbox.Type = "docker"
+	bad.Spec.GPU = apis.AgentGPU{MIGMode: true, Count: 8}
+	_, err = w.ValidateUpdate(ctx, agent, bad)
+	assert.ElementsMatch(t, []string{"spec.imageRef", "spec.sandbox.type", "spec.gpu.count", "spec.gpu.device"}, invalidFields(t, err))
+
+	bad = agent.DeepCopy()
+	bad.Spec.GPU = apis.AgentGPU{MIGMode: true}
+	_, err = w.ValidateCreate(ctx, bad)
+	assert.ElementsMatch(t, []string{"spec.gpu.count", "spec.gpu.device"}, invalidFields(t, err))
+
+	ok := agent.DeepCopy()
+	ok.Spec.GPU = apis.AgentGPU{MIGMode: true, Count: 2, Device: "1g.10gb"}
+	_, err = w.ValidateCreate(ctx, ok)
+	assert.NoError(t, err)
+
+	// 默认请求不超过 limit，显式请求超过 limit 时拒绝
+	limited := &apis.Agent{Spec: apis.AgentSpec{
+		ImageRef: "registry.example.com/agents/coder:1",
+		Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("4Gi"),
+		}},
+	}}
+	require.NoError(t, w.Default(ctx, limited))
+	assert.Equal(t, "100m", limited.Spec.Resources.Requests.Cpu().String())
+	assert.Equal(t, constants.DefaultSandboxMemory, limited.Spec.Resources.Requests.Memory().String())
+	_, err = w.ValidateCreate(ctx, limited)
+	require.NoError(t, err)
+
+	limited.Spec.Resources.Requests[corev1.ResourceCPU] = resource.MustParse("1")
+	limited.Spec.Resources.Requests[corev1.ResourceMemory] = resource.MustParse("8Gi")
+	_, err = w.ValidateCreate(ctx, limited)
+	assert.Equal(t, []string{"spec.resources.requests[cpu]", "spec.resources.requests[memory]"}, invalidFields(t, err))
+
+	// 白名单收紧后，未改镜像的更新仍放行，改镜像时按新白名单校验
+	w.images, err = compileImages([]string{"registry.internal/*"})
+	require.NoError(t, err)
+	scaled := agent.DeepCopy()
+	scaled.Spec.Replicas = 3
+	_, err = w.ValidateUpdate(ctx, agent, scaled)
+	assert.NoError(t, err)
+	scaled.Spec.ImageRef = "registry.example.com/agents/coder:2"
+	_, err = w.ValidateUpdate(ctx, agent, scaled)
+	assert.Equal(t, []string{"spec.imageRef"}, invalidFields(t, err))
+}
+
+func TestTaskWebhook(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, agenticaiov1.AddToScheme(s))
+	dep := func(ids ...string) []agenticaiov1.Dependency {
+		out := make([]agenticaiov1.Dependency, len(ids))
+		for i, id := range ids {
+			out[i] = agenticaiov1.Dependency{TaskID: id, State: agenticaiov1.TaskCompleted}
+		}
+		return out
+	}
+	// 已有 b -> c -> a
+	existing := []*agenticaiov1.Task{
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}, Spec: agenticaiov1.TaskSpec{Dependencies: dep("c")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "default"}, Spec: agenticaiov1.TaskSpec{Dependencies: dep("a")}},
+	}
+	cb := fake.NewClientBuilder().WithScheme(s)
+	for _, e := range existing {
+		cb = cb.WithObjects(e)
+	}
+	reg := tools.NewInMemRegistry()
+	ctx := context.Background()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{Name: "search", Version: "1.0.0"}))
+	w := &taskWebhook{opts: Options{Tools: reg, Reader: cb.Build()}}
+
+	task := &agenticaiov1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+		Spec:       agenticaiov1.TaskSpec{ImageRef: "busybox", Tools: []string{"search@^1.0"}},
+	}
+	require.NoError(t, w.Default(ctx, task))
+	assert.Equal(t, constants.DefaultTaskTimeout, task.Spec.Timeout.Duration)
+	assert.Equal(t, constants.DefaultSandboxCPU, task.Spec.Resources.Requests.Cpu().String())
+	_, err := w.ValidateCreate(ctx, task)
+	require.NoError(t, err)
+
+	// a -> b -> c -> a
+	cyclic := task.DeepCopy()
+	cyclic.Spec.Dependencies = dep("b")
+	_, err = w.ValidateUpdate(ctx, task, cyclic)
+	assert.Equal(t, []string{"spec.dependencies[0].taskId"}, invalidFields(t, err))
+	assert.Contains(t, err.Error(), "a -> b -> c -> a")
+
+	// 依赖尚未创建的任务是允许的
+	pending := task.DeepCopy()
+	pending.Spec.Dependencies = dep("later")
+	_, err = w.ValidateCreate(ctx, pending)
+	assert.NoError(t, err)
+
+	bad := task.DeepCopy()
+	bad.Spec.ImageRef = ""
+	bad.Spec.Timeout.Duration = -time.Second
+	bad.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}
+	bad.Spec.Tools = []string{"missing", "search@", "search@^1.0", "search@^1.0"}
+	bad.Spec.Dependencies = []agenticaiov1.Dependency{{TaskID: "a", State: agenticaiov1.TaskCompleted}, {TaskID: "x", State: "Done"}}
+	_, err = w.ValidateCreate(ctx, bad)
+	assert.ElementsMatch(t, []string{
+		"spec.imageRef", "spec.timeout", "spec.resources.requests[cpu]", "spec.tools[0]", "spec.tools[1]", "spec.tools[3]",
+		"spec.dependencies[0].taskId", "spec.dependencies[1].state",
+	}, invalidFields(t, err))
+}
+
+func TestToolWebhook(t *testing.T) {
+	images, err := compileImages([]string{"registry.example.com/{tools,agents}/*"})
+	require.NoError(t, err)
+	w := &toolWebhook{images: images}
+	ctx := context.Background()
+
+	tool := &apis.Tool{Spec: apis.ToolSpec{
+		Name: "search", Version: "1.0.0",
+		MCP:        &apis.MCPBinding{ServerURL: "http://mcp.internal"},
+		CustomExec: &apis.CustomBinding{Image: "registry.example.com/tools/search:1"},
+	}}
+	require.NoError(t, w.Default(ctx, tool))
+	assert.Equal(t, "search@1.0.0", tool.Spec.ID)
+	tool.Spec.Digest = tools.ComputeDigest(&tool.Spec)
+	_, err = w.ValidateCreate(ctx, tool)
+	require.NoError(t, err)
+
+	bad := tool.DeepCopy()
+	bad.Spec.Description = "changed without updating the digest"
+	bad.Spec.MCP.ServerURL = "mcp.internal"
+	bad.Spec.OpenAPI = &apis.OpenAPIBinding{}
+	bad.Spec.CustomExec.Image = "docker.io/search:1"
+	_, err = w.ValidateUpdate(ctx, tool, bad)
+	assert.ElementsMatch(t, []string{"spec.digest", "spec.mcp.serverUrl", "spec.openapi.specUrl", "spec.custom.image"},
+		invalidFields(t, err))
+}
+
+func TestTaskWebhookUpdate(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, agenticaiov1.AddToScheme(s))
+	reg := tools.NewInMemRegistry()
+	ctx := context.Background()
+	require.NoError(t, reg.Register(ctx, &apis.ToolSpec{ID: "search", Name: "search", Version: "1.0.0"}))
+	w := &taskWebhook{opts: Options{Tools: reg, Reader: fake.NewClientBuilder().WithScheme(s).Build()}}
+
+	old := &agenticaiov1.Task{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+		Spec: agenticaiov1.TaskSpec{
+			ImageRef: "docker.io/old:1", Tools: []string{"search@^1.0"},
+			Dependencies: []agenticaiov1.Dependency{{TaskID: "b", State: agenticaiov1.TaskCompleted}},
+		},
+	}
+	_, err := w.ValidateCreate(ctx, old)
+	require.NoError(t, err)
+
+	// 创建后工具下线、镜像白名单收紧，不影响未改动这些字段的更新
+	require.NoError(t, reg.Deregister(ctx, "search"))
+	w.images, err = compileImages([]string{"registry.example.com/*"})
+	require.NoError(t, err)
+	updated := old.DeepCopy()
+	updated.Spec.Timeout.Duration = time.Minute
+	_, err = w.ValidateUpdate(ctx, old, updated)
+	assert.NoError(t, err)
+
+	changed := updated.DeepCopy()
+	changed.Spec.ImageRef = "docker.io/new:1"
+	changed.Spec.Tools = []string{"search@^1.0", "fetch"}
+	changed.Spec.Dependencies = append(changed.Spec.Dependencies, agenticaiov1.Dependency{TaskID: "a", State: agenticaiov1.TaskCompleted})
+	_, err = w.ValidateUpdate(ctx, old, changed)
+	assert.ElementsMatch(t, []string{
+		"spec.imageRef", "spec.tools[0]", "spec.tools[1]", "spec.dependencies[1].taskId",
+	}, invalidFields(t, err))
+
+	// 其余字段每次更新都校验
+	negative := updated.DeepCopy()
+	negative.Spec.Timeout.Duration = -time.Second
+	_, err = w.ValidateUpdate(ctx, old, negative)
+	assert.Equal(t, []string{"spec.timeout"}, invalidFields(t, err))
+}